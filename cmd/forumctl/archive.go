@@ -0,0 +1,163 @@
+package main
+
+import (
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"github.com/jackc/pgx"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+	"tp-db-forum/internal/app"
+	"tp-db-forum/internal/app/models"
+)
+
+func exportForum(useCase app.UseCase, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	slug := flags.String("forum", "", "slug of the forum to export")
+	output := flags.String("o", "", "archive file; standard output by default")
+	flags.Parse(args)
+
+	if *slug == "" {
+		return errUsage
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		out = file
+	}
+
+	if err := useCase.ExportForum(*slug, out); err != nil {
+		if err == pgx.ErrNoRows {
+			return fmt.Errorf("forum %q not found", *slug)
+		}
+
+		return err
+	}
+
+	if file, ok := out.(*os.File); ok && file != os.Stdout {
+		return file.Close()
+	}
+
+	return nil
+}
+
+func importForum(useCase app.UseCase, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	slug := flags.String("slug", "", "import the forum under this slug")
+	users := flags.String("users", models.ConflictSkip, "existing users: skip, overwrite or fail")
+	slugs := flags.String("slugs", models.ConflictFail, "taken forum and thread slugs: fail or rename")
+	dryRun := flags.Bool("dry-run", false, "only report what would be imported")
+	flags.Parse(args)
+
+	var in io.Reader = os.Stdin
+	switch flags.NArg() {
+	case 0:
+	case 1:
+		if flags.Arg(0) != "-" {
+			file, err := os.Open(flags.Arg(0))
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			in = file
+		}
+	default:
+		return errUsage
+	}
+
+	report, err := useCase.ImportForum(in, models.ImportOptions{
+		Slug:   *slug,
+		Users:  *users,
+		Slugs:  *slugs,
+		DryRun: *dryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	return output(report)
+}
+
+// openSource opens a file to load, decompressing it if it's gzipped.
+func openSource(name string) (io.ReadCloser, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasSuffix(name, ".gz") {
+		return file, nil
+	}
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{reader, file}, nil
+}
+
+func bulkLoad(useCase app.UseCase, args []string) error {
+	flags := flag.NewFlagSet("load", flag.ExitOnError)
+	names := map[string]*string{
+		"users":      flags.String("users", "", "CSV of users: nickname, fullname, about, email"),
+		"forums":     flags.String("forums", "", "CSV of forums: slug, title, user"),
+		"threads":    flags.String("threads", "", "CSV of threads: id, slug, author, forum, title, message, created"),
+		"posts":      flags.String("posts", "", "CSV of posts: id, parent, thread, author, message, created, isEdited"),
+		"votes":      flags.String("votes", "", "CSV of votes: nickname, thread, voice"),
+		"post_votes": flags.String("post-votes", "", "CSV of post votes: nickname, post, voice"),
+	}
+	flags.Parse(args)
+
+	var sources models.BulkSources
+	targets := map[string]*io.Reader{
+		"users":      &sources.Users,
+		"forums":     &sources.Forums,
+		"threads":    &sources.Threads,
+		"posts":      &sources.Posts,
+		"votes":      &sources.Votes,
+		"post_votes": &sources.PostVotes,
+	}
+
+	empty := true
+	for key, name := range names {
+		if *name == "" {
+			continue
+		}
+		empty = false
+
+		source, err := openSource(*name)
+		if err != nil {
+			return err
+		}
+		defer source.Close()
+
+		*targets[key] = source
+	}
+	if empty || flags.NArg() != 0 {
+		return errUsage
+	}
+
+	started := time.Now()
+	report, err := useCase.BulkLoad(sources)
+	if err != nil {
+		return err
+	}
+	log.Printf("loaded in %s", time.Since(started).Round(time.Millisecond))
+
+	return output(report)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"flag"
+	"github.com/jackc/pgx"
+	"strconv"
+	"tp-db-forum/internal/app"
+	"tp-db-forum/internal/app/models"
+)
+
+// oneArg returns the single argument of a command.
+func oneArg(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", errUsage
+	}
+
+	return args[0], nil
+}
+
+func threadBySlugOrId(useCase app.UseCase, slugOrId string) (models.Thread, error) {
+	id, err := strconv.Atoi(slugOrId)
+	if err != nil {
+		return useCase.CheckThreadBySlug(slugOrId)
+	}
+
+	return useCase.CheckThreadById(id)
+}
+
+func postById(useCase app.UseCase, arg string) (models.Post, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return models.Post{}, errUsage
+	}
+
+	posts, err := useCase.CheckPostsByIds([]int{id})
+	if err != nil {
+		return models.Post{}, err
+	}
+	if len(posts) == 0 {
+		return models.Post{}, pgx.ErrNoRows
+	}
+
+	return posts[0], nil
+}
+
+func showUser(useCase app.UseCase, args []string) error {
+	nickname, err := oneArg(args)
+	if err != nil {
+		return err
+	}
+
+	profile, err := useCase.CheckUserProfile(nickname)
+	if err != nil {
+		return err
+	}
+
+	return output(profile)
+}
+
+func showForum(useCase app.UseCase, args []string) error {
+	slug, err := oneArg(args)
+	if err != nil {
+		return err
+	}
+
+	forum, err := useCase.CheckForumBySlug(slug)
+	if err != nil {
+		return err
+	}
+
+	return output(forum)
+}
+
+func showThread(useCase app.UseCase, args []string) error {
+	slugOrId, err := oneArg(args)
+	if err != nil {
+		return err
+	}
+
+	thread, err := threadBySlugOrId(useCase, slugOrId)
+	if err != nil {
+		return err
+	}
+
+	moderation, err := useCase.CheckModeration(models.ModerationThread, thread.Id)
+	if err != nil {
+		return err
+	}
+
+	return output(struct {
+		models.Thread
+		models.Moderation
+	}{thread, moderation})
+}
+
+func showPost(useCase app.UseCase, args []string) error {
+	arg, err := oneArg(args)
+	if err != nil {
+		return err
+	}
+
+	post, err := postById(useCase, arg)
+	if err != nil {
+		return err
+	}
+
+	moderation, err := useCase.CheckModeration(models.ModerationPost, post.Id)
+	if err != nil {
+		return err
+	}
+
+	return output(struct {
+		models.Post
+		models.Moderation
+	}{post, moderation})
+}
+
+func showStats(useCase app.UseCase, args []string) error {
+	flags := flag.NewFlagSet("stats", flag.ExitOnError)
+	estimate := flags.Bool("estimate", false, "take the counts from the planner statistics")
+	flags.Parse(args)
+	if flags.NArg() != 0 {
+		return errUsage
+	}
+
+	status, err := useCase.GetServiceStatus(*estimate)
+	if err != nil {
+		return err
+	}
+
+	return output(status)
+}
+
+func showLeaderboard(useCase app.UseCase, args []string) error {
+	flags := flag.NewFlagSet("leaderboard", flag.ExitOnError)
+	forum := flags.String("forum", "", "slug of the forum, all of them if empty")
+	window := flags.String("window", models.WindowAll, "all, day, week, month or year")
+	limit := flags.Int("limit", models.DefaultStatsLimit, "number of users")
+	flags.Parse(args)
+	if flags.NArg() != 0 {
+		return errUsage
+	}
+
+	leaderboard, err := useCase.CheckLeaderboard(models.LeaderboardOptions{Forum: *forum, Window: *window, Limit: *limit})
+	if err != nil {
+		return err
+	}
+
+	if format == formatJSON {
+		return output(leaderboard)
+	}
+
+	return output(leaderboard.Entries)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/jackc/pgx"
+	"log"
+	"os"
+	"strings"
+	"tp-db-forum/configs"
+	"tp-db-forum/internal/app"
+	_repo "tp-db-forum/internal/app/repository"
+	_useCase "tp-db-forum/internal/app/usecase"
+)
+
+// errUsage makes main print the usage instead of an error.
+var errUsage = errors.New("usage")
+
+type command struct {
+	name  string
+	usage string
+	run   func(useCase app.UseCase, args []string) error
+}
+
+var commands = []command{
+	{"user show", "nickname", showUser},
+	{"user rename", "nickname new-nickname", renameUser},
+	{"forum show", "slug", showForum},
+	{"thread show", "slug-or-id", showThread},
+	{"thread lock", "slug-or-id", lockThread(true)},
+	{"thread unlock", "slug-or-id", lockThread(false)},
+	{"thread delete", "slug-or-id", moderateThread(true)},
+	{"thread restore", "slug-or-id", moderateThread(false)},
+	{"post show", "id", showPost},
+	{"post delete", "id", moderatePost(true)},
+	{"post restore", "id", moderatePost(false)},
+	{"counters", "[-fix]", reconcileCounters},
+	{"members", "[-forum slug] [-fix]", reconcileForumUsers},
+	{"stats", "[-estimate]", showStats},
+	{"leaderboard", "[-forum slug] [-window all|day|week|month|year] [-limit n]", showLeaderboard},
+	{"migrate status", "", migrationStatus},
+	{"migrate up", "", migrateUp},
+	{"clear", "-yes", clearDatabase},
+	{"export", "-forum slug [-o file]", exportForum},
+	{"import", "[-slug slug] [-users skip|overwrite|fail] [-slugs fail|rename] [-dry-run] [file]", importForum},
+	{"load", "[-users file] [-forums file] [-threads file] [-posts file] [-votes file] [-post-votes file]", bulkLoad},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: forumctl [-format table|json] command")
+	for _, cmd := range commands {
+		fmt.Fprintln(os.Stderr, strings.TrimRight("  forumctl "+cmd.name+" "+cmd.usage, " "))
+	}
+	os.Exit(2)
+}
+
+// find looks the command up by its first one or two words.
+func find(args []string) (*command, []string) {
+	for i := range commands {
+		words := strings.Fields(commands[i].name)
+		if len(args) >= len(words) && strings.Join(args[:len(words)], " ") == commands[i].name {
+			return &commands[i], args[len(words):]
+		}
+	}
+
+	return nil, nil
+}
+
+func connect() (*pgx.ConnPool, error) {
+	connString := fmt.Sprintf("user=%s password=%s dbname=%s sslmode=disable port=%s",
+		configs.PostgresConfig.User,
+		configs.PostgresConfig.Password,
+		configs.PostgresConfig.DB,
+		configs.PostgresConfig.Port,
+	)
+
+	pgxConnConfig, err := pgx.ParseConnectionString(connString)
+	if err != nil {
+		return nil, err
+	}
+	pgxConnConfig.PreferSimpleProtocol = true
+
+	return pgx.NewConnPool(pgx.ConnPoolConfig{
+		ConnConfig:     pgxConnConfig,
+		MaxConnections: 4,
+	})
+}
+
+// forumctl runs maintenance tasks against the database of the server,
+// configured the same way through configs.PostgresConfig. It goes to the
+// database directly, so a running server may serve what it has cached for
+// up to repository.DefaultCacheConfig.TTL after a change.
+func main() {
+	flag.StringVar(&format, "format", formatTable, "output format: table or json")
+	flag.Usage = usage
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("forumctl: ")
+
+	if format != formatTable && format != formatJSON {
+		usage()
+	}
+
+	cmd, args := find(flag.Args())
+	if cmd == nil {
+		usage()
+	}
+
+	pool, err := connect()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer pool.Close()
+
+	useCase := _useCase.NewAppUseCase(_repo.NewPostgresAppRepository(pool))
+	if err := cmd.run(useCase, args); err != nil {
+		switch err {
+		case errUsage:
+			usage()
+		case pgx.ErrNoRows:
+			log.Fatal("not found")
+		}
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"tp-db-forum/internal/app"
+)
+
+func reconcileCounters(useCase app.UseCase, args []string) error {
+	flags := flag.NewFlagSet("counters", flag.ExitOnError)
+	fix := flags.Bool("fix", false, "set the drifted counters to the actual values")
+	flags.Parse(args)
+
+	report, err := useCase.ReconcileCounters(*fix)
+	if err != nil {
+		return err
+	}
+
+	if format == formatJSON {
+		return output(report)
+	}
+
+	return output(report.Drifts)
+}
+
+func migrationStatus(useCase app.UseCase, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	migrations, err := useCase.CheckMigrations()
+	if err != nil {
+		return err
+	}
+
+	return output(migrations)
+}
+
+func migrateUp(useCase app.UseCase, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	applied, err := useCase.Migrate()
+	if err != nil {
+		return err
+	}
+
+	return output(applied)
+}
+
+func reconcileForumUsers(useCase app.UseCase, args []string) error {
+	flags := flag.NewFlagSet("members", flag.ExitOnError)
+	forum := flags.String("forum", "", "slug of the forum to check, all of them if empty")
+	fix := flags.Bool("fix", false, "rebuild the rows that are off")
+	flags.Parse(args)
+
+	report, err := useCase.ReconcileForumUsers(*forum, *fix)
+	if err != nil {
+		return err
+	}
+
+	if format == formatJSON {
+		return output(report)
+	}
+
+	return output(report.Drifts)
+}
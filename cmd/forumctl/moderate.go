@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"tp-db-forum/internal/app"
+)
+
+func lockThread(locked bool) func(app.UseCase, []string) error {
+	return func(useCase app.UseCase, args []string) error {
+		slugOrId, err := oneArg(args)
+		if err != nil {
+			return err
+		}
+
+		thread, err := threadBySlugOrId(useCase, slugOrId)
+		if err != nil {
+			return err
+		}
+
+		if err := useCase.LockThread(thread.Id, locked); err != nil {
+			return err
+		}
+
+		return showThread(useCase, []string{strconv.Itoa(thread.Id)})
+	}
+}
+
+func moderateThread(deleted bool) func(app.UseCase, []string) error {
+	return func(useCase app.UseCase, args []string) error {
+		slugOrId, err := oneArg(args)
+		if err != nil {
+			return err
+		}
+
+		thread, err := threadBySlugOrId(useCase, slugOrId)
+		if err != nil {
+			return err
+		}
+
+		if deleted {
+			err = useCase.DeleteThread(thread.Id)
+		} else {
+			err = useCase.RestoreThread(thread.Id)
+		}
+		if err != nil {
+			return err
+		}
+
+		return showThread(useCase, []string{strconv.Itoa(thread.Id)})
+	}
+}
+
+func moderatePost(deleted bool) func(app.UseCase, []string) error {
+	return func(useCase app.UseCase, args []string) error {
+		arg, err := oneArg(args)
+		if err != nil {
+			return err
+		}
+
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			return errUsage
+		}
+
+		if deleted {
+			err = useCase.DeletePost(id)
+		} else {
+			err = useCase.RestorePost(id)
+		}
+		if err != nil {
+			return err
+		}
+
+		return showPost(useCase, args)
+	}
+}
+
+func renameUser(useCase app.UseCase, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+
+	user, err := useCase.RenameUser(args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	return output(user)
+}
+
+func clearDatabase(useCase app.UseCase, args []string) error {
+	flags := flag.NewFlagSet("clear", flag.ExitOnError)
+	yes := flags.Bool("yes", false, "really remove all data")
+	flags.Parse(args)
+
+	if !*yes {
+		return fmt.Errorf("clear removes all data; pass -yes to go ahead")
+	}
+
+	return useCase.ClearDatabase()
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"tp-db-forum/internal/app/models"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+var format = formatTable
+
+// output prints a result in the chosen format. Tables have a row per
+// element of a slice, or a row per field of anything else.
+func output(v interface{}) error {
+	if format == formatJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(v)
+	}
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Slice:
+		if value.Len() == 0 {
+			fmt.Fprintln(table, "(none)")
+			break
+		}
+
+		columns := fields(value.Type().Elem())
+		names := make([]string, len(columns))
+		for i, column := range columns {
+			names[i] = strings.ToUpper(column.name)
+		}
+		fmt.Fprintln(table, strings.Join(names, "\t"))
+
+		for i := 0; i < value.Len(); i++ {
+			cells := make([]string, len(columns))
+			for j, column := range columns {
+				cells[j] = cell(value.Index(i).FieldByIndex(column.index))
+			}
+			fmt.Fprintln(table, strings.Join(cells, "\t"))
+		}
+	case reflect.Map:
+		keys := value.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, key := range keys {
+			fmt.Fprintf(table, "%v\t%s\n", key, cell(value.MapIndex(key)))
+		}
+	case reflect.Struct:
+		for _, field := range fields(value.Type()) {
+			fmt.Fprintf(table, "%s\t%s\n", field.name, cell(value.FieldByIndex(field.index)))
+		}
+	default:
+		fmt.Fprintln(table, v)
+	}
+
+	return table.Flush()
+}
+
+type field struct {
+	name  string
+	index []int
+}
+
+// fields lists the JSON fields of a struct, with the ones of embedded
+// structs in their place.
+func fields(t reflect.Type) []field {
+	var result []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" || f.PkgPath != "" {
+			continue
+		}
+
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && name == "" {
+			for _, inner := range fields(f.Type) {
+				result = append(result, field{inner.name, append([]int{i}, inner.index...)})
+			}
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		result = append(result, field{name, []int{i}})
+	}
+
+	return result
+}
+
+func cell(value reflect.Value) string {
+	if value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+	if !value.IsValid() {
+		return ""
+	}
+
+	switch v := value.Interface().(type) {
+	case string:
+		return v
+	case models.JsonNullInt:
+		if !v.Valid {
+			return ""
+		}
+
+		return fmt.Sprint(v.Int64)
+	}
+
+	switch value.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Struct:
+		b, err := json.Marshal(value.Interface())
+		if err != nil {
+			return fmt.Sprint(value.Interface())
+		}
+
+		return string(b)
+	}
+
+	return fmt.Sprint(value.Interface())
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type client struct {
+	base string
+	http *http.Client
+}
+
+func newClient(base string, connections int) *client {
+	return &client{
+		base: base,
+		http: &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				MaxIdleConns:        connections,
+				MaxIdleConnsPerHost: connections,
+				IdleConnTimeout:     time.Minute,
+			},
+		},
+	}
+}
+
+// local reports whether base names this machine; loadgen doesn't point
+// load at anything else unless told to.
+func local(base string) (bool, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return false, err
+	}
+
+	host := u.Hostname()
+	if host == "localhost" {
+		return true, nil
+	}
+
+	addrs, err := net.LookupIP(host)
+	if err != nil {
+		return false, err
+	}
+	for _, addr := range addrs {
+		if !addr.IsLoopback() {
+			return false, nil
+		}
+	}
+
+	return len(addrs) != 0, nil
+}
+
+// do sends a request with in, if any, as JSON and decodes a 2xx response
+// into out, if any. The status is returned even with an error.
+func (c *client) do(method, path string, in, out interface{}) (int, error) {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return 0, err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	request, err := http.NewRequest(method, c.base+path, body)
+	if err != nil {
+		return 0, err
+	}
+	if in != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := c.http.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	b, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return response.StatusCode, err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("%s %s: %d %s", method, path, response.StatusCode, bytes.TrimSpace(b))
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(b, out); err != nil {
+			return response.StatusCode, fmt.Errorf("%s %s: %v", method, path, err)
+		}
+	}
+
+	return response.StatusCode, nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"log"
+	mathrand "math/rand"
+	"os"
+	"time"
+)
+
+// loadgen benchmarks a local instance: it creates its own users, forums,
+// threads and reply trees through the API, under a prefix of its own so it
+// can run against a database that has data already, then sends a mix of
+// reads and writes at a target rate and reports latency per operation.
+func main() {
+	addr := flag.String("addr", "http://localhost:5000", "base URL of the service")
+	allowRemote := flag.Bool("allow-remote", false, "allow an -addr that isn't this machine")
+	prefix := flag.String("prefix", "", "prefix of the created nicknames and slugs; random by default")
+	users := flag.Int("users", 200, "users to create")
+	forums := flag.Int("forums", 10, "forums to create")
+	threads := flag.Int("threads", 500, "threads to create")
+	posts := flag.Int("posts", 20000, "posts to create, spread unevenly over the threads")
+	depth := flag.Int("depth", 12, "maximum depth of the reply trees")
+	batch := flag.Int("batch", 100, "posts per create request while seeding")
+	rps := flag.Int("rps", 200, "target requests per second of the load phase")
+	duration := flag.Duration("duration", 30*time.Second, "length of the load phase; 0 only seeds")
+	workers := flag.Int("workers", 64, "concurrent requests")
+	mixSpec := flag.String("mix", defaultMix, "weighted operations of the load phase, as name=weight,...")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "random seed")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("loadgen: ")
+
+	m, err := parseMix(*mixSpec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *users < 1 || *forums < 1 || *threads < 1 || *posts < 1 || *depth < 1 || *batch < 1 || *rps < 1 || *workers < 1 {
+		log.Fatal("counts, -rps and -workers must be positive")
+	}
+
+	if !*allowRemote {
+		ok, err := local(*addr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !ok {
+			log.Fatalf("%s isn't this machine; pass -allow-remote to load it anyway", *addr)
+		}
+	}
+
+	if *prefix == "" {
+		b := make([]byte, 3)
+		if _, err := rand.Read(b); err != nil {
+			log.Fatal(err)
+		}
+		*prefix = "lg" + hex.EncodeToString(b)
+	}
+	mathrand.Seed(*seed)
+
+	c := newClient(*addr, *workers)
+	config := seedConfig{
+		prefix:  *prefix,
+		users:   *users,
+		forums:  *forums,
+		threads: *threads,
+		posts:   *posts,
+		depth:   *depth,
+		batch:   *batch,
+		workers: *workers,
+	}
+	data := &dataset{threadPosts: map[int][]int{}}
+
+	for _, step := range []struct {
+		name string
+		run  func(*client, seedConfig, *dataset) error
+	}{
+		{"users", seedUsers},
+		{"forums", seedForums},
+		{"threads", seedThreads},
+		{"posts", seedPosts},
+	} {
+		started := time.Now()
+		if err := step.run(c, config, data); err != nil {
+			log.Fatalf("seeding %s: %v", step.name, err)
+		}
+		log.Printf("seeded %s in %s", step.name, time.Since(started).Round(time.Millisecond))
+	}
+	log.Printf("dataset %s: %d users, %d forums, %d threads, %d posts",
+		*prefix, len(data.users), len(data.forums), len(data.threads), len(data.posts))
+
+	if *duration == 0 {
+		return
+	}
+
+	log.Printf("sending %d rps for %s", *rps, *duration)
+	rec := newRecorder()
+	elapsed := drive(c, data, m, *rps, *duration, *workers, rec)
+
+	result := rec.report(elapsed)
+	if *asJSON {
+		err = result.writeJSON(os.Stdout)
+	} else {
+		err = result.writeTable(os.Stdout)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultMix = "thread_posts_flat=15,thread_posts_tree=10,thread_posts_parent_tree=10," +
+	"forum_threads=15,forum_users=10,post_details=15,create_posts=15,vote=8,create_thread=2"
+
+// operation sends one request of the mix, returning its status.
+type operation func(c *client, data *dataset) (int, error)
+
+func listQuery(extra string) string {
+	return fmt.Sprintf("?limit=%d&desc=%t%s", 10+rand.Intn(91), rand.Intn(2) == 0, extra)
+}
+
+func threadPosts(sort string) operation {
+	return func(c *client, data *dataset) (int, error) {
+		return c.do(http.MethodGet, fmt.Sprintf("/api/thread/%d/posts", data.thread())+listQuery("&sort="+sort), nil, nil)
+	}
+}
+
+var operations = map[string]operation{
+	"thread_posts_flat":        threadPosts("flat"),
+	"thread_posts_tree":        threadPosts("tree"),
+	"thread_posts_parent_tree": threadPosts("parent_tree"),
+	"forum_threads": func(c *client, data *dataset) (int, error) {
+		return c.do(http.MethodGet, "/api/forum/"+data.forum()+"/threads"+listQuery(""), nil, nil)
+	},
+	"forum_users": func(c *client, data *dataset) (int, error) {
+		return c.do(http.MethodGet, "/api/forum/"+data.forum()+"/users"+listQuery(""), nil, nil)
+	},
+	"post_details": func(c *client, data *dataset) (int, error) {
+		return c.do(http.MethodGet, fmt.Sprintf("/api/post/%d/details?related=user,forum,thread", data.post()), nil, nil)
+	},
+	"create_posts": func(c *client, data *dataset) (int, error) {
+		thread := data.thread()
+
+		posts := make([]postInput, 1+rand.Intn(10))
+		for i := range posts {
+			posts[i] = postInput{Author: data.user(), Message: sentence(5, 60), Parent: data.parent(thread)}
+		}
+
+		var result []created
+		status, err := c.do(http.MethodPost, fmt.Sprintf("/api/thread/%d/create", thread), posts, &result)
+		if err == nil {
+			ids := make([]int, len(result))
+			for i, post := range result {
+				ids[i] = post.Id
+			}
+			data.addPosts(thread, ids)
+		}
+
+		return status, err
+	},
+	"vote": func(c *client, data *dataset) (int, error) {
+		voice := 1
+		if rand.Intn(3) == 0 {
+			voice = -1
+		}
+
+		return c.do(http.MethodPost, fmt.Sprintf("/api/thread/%d/vote", data.thread()), map[string]interface{}{
+			"nickname": data.user(),
+			"voice":    voice,
+		}, nil)
+	},
+	"create_thread": func(c *client, data *dataset) (int, error) {
+		var result created
+		status, err := c.do(http.MethodPost, "/api/forum/"+data.forum()+"/create", map[string]string{
+			"author":  data.user(),
+			"title":   sentence(3, 10),
+			"message": sentence(10, 80),
+		}, &result)
+		if err == nil {
+			data.addThread(result.Id)
+		}
+
+		return status, err
+	},
+}
+
+type mix struct {
+	names   []string
+	weights []int
+	total   int
+}
+
+// parseMix reads name=weight pairs separated by commas.
+func parseMix(spec string) (mix, error) {
+	var m mix
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return m, fmt.Errorf("mix: %q isn't name=weight", part)
+		}
+		if _, ok := operations[kv[0]]; !ok {
+			known := make([]string, 0, len(operations))
+			for name := range operations {
+				known = append(known, name)
+			}
+			sort.Strings(known)
+
+			return m, fmt.Errorf("mix: unknown operation %q, known are %s", kv[0], strings.Join(known, ", "))
+		}
+
+		weight, err := strconv.Atoi(kv[1])
+		if err != nil || weight < 0 {
+			return m, fmt.Errorf("mix: bad weight %q", kv[1])
+		}
+		if weight == 0 {
+			continue
+		}
+
+		m.names = append(m.names, kv[0])
+		m.weights = append(m.weights, weight)
+		m.total += weight
+	}
+
+	if m.total == 0 {
+		return m, fmt.Errorf("mix: no operations")
+	}
+
+	return m, nil
+}
+
+func (m mix) pick() string {
+	n := rand.Intn(m.total)
+	for i, weight := range m.weights {
+		if n < weight {
+			return m.names[i]
+		}
+		n -= weight
+	}
+
+	return m.names[len(m.names)-1]
+}
+
+// drive sends requests of the mix at rps for duration, open loop: requests
+// are due on a schedule whether or not earlier ones came back, and the ones
+// no worker is free for are counted as dropped.
+func drive(c *client, data *dataset, m mix, rps int, duration time.Duration, workers int, rec *recorder) time.Duration {
+	jobs := make(chan string, workers)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range jobs {
+				started := time.Now()
+				status, err := operations[name](c, data)
+				rec.record(name, time.Since(started), status, err)
+			}
+		}()
+	}
+
+	started := time.Now()
+	deadline := started.Add(duration)
+	interval := time.Second / time.Duration(rps)
+
+	for due := started; due.Before(deadline); due = due.Add(interval) {
+		if wait := time.Until(due); wait > 0 {
+			time.Sleep(wait)
+		}
+
+		select {
+		case jobs <- m.pick():
+		default:
+			rec.drop()
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	return time.Since(started)
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var words = strings.Fields(`forum thread post reply answer question index query cache
+	latency replica vacuum planner cursor tree path parent vote user slug migration backup
+	database server request response benchmark profile trace lock deadlock transaction
+	postgres golang handler router middleware stream archive import export counter metric`)
+
+var names = strings.Fields(`Ada Alan Barbara Claude Donald Edsger Frances Grace John Ken
+	Leslie Linus Margaret Niklaus Rob Radia Robert Shafi Tony Whitfield`)
+
+func sentence(min, max int) string {
+	n := min + rand.Intn(max-min+1)
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = words[rand.Intn(len(words))]
+	}
+	parts[0] = strings.Title(parts[0])
+
+	return strings.Join(parts, " ")
+}
+
+type seedConfig struct {
+	prefix  string
+	users   int
+	forums  int
+	threads int
+	posts   int
+	depth   int
+	batch   int
+	workers int
+}
+
+// dataset is what loadgen created, for the load phase to pick from.
+type dataset struct {
+	mu          sync.RWMutex
+	users       []string
+	forums      []string
+	threads     []int
+	posts       []int
+	threadPosts map[int][]int
+}
+
+func (d *dataset) user() string {
+	return d.users[rand.Intn(len(d.users))]
+}
+
+func (d *dataset) forum() string {
+	return d.forums[rand.Intn(len(d.forums))]
+}
+
+func (d *dataset) thread() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.threads[rand.Intn(len(d.threads))]
+}
+
+func (d *dataset) post() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.posts[rand.Intn(len(d.posts))]
+}
+
+// parent picks a post of thread to reply to, 0 for a new root.
+func (d *dataset) parent(thread int) int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	posts := d.threadPosts[thread]
+	if len(posts) == 0 || rand.Intn(10) == 0 {
+		return 0
+	}
+
+	return posts[rand.Intn(len(posts))]
+}
+
+func (d *dataset) addThread(id int) {
+	d.mu.Lock()
+	d.threads = append(d.threads, id)
+	d.mu.Unlock()
+}
+
+func (d *dataset) addPosts(thread int, ids []int) {
+	d.mu.Lock()
+	d.posts = append(d.posts, ids...)
+	d.threadPosts[thread] = append(d.threadPosts[thread], ids...)
+	d.mu.Unlock()
+}
+
+// parallel calls fn for 0 to n-1 on workers goroutines, stopping at the
+// first error.
+func parallel(n, workers int, fn func(i int) error) error {
+	var next int64 = -1
+	var failed atomic.Value
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for failed.Load() == nil {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= n {
+					return
+				}
+
+				if err := fn(i); err != nil {
+					failed.Store(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err, ok := failed.Load().(error); ok {
+		return err
+	}
+
+	return nil
+}
+
+type postInput struct {
+	Author  string `json:"author"`
+	Message string `json:"message"`
+	Parent  int    `json:"parent,omitempty"`
+}
+
+type created struct {
+	Id int `json:"id"`
+}
+
+func seedUsers(c *client, config seedConfig, data *dataset) error {
+	data.users = make([]string, config.users)
+
+	return parallel(config.users, config.workers, func(i int) error {
+		nickname := fmt.Sprintf("%s_u%d", config.prefix, i)
+		data.users[i] = nickname
+
+		_, err := c.do(http.MethodPost, "/api/user/"+nickname+"/create", map[string]string{
+			"fullname": names[rand.Intn(len(names))] + " " + names[rand.Intn(len(names))],
+			"about":    sentence(5, 30),
+			"email":    nickname + "@loadgen.test",
+		}, nil)
+
+		return err
+	})
+}
+
+func seedForums(c *client, config seedConfig, data *dataset) error {
+	data.forums = make([]string, config.forums)
+
+	return parallel(config.forums, config.workers, func(i int) error {
+		slug := fmt.Sprintf("%s-f%d", config.prefix, i)
+		data.forums[i] = slug
+
+		_, err := c.do(http.MethodPost, "/api/forum/create", map[string]string{
+			"slug":  slug,
+			"title": sentence(2, 6),
+			"user":  data.user(),
+		}, nil)
+
+		return err
+	})
+}
+
+func seedThreads(c *client, config seedConfig, data *dataset) error {
+	return parallel(config.threads, config.workers, func(i int) error {
+		thread := map[string]string{
+			"author":  data.user(),
+			"title":   sentence(3, 10),
+			"message": sentence(10, 80),
+		}
+		// Some threads go without a slug, like the ones made by most clients.
+		if i%4 != 0 {
+			thread["slug"] = fmt.Sprintf("%s-t%d", config.prefix, i)
+		}
+
+		var result created
+		if _, err := c.do(http.MethodPost, "/api/forum/"+data.forum()+"/create", thread, &result); err != nil {
+			return err
+		}
+		data.addThread(result.Id)
+
+		return nil
+	})
+}
+
+// seedPosts spreads the posts over the threads unevenly, a few threads
+// getting most of them, and grows reply trees in them down to config.depth.
+func seedPosts(c *client, config seedConfig, data *dataset) error {
+	counts := make([]int, len(data.threads))
+	for i := 0; i < config.posts; i++ {
+		counts[int(float64(len(counts))*math.Pow(rand.Float64(), 3))]++
+	}
+
+	return parallel(len(counts), config.workers, func(i int) error {
+		thread := data.threads[i]
+
+		type node struct {
+			id    int
+			depth int
+		}
+		var nodes []node
+
+		for remaining := counts[i]; remaining > 0; {
+			size := config.batch
+			if remaining < size {
+				size = remaining
+			}
+			remaining -= size
+
+			posts := make([]postInput, size)
+			depths := make([]int, size)
+			for j := range posts {
+				posts[j] = postInput{Author: data.user(), Message: sentence(5, 60)}
+				depths[j] = 1
+
+				// Mostly replies to recent posts, so the trees get deep.
+				if len(nodes) != 0 && rand.Intn(8) != 0 {
+					recent := nodes[len(nodes)-1-rand.Intn(minInt(len(nodes), 32))]
+					if recent.depth < config.depth {
+						posts[j].Parent = recent.id
+						depths[j] = recent.depth + 1
+					}
+				}
+			}
+
+			var result []created
+			if _, err := c.do(http.MethodPost, fmt.Sprintf("/api/thread/%d/create", thread), posts, &result); err != nil {
+				return err
+			}
+
+			ids := make([]int, len(result))
+			for j, post := range result {
+				ids[j] = post.Id
+				nodes = append(nodes, node{post.Id, depths[j]})
+			}
+			data.addPosts(thread, ids)
+		}
+
+		return nil
+	})
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+type endpointStats struct {
+	latencies []time.Duration
+	errors    int
+	statuses  map[int]int
+}
+
+// recorder collects the latency of every request of the load phase, per
+// operation of the mix.
+type recorder struct {
+	mu        sync.Mutex
+	endpoints map[string]*endpointStats
+	dropped   int
+}
+
+func newRecorder() *recorder {
+	return &recorder{endpoints: map[string]*endpointStats{}}
+}
+
+func (r *recorder) record(name string, latency time.Duration, status int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats, ok := r.endpoints[name]
+	if !ok {
+		stats = &endpointStats{statuses: map[int]int{}}
+		r.endpoints[name] = stats
+	}
+
+	stats.latencies = append(stats.latencies, latency)
+	stats.statuses[status]++
+	if err != nil {
+		stats.errors++
+	}
+}
+
+// drop counts a request the workers had no time to send, which means the
+// target rate is more than the service (or loadgen) keeps up with.
+func (r *recorder) drop() {
+	r.mu.Lock()
+	r.dropped++
+	r.mu.Unlock()
+}
+
+type endpointReport struct {
+	Endpoint  string         `json:"endpoint"`
+	Requests  int            `json:"requests"`
+	Errors    int            `json:"errors"`
+	ErrorRate float64        `json:"error_rate"`
+	RPS       float64        `json:"rps"`
+	P50       float64        `json:"p50_ms"`
+	P90       float64        `json:"p90_ms"`
+	P99       float64        `json:"p99_ms"`
+	Max       float64        `json:"max_ms"`
+	Statuses  map[string]int `json:"statuses"`
+}
+
+type report struct {
+	Duration  float64          `json:"duration_s"`
+	Requests  int              `json:"requests"`
+	Errors    int              `json:"errors"`
+	Dropped   int              `json:"dropped"`
+	RPS       float64          `json:"rps"`
+	Endpoints []endpointReport `json:"endpoints"`
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// percentile of sorted latencies, by the nearest rank.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+
+	return sorted[rank]
+}
+
+func (r *recorder) report(elapsed time.Duration) report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := report{Duration: elapsed.Seconds(), Dropped: r.dropped}
+	for name, stats := range r.endpoints {
+		sorted := append([]time.Duration(nil), stats.latencies...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+		statuses := make(map[string]int, len(stats.statuses))
+		for status, count := range stats.statuses {
+			key := fmt.Sprint(status)
+			if status == 0 {
+				key = "transport"
+			}
+			statuses[key] = count
+		}
+
+		endpoint := endpointReport{
+			Endpoint:  name,
+			Requests:  len(sorted),
+			Errors:    stats.errors,
+			ErrorRate: float64(stats.errors) / float64(len(sorted)),
+			RPS:       float64(len(sorted)) / elapsed.Seconds(),
+			P50:       milliseconds(percentile(sorted, 0.5)),
+			P90:       milliseconds(percentile(sorted, 0.9)),
+			P99:       milliseconds(percentile(sorted, 0.99)),
+			Max:       milliseconds(sorted[len(sorted)-1]),
+			Statuses:  statuses,
+		}
+		result.Endpoints = append(result.Endpoints, endpoint)
+		result.Requests += endpoint.Requests
+		result.Errors += endpoint.Errors
+	}
+
+	sort.Slice(result.Endpoints, func(i, j int) bool {
+		return result.Endpoints[i].Endpoint < result.Endpoints[j].Endpoint
+	})
+	result.RPS = float64(result.Requests) / elapsed.Seconds()
+
+	return result
+}
+
+func (r report) writeJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(r)
+}
+
+func (r report) writeTable(w io.Writer) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "endpoint\trequests\terrors\terror %\trps\tp50 ms\tp90 ms\tp99 ms\tmax ms\t")
+	for _, e := range r.Endpoints {
+		fmt.Fprintf(table, "%s\t%d\t%d\t%.2f\t%.1f\t%.2f\t%.2f\t%.2f\t%.2f\t\n",
+			e.Endpoint, e.Requests, e.Errors, e.ErrorRate*100, e.RPS, e.P50, e.P90, e.P99, e.Max)
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d requests in %.1fs, %.1f rps, %d errors, %d dropped\n",
+		r.Requests, r.Duration, r.RPS, r.Errors, r.Dropped)
+
+	return err
+}
@@ -1,19 +1,34 @@
 package main
 
 import (
+	"expvar"
 	"fmt"
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx"
 	"github.com/valyala/fasthttp"
-	"github.com/valyala/fasthttp/fasthttpadaptor"
+	"google.golang.org/grpc"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"time"
 	"tp-db-forum/configs"
+	"tp-db-forum/internal/adaptor"
+	"tp-db-forum/internal/app/compress"
+	"tp-db-forum/internal/app/counters"
 	_handler "tp-db-forum/internal/app/delivery"
+	"tp-db-forum/internal/app/delivery/gql"
+	"tp-db-forum/internal/app/delivery/openapi"
+	"tp-db-forum/internal/app/delivery/rpc"
+	"tp-db-forum/internal/app/events"
+	"tp-db-forum/internal/app/idempotency"
 	_repo "tp-db-forum/internal/app/repository"
 	_useCase "tp-db-forum/internal/app/usecase"
+	"tp-db-forum/internal/app/webhook"
 )
 
+const grpcAddr = ":5001"
+
 func applicationJSONMiddleware(_ *mux.Router) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -23,6 +38,19 @@ func applicationJSONMiddleware(_ *mux.Router) mux.MiddlewareFunc {
 	}
 }
 
+// routesNamed reports whether a request goes to one of the named routes.
+func routesNamed(router *mux.Router, names ...string) func(*http.Request) bool {
+	named := make(map[string]bool, len(names))
+	for _, name := range names {
+		named[name] = true
+	}
+
+	return func(request *http.Request) bool {
+		var match mux.RouteMatch
+		return router.Match(request, &match) && match.Route != nil && named[match.Route.GetName()]
+	}
+}
+
 func main() {
 	router := mux.NewRouter()
 
@@ -52,10 +80,101 @@ func main() {
 	}
 
 	repo := _repo.NewPostgresAppRepository(pool)
+
+	// MIGRATE=off leaves an older schema as it is; forumctl migrate up
+	// applies the migrations then.
+	if os.Getenv("MIGRATE") != "off" {
+		applied, err := repo.Migrate()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		for _, migration := range applied {
+			log.Printf("applied migration %d %s", migration.Version, migration.Name)
+		}
+	}
+
+	// REPOSITORY_CACHE=off reads users, forums and threads straight from
+	// Postgres. The cache is per process, so turn it off when several
+	// instances write to the same database.
+	if os.Getenv("REPOSITORY_CACHE") != "off" {
+		cached := _repo.NewCachedAppRepository(repo, _repo.DefaultCacheConfig)
+		expvar.Publish("repository_cache", expvar.Func(func() interface{} {
+			return cached.Stats()
+		}))
+		repo = cached
+	}
+
+	// COUNTER_RECONCILE=fix corrects the counters that drifted instead of
+	// only reporting them, =off doesn't schedule the check at all;
+	// COUNTER_RECONCILE_INTERVAL sets how often it runs.
+	reconcileConfig := counters.DefaultConfig
+	if interval, err := time.ParseDuration(os.Getenv("COUNTER_RECONCILE_INTERVAL")); err == nil && interval > 0 {
+		reconcileConfig.Interval = interval
+	}
+	reconcileConfig.Fix = os.Getenv("COUNTER_RECONCILE") == "fix"
+
+	reconciler := counters.NewReconciler(repo, reconcileConfig)
+	expvar.Publish("counter_drift", expvar.Func(func() interface{} {
+		return reconciler.Stats()
+	}))
+	repo = reconciler
+	if os.Getenv("COUNTER_RECONCILE") != "off" {
+		go reconciler.Run(nil)
+	}
+
 	useCase := _useCase.NewAppUseCase(repo)
-	_handler.NewAppHandler(router, useCase)
+	dispatcher := webhook.NewDispatcher(repo, webhook.DefaultConfig)
+	go dispatcher.Run(nil)
+
+	broker := events.NewBroker(1000)
+	_handler.NewAppHandler(router, useCase, broker)
+	if err := gql.NewGraphQLHandler(router, useCase, broker); err != nil {
+		log.Fatal(err.Error())
+	}
+
+	if err := openapi.NewOpenAPIHandler(router); err != nil {
+		log.Fatal(err.Error())
+	}
+	router.Handle("/debug/vars", expvar.Handler()).Methods(http.MethodGet)
+
+	grpcServer := grpc.NewServer()
+	rpc.NewAppServer(grpcServer, useCase, broker)
+
+	listener, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	go func() {
+		log.Fatal(grpcServer.Serve(listener))
+	}()
 
 	router.Use(applicationJSONMiddleware(router))
 
-	log.Fatal(fasthttp.ListenAndServe(":5000", fasthttpadaptor.NewFastHTTPHandler(router)))
+	// Server-sent events are tiny and latency bound, so they go out as is.
+	if os.Getenv("COMPRESSION") != "off" {
+		router.Use(compress.Middleware(compress.DefaultConfig, "ForumEvents"))
+	}
+
+	// IDEMPOTENCY_STORE=memory keeps Idempotency-Key responses in process
+	// instead of in the idempotency_key table.
+	idempotencyStore := idempotency.NewPostgresStore(repo)
+	if os.Getenv("IDEMPOTENCY_STORE") == "memory" {
+		idempotencyStore = idempotency.NewMemoryStore()
+	}
+	router.Use(idempotency.Middleware(idempotencyStore, idempotency.DefaultTTL,
+		"CreateUser", "CreateForum", "CreateThread", "CreatePosts", "VoteThread", "VotePost"))
+
+	// OPENAPI_VALIDATE=log or strict checks every response against
+	// /api/openapi.json; meant for test runs.
+	if mode := os.Getenv("OPENAPI_VALIDATE"); mode != "" {
+		for _, route := range openapi.Undocumented(router, openapi.Document()) {
+			log.Printf("openapi: %s is not documented", route)
+		}
+		router.Use(openapi.ValidationMiddleware(mode))
+	}
+
+	// Only the listings, the export and the events flush as they go; the
+	// rest are served without a goroutine per request.
+	flushes := routesNamed(router, "ForumThreads", "ForumUsers", "ThreadPosts", "ForumExport", "ForumEvents")
+	log.Fatal(fasthttp.ListenAndServe(":5000", adaptor.NewFastHTTPHandler(router, flushes)))
 }
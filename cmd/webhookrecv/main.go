@@ -0,0 +1,48 @@
+package main
+
+import (
+	"crypto/hmac"
+	"flag"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"tp-db-forum/internal/app/webhook"
+)
+
+// webhookrecv is a stand-in webhook receiver for local testing: it checks the
+// signature of every delivery, logs it and fails a share of requests so the
+// retry and dead-letter paths of the dispatcher can be exercised.
+func main() {
+	addr := flag.String("addr", ":5080", "listen address")
+	secret := flag.String("secret", "", "webhook secret used to verify signatures")
+	failRate := flag.Float64("fail-rate", 0, "share of deliveries answered with 500")
+	flag.Parse()
+
+	http.HandleFunc("/", func(writer http.ResponseWriter, request *http.Request) {
+		body, err := ioutil.ReadAll(request.Body)
+		if err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		timestamp := request.Header.Get(webhook.TimestampHeader)
+		signature := request.Header.Get(webhook.SignatureHeader)
+		if *secret != "" && !hmac.Equal([]byte(signature), []byte(webhook.Sign(*secret, timestamp, body))) {
+			log.Printf("delivery %s: bad signature", request.Header.Get(webhook.DeliveryHeader))
+			writer.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if rand.Float64() < *failRate {
+			log.Printf("delivery %s: failing on purpose", request.Header.Get(webhook.DeliveryHeader))
+			writer.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		log.Printf("delivery %s %s: %s", request.Header.Get(webhook.DeliveryHeader), request.Header.Get(webhook.EventHeader), body)
+		writer.WriteHeader(http.StatusNoContent)
+	})
+
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
@@ -0,0 +1,5 @@
+package configs
+
+// Version is set at build time with
+// -ldflags "-X tp-db-forum/configs.Version=...".
+var Version = "dev"
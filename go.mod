@@ -3,18 +3,20 @@ module tp-db-forum
 go 1.13
 
 require (
-	github.com/andybalholm/brotli v1.0.1 // indirect
+	github.com/andybalholm/brotli v1.0.1
 	github.com/cockroachdb/apd v1.1.0 // indirect
 	github.com/go-openapi/errors v0.19.9 // indirect
 	github.com/go-openapi/strfmt v0.19.11
 	github.com/go-pg/pg v8.0.7+incompatible // indirect
 	github.com/gofrs/uuid v4.0.0+incompatible // indirect
+	github.com/golang/protobuf v1.4.2
 	github.com/google/uuid v1.1.4
 	github.com/gorilla/mux v1.8.0
+	github.com/graphql-go/graphql v0.8.0
 	github.com/jackc/fake v0.0.0-20150926172116-812a484cc733 // indirect
 	github.com/jackc/pgx v3.6.2+incompatible
 	github.com/jinzhu/inflection v1.0.0 // indirect
-	github.com/klauspost/compress v1.11.6 // indirect
+	github.com/klauspost/compress v1.11.6
 	github.com/lib/pq v1.9.0 // indirect
 	github.com/mitchellh/mapstructure v1.4.0 // indirect
 	github.com/shopspring/decimal v1.2.0 // indirect
@@ -22,5 +24,7 @@ require (
 	go.mongodb.org/mongo-driver v1.4.4 // indirect
 	golang.org/x/crypto v0.0.0-20201221181555-eec23a3978ad // indirect
 	golang.org/x/text v0.3.5 // indirect
+	google.golang.org/grpc v1.36.0
+	google.golang.org/protobuf v1.25.0
 	mellium.im/sasl v0.2.1 // indirect
 )
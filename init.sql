@@ -56,6 +56,7 @@ CREATE UNLOGGED TABLE post (
     parent   BIGINT                   DEFAULT 0,
     thread   INT,
     Path     BIGINT[]                 DEFAULT ARRAY []::INTEGER[],
+    votes    INT    NOT NULL          DEFAULT 0,
 
     FOREIGN KEY (author) REFERENCES "users"  (nickname),
     FOREIGN KEY (forum)  REFERENCES "forum"  (slug),
@@ -68,12 +69,25 @@ CREATE UNLOGGED TABLE votes
     nickname  citext,
     voice     INT,
     id_thread INT,
+    created   TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
 
     FOREIGN KEY (nickname) REFERENCES "users" (nickname),
     FOREIGN KEY (id_thread) REFERENCES "thread" (id),
     UNIQUE (nickname, id_thread)
 );
 
+CREATE UNLOGGED TABLE post_votes
+(
+    nickname CITEXT NOT NULL,
+    voice    INT    NOT NULL,
+    id_post  BIGINT NOT NULL,
+    created  TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    FOREIGN KEY (nickname) REFERENCES "users" (nickname),
+    FOREIGN KEY (id_post) REFERENCES "post" (id),
+    UNIQUE (nickname, id_post)
+);
+
 CREATE UNLOGGED TABLE users_forum
 (
     nickname CITEXT NOT NULL,
@@ -86,6 +100,111 @@ CREATE UNLOGGED TABLE users_forum
     UNIQUE (nickname, slug)
 );
 
+-- Unlike the forum tables, the outbox and the webhooks are logged, so a
+-- crash loses neither pending events nor registered webhooks.
+CREATE TABLE outbox
+(
+    id         BIGSERIAL PRIMARY KEY,
+    topic      TEXT  NOT NULL,
+    payload    JSONB NOT NULL,
+    created    TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    dispatched BOOLEAN                  DEFAULT FALSE
+);
+
+CREATE TABLE webhook
+(
+    id      SERIAL PRIMARY KEY,
+    url     TEXT NOT NULL,
+    secret  TEXT NOT NULL,
+    topics  TEXT[]                   DEFAULT ARRAY []::TEXT[],
+    created TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE webhook_delivery
+(
+    id           BIGSERIAL PRIMARY KEY,
+    id_outbox    BIGINT NOT NULL,
+    id_webhook   INT    NOT NULL,
+    status       TEXT                     DEFAULT 'pending',
+    attempts     INT                      DEFAULT 0,
+    next_attempt TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    last_error   TEXT,
+
+    FOREIGN KEY (id_outbox) REFERENCES "outbox" (id),
+    FOREIGN KEY (id_webhook) REFERENCES "webhook" (id) ON DELETE CASCADE
+);
+
+CREATE UNLOGGED TABLE idempotency_key
+(
+    key         TEXT PRIMARY KEY,
+    fingerprint TEXT                     NOT NULL,
+    status      INT,
+    body        BYTEA,
+    created     TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    expires     TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE UNLOGGED TABLE thread_lock
+(
+    thread INT PRIMARY KEY,
+    locked TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    FOREIGN KEY (thread) REFERENCES "thread" (id)
+);
+
+CREATE UNLOGGED TABLE moderated
+(
+    kind    TEXT   NOT NULL,
+    id      BIGINT NOT NULL,
+    title   TEXT,
+    message TEXT   NOT NULL,
+    deleted TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    PRIMARY KEY (kind, id)
+);
+
+CREATE UNLOGGED TABLE table_count
+(
+    name  TEXT   NOT NULL,
+    shard INT    NOT NULL,
+    n     BIGINT NOT NULL,
+
+    PRIMARY KEY (name, shard)
+);
+
+CREATE UNLOGGED TABLE reputation
+(
+    nickname CITEXT NOT NULL,
+    forum    CITEXT NOT NULL,
+    score    BIGINT NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (nickname, forum)
+);
+
+-- This file includes the migrations listed here; see
+-- internal/app/repository/migrations.go.
+CREATE TABLE schema_migrations
+(
+    version INT PRIMARY KEY,
+    name    TEXT NOT NULL,
+    applied TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+INSERT INTO schema_migrations (version, name)
+VALUES (0, 'baseline'),
+       (1, 'moderation'),
+       (2, 'users_forum_sync'),
+       (3, 'forum_stats'),
+       (4, 'table_count'),
+       (5, 'reputation'),
+       (6, 'post_votes'),
+       (7, 'logged_outbox'),
+       (8, 'thread_forum_created_id'),
+       (9, 'bulk_guard'),
+       (10, 'bulk_guard_post_votes'),
+       (11, 'subscribed_outbox'),
+       (12, 'post_thread_lock');
+
 CREATE INDEX all_users_forum ON users_forum (nickname, fullname, about, email);
 CLUSTER users_forum USING all_users_forum;
 CREATE INDEX nickname_users_forum ON users_forum using hash (nickname);
@@ -105,6 +224,15 @@ BEGIN
 end
 $update_users_forum$ LANGUAGE plpgsql;
 
+CREATE OR REPLACE FUNCTION sync_user_forum() RETURNS TRIGGER AS
+$sync_user_forum$
+BEGIN
+    UPDATE users_forum SET fullname = NEW.fullname, about = NEW.about, email = NEW.email
+    WHERE nickname = NEW.nickname;
+    return NEW;
+end
+$sync_user_forum$ LANGUAGE plpgsql;
+
 
 CREATE OR REPLACE FUNCTION insertVotes() RETURNS TRIGGER AS
 $update_users_forum$
@@ -141,6 +269,10 @@ DECLARE
     parentPath         BIGINT[];
     first_parent_thread INT;
 BEGIN
+    IF EXISTS (SELECT 1 FROM thread_lock WHERE thread = NEW.thread) THEN
+        RAISE EXCEPTION 'thread is locked' USING ERRCODE = '00423';
+    end if;
+
     IF (NEW.parent IS NULL) THEN
         NEW.path := array_append(new.path, new.id);
     ELSE
@@ -157,18 +289,93 @@ BEGIN
 end
 $update_path$ LANGUAGE plpgsql;
 
+CREATE OR REPLACE FUNCTION writeOutbox() RETURNS TRIGGER AS
+$write_outbox$
+DECLARE
+    event TEXT := TG_ARGV[0] || CASE TG_OP WHEN 'INSERT' THEN '.created' ELSE '.updated' END;
+BEGIN
+    IF EXISTS (SELECT 1 FROM webhook w WHERE cardinality(w.topics) = 0 OR event = ANY(w.topics) OR TG_ARGV[0] = ANY(w.topics)) THEN
+        INSERT INTO outbox (topic, payload) VALUES (event, row_to_json(NEW));
+    END IF;
+    return NEW;
+end
+$write_outbox$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION count_rows() RETURNS TRIGGER AS
+$count_rows$
+DECLARE
+    delta BIGINT;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        SELECT count(*) FROM new_rows INTO delta;
+    ELSE
+        SELECT -count(*) FROM old_rows INTO delta;
+    END IF;
+    IF delta <> 0 THEN
+        INSERT INTO table_count (name, shard, n) VALUES (TG_TABLE_NAME, pg_backend_pid() % 16, delta)
+        ON CONFLICT (name, shard) DO UPDATE SET n = table_count.n + EXCLUDED.n;
+    END IF;
+    RETURN NULL;
+end
+$count_rows$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION vote_reputation() RETURNS TRIGGER AS
+$vote_reputation$
+DECLARE
+    delta BIGINT;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        delta := NEW.voice;
+    ELSE
+        delta := NEW.voice - OLD.voice;
+    END IF;
+    IF delta <> 0 THEN
+        INSERT INTO reputation (nickname, forum, score)
+        SELECT author, forum, delta FROM thread WHERE id = NEW.id_thread
+        ON CONFLICT (nickname, forum) DO UPDATE SET score = reputation.score + EXCLUDED.score;
+    END IF;
+    RETURN NULL;
+end
+$vote_reputation$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION post_vote() RETURNS TRIGGER AS
+$post_vote$
+DECLARE
+    delta BIGINT;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        delta := NEW.voice;
+    ELSE
+        delta := NEW.voice - OLD.voice;
+    END IF;
+    IF delta <> 0 THEN
+        WITH voted AS (
+            UPDATE post SET votes = votes + delta WHERE id = NEW.id_post RETURNING author, forum
+        )
+        INSERT INTO reputation (nickname, forum, score)
+        SELECT author, forum, delta FROM voted
+        ON CONFLICT (nickname, forum) DO UPDATE SET score = reputation.score + EXCLUDED.score;
+    END IF;
+    RETURN NULL;
+end
+$post_vote$ LANGUAGE plpgsql;
+
 CREATE INDEX path_ ON post (path);
 
+-- A bulk load sets forum.bulk for its transaction and does the work of the
+-- triggers it guards once for all of its rows.
 CREATE TRIGGER addThreadInForum
     BEFORE INSERT
     ON thread
     FOR EACH ROW
+    WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
 EXECUTE PROCEDURE updateCountOfThreads();
 
 CREATE TRIGGER add_voice
     BEFORE INSERT
     ON votes
     FOR EACH ROW
+    WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
 EXECUTE PROCEDURE insertVotes();
 
 CREATE TRIGGER edit_voice
@@ -177,24 +384,149 @@ CREATE TRIGGER edit_voice
     FOR EACH ROW
 EXECUTE PROCEDURE updateVotes();
 
+CREATE TRIGGER votes_reputation
+    AFTER INSERT OR UPDATE OF voice
+    ON votes
+    FOR EACH ROW
+    WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+EXECUTE PROCEDURE vote_reputation();
+
+CREATE TRIGGER post_votes_count
+    AFTER INSERT OR UPDATE OF voice
+    ON post_votes
+    FOR EACH ROW
+    WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+EXECUTE PROCEDURE post_vote();
+
 CREATE TRIGGER update_path_trigger
     BEFORE INSERT
     ON post
     FOR EACH ROW
+    WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
 EXECUTE PROCEDURE updatePath();
 
 CREATE TRIGGER thread_insert_user_forum
     AFTER INSERT
     ON thread
     FOR EACH ROW
+    WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
 EXECUTE PROCEDURE update_user_forum();
 
 CREATE TRIGGER post_insert_user_forum
     AFTER INSERT
     ON post
     FOR EACH ROW
+    WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
 EXECUTE PROCEDURE update_user_forum();
 
+CREATE TRIGGER users_update_user_forum
+    AFTER UPDATE OF fullname, about, email
+    ON users
+    FOR EACH ROW
+EXECUTE PROCEDURE sync_user_forum();
+
+
+CREATE TRIGGER users_count_insert
+    AFTER INSERT
+    ON users
+    REFERENCING NEW TABLE AS new_rows
+    FOR EACH STATEMENT
+    WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+EXECUTE PROCEDURE count_rows();
+
+CREATE TRIGGER users_count_delete
+    AFTER DELETE
+    ON users
+    REFERENCING OLD TABLE AS old_rows
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE count_rows();
+
+CREATE TRIGGER forum_count_insert
+    AFTER INSERT
+    ON forum
+    REFERENCING NEW TABLE AS new_rows
+    FOR EACH STATEMENT
+    WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+EXECUTE PROCEDURE count_rows();
+
+CREATE TRIGGER forum_count_delete
+    AFTER DELETE
+    ON forum
+    REFERENCING OLD TABLE AS old_rows
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE count_rows();
+
+CREATE TRIGGER thread_count_insert
+    AFTER INSERT
+    ON thread
+    REFERENCING NEW TABLE AS new_rows
+    FOR EACH STATEMENT
+    WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+EXECUTE PROCEDURE count_rows();
+
+CREATE TRIGGER thread_count_delete
+    AFTER DELETE
+    ON thread
+    REFERENCING OLD TABLE AS old_rows
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE count_rows();
+
+CREATE TRIGGER post_count_insert
+    AFTER INSERT
+    ON post
+    REFERENCING NEW TABLE AS new_rows
+    FOR EACH STATEMENT
+    WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+EXECUTE PROCEDURE count_rows();
+
+CREATE TRIGGER post_count_delete
+    AFTER DELETE
+    ON post
+    REFERENCING OLD TABLE AS old_rows
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE count_rows();
+
+CREATE TRIGGER users_outbox
+    AFTER INSERT OR UPDATE OF fullname, about, email
+    ON users
+    FOR EACH ROW
+    WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+EXECUTE PROCEDURE writeOutbox('user');
+
+CREATE TRIGGER forum_outbox
+    AFTER INSERT
+    ON forum
+    FOR EACH ROW
+    WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+EXECUTE PROCEDURE writeOutbox('forum');
+
+CREATE TRIGGER thread_outbox
+    AFTER INSERT OR UPDATE OF title, message
+    ON thread
+    FOR EACH ROW
+    WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+EXECUTE PROCEDURE writeOutbox('thread');
+
+CREATE TRIGGER post_outbox
+    AFTER INSERT OR UPDATE OF message
+    ON post
+    FOR EACH ROW
+    WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+EXECUTE PROCEDURE writeOutbox('post');
+
+CREATE TRIGGER votes_outbox
+    AFTER INSERT OR UPDATE OF voice
+    ON votes
+    FOR EACH ROW
+    WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+EXECUTE PROCEDURE writeOutbox('vote');
+
+CREATE TRIGGER post_votes_outbox
+    AFTER INSERT OR UPDATE OF voice
+    ON post_votes
+    FOR EACH ROW
+    WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+EXECUTE PROCEDURE writeOutbox('post_vote');
 
 CREATE INDEX IF NOT EXISTS user_nickname ON users using hash (nickname);
 CREATE INDEX IF NOT EXISTS user_email ON users using hash (email);
@@ -205,6 +537,11 @@ CREATE INDEX IF NOT EXISTS  thr_slug ON thread using hash (slug);
 CREATE INDEX IF NOT EXISTS  thr_date ON thread (created);
 CREATE INDEX IF NOT EXISTS  thr_forum ON thread using hash (forum);
 CREATE INDEX IF NOT EXISTS  thr_forum_date ON thread (forum, created);
+CREATE INDEX IF NOT EXISTS thr_forum_created_id ON thread (forum, created, id);
+CREATE INDEX IF NOT EXISTS post_forum_created ON post (forum, created);
+CREATE INDEX IF NOT EXISTS votes_created ON votes (created);
+CREATE INDEX IF NOT EXISTS post_votes_created ON post_votes (created);
+CREATE INDEX IF NOT EXISTS reputation_forum_score ON reputation (forum, score DESC);
 CREATE INDEX IF NOT EXISTS post_id_path on post (id, (path[1]));
 CREATE INDEX IF NOT EXISTS post_thread_id_path1_parent on post (thread, id, (path[1]), parent);
 CREATE INDEX IF NOT EXISTS post_thread_path_id on post (thread, path, id);
@@ -214,3 +551,7 @@ CREATE INDEX IF NOT EXISTS post_thr_id ON post (thread);
 CREATE UNIQUE INDEX IF NOT EXISTS  vote_unique on votes (nickname, id_thread);
 CREATE INDEX IF NOT EXISTS post_path1_path_id_desc ON post ((path[1]) DESC, path, id);
 CREATE INDEX IF NOT EXISTS post_path1_path_id_asc ON post ((path[1]) DESC, path, id);
+CREATE INDEX IF NOT EXISTS outbox_not_dispatched ON outbox (id) WHERE NOT dispatched;
+CREATE INDEX IF NOT EXISTS webhook_delivery_pending ON webhook_delivery (next_attempt) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_delivery_outbox ON webhook_delivery (id_outbox);
+CREATE INDEX IF NOT EXISTS idempotency_key_expires ON idempotency_key (expires);
\ No newline at end of file
@@ -0,0 +1,236 @@
+package adaptor
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/valyala/fasthttp"
+)
+
+// NewFastHTTPHandler works like fasthttpadaptor.NewFastHTTPHandler, but the
+// wrapped handler may call Flush on its http.ResponseWriter for the requests
+// flushes reports. Those are served on a goroutine of their own: everything
+// written before the first Flush is buffered as usual; after it the status
+// line and headers are sent and the rest of the body is streamed to the
+// client. The other requests, and all of them when flushes is nil, are served
+// in place and Flush does nothing.
+func NewFastHTTPHandler(h http.Handler, flushes func(*http.Request) bool) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		r, err := convertRequest(ctx)
+		if err != nil {
+			ctx.Logger().Printf("cannot parse requestURI %q: %s", ctx.RequestURI(), err)
+			ctx.Error("Internal Server Error", fasthttp.StatusInternalServerError)
+			return
+		}
+
+		if flushes == nil || !flushes(r) {
+			w := &responseWriter{header: make(http.Header)}
+			h.ServeHTTP(w, r)
+			writeHeader(ctx, w.statusCode, w.header, w.body)
+			ctx.Write(w.body)
+
+			return
+		}
+
+		requestCtx, cancel := context.WithCancel(context.Background())
+		w := &responseWriter{
+			ctx:     requestCtx,
+			header:  make(http.Header),
+			flushed: make(chan struct{}),
+			done:    make(chan struct{}),
+			chunks:  make(chan []byte),
+		}
+
+		go func() {
+			defer close(w.done)
+			h.ServeHTTP(w, r.WithContext(requestCtx))
+		}()
+
+		select {
+		case <-w.done:
+			cancel()
+			writeHeader(ctx, w.statusCode, w.header, w.body)
+			ctx.Write(w.body)
+		case <-w.flushed:
+			writeHeader(ctx, w.sentStatus, w.sent, w.body)
+			ctx.SetBodyStreamWriter(func(bw *bufio.Writer) {
+				defer cancel()
+
+				if _, err := bw.Write(w.body); err != nil {
+					return
+				}
+				if err := bw.Flush(); err != nil {
+					return
+				}
+
+				for {
+					select {
+					case chunk := <-w.chunks:
+						if chunk == nil {
+							err = bw.Flush()
+						} else {
+							_, err = bw.Write(chunk)
+						}
+						if err != nil {
+							return
+						}
+					case <-w.done:
+						bw.Flush()
+						return
+					}
+				}
+			})
+		}
+	}
+}
+
+func convertRequest(ctx *fasthttp.RequestCtx) (*http.Request, error) {
+	var r http.Request
+
+	body := ctx.PostBody()
+	r.Method = string(ctx.Method())
+	r.Proto = "HTTP/1.1"
+	r.ProtoMajor = 1
+	r.ProtoMinor = 1
+	r.RequestURI = string(ctx.RequestURI())
+	r.ContentLength = int64(len(body))
+	r.Host = string(ctx.Host())
+	r.RemoteAddr = ctx.RemoteAddr().String()
+
+	header := make(http.Header)
+	ctx.Request.Header.VisitAll(func(k, v []byte) {
+		key := string(k)
+		value := string(v)
+		if key == "Transfer-Encoding" {
+			r.TransferEncoding = append(r.TransferEncoding, value)
+		} else {
+			header.Add(key, value)
+		}
+	})
+	r.Header = header
+	r.Body = &requestBody{body}
+
+	rURL, err := url.ParseRequestURI(r.RequestURI)
+	if err != nil {
+		return nil, err
+	}
+	r.URL = rURL
+
+	return &r, nil
+}
+
+func writeHeader(ctx *fasthttp.RequestCtx, statusCode int, header http.Header, body []byte) {
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	ctx.SetStatusCode(statusCode)
+
+	for key, values := range header {
+		for i, value := range values {
+			if i == 0 {
+				ctx.Response.Header.Set(key, value)
+			} else {
+				ctx.Response.Header.Add(key, value)
+			}
+		}
+	}
+
+	if header.Get(fasthttp.HeaderContentType) == "" {
+		l := 512
+		if len(body) < l {
+			l = len(body)
+		}
+		ctx.Response.Header.Set(fasthttp.HeaderContentType, http.DetectContentType(body[:l]))
+	}
+}
+
+type requestBody struct {
+	b []byte
+}
+
+func (r *requestBody) Read(p []byte) (int, error) {
+	if len(r.b) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.b)
+	r.b = r.b[n:]
+	return n, nil
+}
+
+func (r *requestBody) Close() error {
+	r.b = r.b[:0]
+	return nil
+}
+
+type responseWriter struct {
+	ctx        context.Context
+	statusCode int
+	header     http.Header
+	body       []byte
+	streaming  bool
+	flushed    chan struct{}
+	done       chan struct{}
+	chunks     chan []byte
+
+	// sentStatus and sent are copied by the first Flush, before it hands
+	// the response over; the handler may still change the originals.
+	sentStatus int
+	sent       http.Header
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if !w.streaming {
+		w.body = append(w.body, p...)
+		return len(p), nil
+	}
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	chunk := make([]byte, len(p))
+	copy(chunk, p)
+
+	select {
+	case w.chunks <- chunk:
+		return len(p), nil
+	case <-w.ctx.Done():
+		return 0, w.ctx.Err()
+	}
+}
+
+func (w *responseWriter) Flush() {
+	if w.flushed == nil {
+		return
+	}
+
+	if !w.streaming {
+		w.streaming = true
+		w.sentStatus = w.statusCode
+		w.sent = make(http.Header, len(w.header))
+		for key, values := range w.header {
+			w.sent[key] = append([]string(nil), values...)
+		}
+		close(w.flushed)
+
+		return
+	}
+
+	select {
+	case w.chunks <- nil:
+	case <-w.ctx.Done():
+	}
+}
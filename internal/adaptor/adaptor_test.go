@@ -0,0 +1,54 @@
+package adaptor_test
+
+import (
+	"net/http"
+	"testing"
+	"tp-db-forum/internal/adaptor"
+
+	"github.com/valyala/fasthttp"
+)
+
+func serve(handler fasthttp.RequestHandler) *fasthttp.RequestCtx {
+	var request fasthttp.Request
+	request.SetRequestURI("/")
+
+	var ctx fasthttp.RequestCtx
+	ctx.Init(&request, nil, nil)
+	handler(&ctx)
+
+	return &ctx
+}
+
+func TestChangesAfterFlush(t *testing.T) {
+	handler := adaptor.NewFastHTTPHandler(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("X-Before", "1")
+		writer.WriteHeader(http.StatusAccepted)
+		writer.Write([]byte("a"))
+		writer.(http.Flusher).Flush()
+
+		writer.Header().Set("X-After", "1")
+		writer.WriteHeader(http.StatusTeapot)
+	}), func(*http.Request) bool { return true })
+
+	ctx := serve(handler)
+	if ctx.Response.StatusCode() != http.StatusAccepted {
+		t.Errorf("status %d, want %d", ctx.Response.StatusCode(), http.StatusAccepted)
+	}
+	if string(ctx.Response.Header.Peek("X-Before")) != "1" || len(ctx.Response.Header.Peek("X-After")) != 0 {
+		t.Errorf("headers %s", ctx.Response.Header.String())
+	}
+}
+
+func TestServedInPlace(t *testing.T) {
+	handler := adaptor.NewFastHTTPHandler(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte("a"))
+		writer.(http.Flusher).Flush()
+		writer.WriteHeader(http.StatusTeapot)
+		writer.Write([]byte("b"))
+	}), nil)
+
+	ctx := serve(handler)
+	if ctx.Response.StatusCode() != http.StatusTeapot || string(ctx.Response.Body()) != "ab" {
+		t.Errorf("got %d %q", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+}
@@ -1,6 +1,8 @@
 package app
 
 import (
+	"io"
+	"time"
 	"tp-db-forum/internal/app/models"
 )
 
@@ -16,20 +18,76 @@ type Repository interface {
 	InsertThread(thread models.Thread) (models.Thread, error)
 	SelectThreadBySlug(slug string) (models.Thread, error)
 	SelectThreadById(id int) (models.Thread, error)
-	InsertPosts(posts []models.Post, thread int) ([]models.Post, error)
+	SelectForumSlugByThreadId(id int) (string, error)
+	InsertPosts(posts []models.Post, thread int, forum string) ([]models.Post, error)
 	UpdateThread(thread models.Thread) (models.Thread, error)
 	InsertVote(vote models.Vote) (models.Vote, error)
 	UpdateVote(vote models.Vote) (models.Vote, error)
-	GetServiceStatus() (map[string]int, error)
+	InsertPostVote(vote models.Vote) (models.Vote, error)
+	UpdatePostVote(vote models.Vote) (models.Vote, error)
+	GetServiceStatus(estimate bool) (map[string]int, error)
 	ClearDatabase() error
 	SelectUsersByForum(slugForum string, parameters models.QueryParameters) ([]models.User, error)
+	StreamUsersByForum(slugForum string, parameters models.QueryParameters, each func(models.User) error) error
 	SelectThreadsByForum(slugForum string, parameters models.QueryParameters) ([]models.Thread, error)
+	StreamThreadsByForum(slugForum string, parameters models.QueryParameters, each func(models.Thread) error) error
 	SelectPostById(id int) (models.Post, error)
-	UpdatePost(id int, message string) (models.Post, error)
+	UpdatePost(id int, message string, version int64) (models.Post, error)
 	SelectPostsByThread(thread models.Thread, limit, since int, sort string, desc bool) ([]models.Post, error)
+	StreamPostsByThread(thread models.Thread, limit, since int, sort string, desc bool, each func(models.Post) error) error
 	SelectThreadByForum(forum string) (models.Thread, error)
 
 	SelectThreadIdBySlug(slug string) (int, error)
+
+	SelectThreadsByForumCursor(slugForum string, limit int, cursor models.Cursor) ([]models.Thread, bool, error)
+	SelectUsersByForumCursor(slugForum string, limit int, cursor models.Cursor) ([]models.User, bool, error)
+	SelectPostsByThreadCursor(thread models.Thread, limit int, cursor models.Cursor) ([]models.Post, bool, error)
+	CountThreadsByForum(slugForum string, estimate bool) (int, error)
+	CountUsersByForum(slugForum string, estimate bool) (int, error)
+	CountPostsByThread(thread models.Thread, estimate bool) (int, error)
+
+	SelectUsersByNicknames(nicknames []string) ([]models.User, error)
+	SelectForumsBySlugs(slugs []string) ([]models.Forum, error)
+	SelectThreadsByIds(ids []int) ([]models.Thread, error)
+	SelectThreadsByIdsOrSlugs(ids []int, slugs []string) ([]models.Thread, error)
+	SelectPostsByIds(ids []int) ([]models.Post, error)
+
+	InsertWebhook(webhook models.Webhook) (models.Webhook, error)
+	SelectWebhooks() ([]models.Webhook, error)
+	DeleteWebhook(id int) error
+	FanOutOutbox(limit int) (int, error)
+	ClaimDueDeliveries(limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	UpdateDelivery(delivery models.WebhookDelivery, retryIn time.Duration) error
+	SelectDeadDeliveries(limit int) ([]models.WebhookDelivery, error)
+	ReplayDelivery(id int64) error
+	ReplayOutboxEvent(id int64) (int, error)
+	PruneOutbox(olderThan time.Duration) error
+
+	InsertIdempotencyKey(key, fingerprint string, ttl time.Duration) (bool, error)
+	SelectIdempotencyKey(key string) (models.IdempotencyRecord, error)
+	CompleteIdempotencyKey(key string, status int, body []byte) error
+	DeleteIdempotencyKey(key string) error
+	PruneIdempotencyKeys() error
+
+	ExportForum(slug string, each func(item interface{}) error) error
+	ImportForum(next func() (interface{}, error), options models.ImportOptions) (models.ImportReport, error)
+	BulkLoad(sources models.BulkSources) (models.BulkReport, error)
+
+	LockThread(id int, locked bool) error
+	ModerateThread(id int, deleted bool) error
+	ModeratePost(id int, deleted bool) error
+	SelectModeration(kind string, id int) (models.Moderation, error)
+	RenameUser(nickname, newNickname string) (models.User, error)
+	ReconcileCounters(fix bool) (models.CounterReport, error)
+	ReconcileForumUsers(slug string, fix bool) (models.MembershipReport, error)
+	Migrate() ([]models.Migration, error)
+	SelectMigrations() ([]models.Migration, error)
+
+	SelectForumStats(slug string, options models.StatsOptions) (models.ForumStats, error)
+	SelectPoolStats() models.PoolStats
+
+	SelectReputation(nickname string) (int64, error)
+	SelectLeaderboard(options models.LeaderboardOptions) (models.Leaderboard, error)
 }
 
 type UseCase interface {
@@ -48,14 +106,61 @@ type UseCase interface {
 	EditThread(thread models.Thread) (models.Thread, error)
 	AddVote(vote models.Vote) (models.Vote, error)
 	UpdateVote(vote models.Vote) (models.Vote, error)
-	GetServiceStatus() (map[string]int, error)
+	AddPostVote(vote models.Vote) (models.Vote, error)
+	UpdatePostVote(vote models.Vote) (models.Vote, error)
+	GetServiceStatus(estimate bool) (map[string]int, error)
 	ClearDatabase() error
 	CheckUsersByForum(slugForum string, parameters models.QueryParameters) ([]models.User, error)
+	StreamUsersByForum(slugForum string, parameters models.QueryParameters, each func(models.User) error) error
 	CheckThreadsByForum(slugForum string, parameters models.QueryParameters) ([]models.Thread, error)
+	StreamThreadsByForum(slugForum string, parameters models.QueryParameters, each func(models.Thread) error) error
 	CheckPostById(id int, related []string) (map[string]interface{}, error)
-	EditPost(id int, message string) (models.Post, error)
+	EditPost(id int, message string, version int64) (models.Post, error)
 	CheckPostsByThread(thread models.Thread, limit, since int, sort string, desc bool) ([]models.Post, error)
+	StreamPostsByThread(thread models.Thread, limit, since int, sort string, desc bool, each func(models.Post) error) error
 	CheckThreadByForum(forum string) (models.Thread, error)
 
 	CheckThreadIdBySlug(slug string) (int, error)
+
+	CheckThreadsPageByForum(slugForum string, parameters models.QueryParameters) ([]models.Thread, models.Page, error)
+	CheckUsersPageByForum(slugForum string, parameters models.QueryParameters) ([]models.User, models.Page, error)
+	CheckPostsPageByThread(thread models.Thread, limit, since int, sort string, desc bool, cursor *models.Cursor) ([]models.Post, models.Page, error)
+	CountThreadsByForum(slugForum string, estimate bool) (int, error)
+	CountUsersByForum(slugForum string, estimate bool) (int, error)
+	CountPostsByThread(thread models.Thread, estimate bool) (int, error)
+
+	CheckUsersByNicknames(nicknames []string) ([]models.User, error)
+	CheckForumsBySlugs(slugs []string) ([]models.Forum, error)
+	CheckThreadsByIds(ids []int) ([]models.Thread, error)
+	CheckThreadsByKeys(keys []string) ([]models.Thread, error)
+	CheckPostsByIds(ids []int) ([]models.Post, error)
+
+	CreateWebhook(webhook models.Webhook) (models.Webhook, error)
+	CheckWebhooks() ([]models.Webhook, error)
+	RemoveWebhook(id int) error
+	CheckDeadDeliveries(limit int) ([]models.WebhookDelivery, error)
+	ReplayDelivery(id int64) error
+	ReplayEvent(id int64) (int, error)
+
+	ExportForum(slug string, w io.Writer) error
+	ImportForum(r io.Reader, options models.ImportOptions) (models.ImportReport, error)
+	BulkLoad(sources models.BulkSources) (models.BulkReport, error)
+
+	LockThread(id int, locked bool) error
+	DeleteThread(id int) error
+	RestoreThread(id int) error
+	DeletePost(id int) error
+	RestorePost(id int) error
+	CheckModeration(kind string, id int) (models.Moderation, error)
+	RenameUser(nickname, newNickname string) (models.User, error)
+	ReconcileCounters(fix bool) (models.CounterReport, error)
+	ReconcileForumUsers(slug string, fix bool) (models.MembershipReport, error)
+	Migrate() ([]models.Migration, error)
+	CheckMigrations() ([]models.Migration, error)
+
+	CheckForumStats(slug string, options models.StatsOptions) (models.ForumStats, error)
+	CheckServiceInfo(estimate bool) (models.ServiceInfo, error)
+
+	CheckUserProfile(nickname string) (models.UserProfile, error)
+	CheckLeaderboard(options models.LeaderboardOptions) (models.Leaderboard, error)
 }
@@ -0,0 +1,275 @@
+// Package archive reads and writes forum archives: JSON lines of
+// {"type": ..., "data": ...} records, an "archive" record with
+// models.ArchiveInfo first, then the users, the forum, its threads, posts
+// in id order, thread votes and post votes, and a "trailer" record with models.ArchiveTrailer
+// last.
+package archive
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/go-openapi/strfmt"
+	"io"
+	"time"
+	"tp-db-forum/internal/app/models"
+)
+
+const MediaType = "application/x-ndjson"
+
+type record struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+func recordType(item interface{}) (string, error) {
+	switch item.(type) {
+	case models.ArchiveInfo:
+		return "archive", nil
+	case models.ArchiveTrailer:
+		return "trailer", nil
+	case models.User:
+		return "user", nil
+	case models.Forum:
+		return "forum", nil
+	case models.Thread:
+		return "thread", nil
+	case models.ArchivePost:
+		return "post", nil
+	case models.ArchiveVote:
+		return "vote", nil
+	case models.ArchivePostVote:
+		return "post_vote", nil
+	}
+
+	return "", fmt.Errorf("archive: can't write %T", item)
+}
+
+// Writer writes an archive of one forum. The opening record is written
+// along with the first item, so nothing is written for a forum that turns
+// out not to exist.
+type Writer struct {
+	w       *bufio.Writer
+	forum   string
+	started bool
+	counts  map[string]int
+}
+
+func NewWriter(w io.Writer, forum string) *Writer {
+	return &Writer{w: bufio.NewWriterSize(w, 64*1024), forum: forum, counts: map[string]int{}}
+}
+
+func (w *Writer) write(item interface{}) error {
+	kind, err := recordType(item)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(record{Type: kind, Data: data})
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.w.Write(line); err != nil {
+		return err
+	}
+
+	return w.w.WriteByte('\n')
+}
+
+func (w *Writer) Write(item interface{}) error {
+	if !w.started {
+		w.started = true
+
+		err := w.write(models.ArchiveInfo{
+			Version:  models.ArchiveVersion,
+			Forum:    w.forum,
+			Exported: strfmt.DateTime(time.Now().UTC()).String(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := w.write(item); err != nil {
+		return err
+	}
+
+	kind, _ := recordType(item)
+	w.counts[kind]++
+
+	return nil
+}
+
+// Close ends the archive with its trailer and sends what is buffered to the
+// underlying writer. An archive that isn't closed is rejected on import.
+func (w *Writer) Close() error {
+	if err := w.write(models.ArchiveTrailer{Counts: w.counts}); err != nil {
+		return err
+	}
+
+	return w.w.Flush()
+}
+
+// Reader reads an archive record by record.
+type Reader struct {
+	scanner *bufio.Scanner
+	line    int
+	counts  map[string]int
+	ended   bool
+	Info    models.ArchiveInfo
+}
+
+// NewReader checks the opening record of the archive.
+func NewReader(r io.Reader) (*Reader, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	reader := &Reader{scanner: scanner, counts: map[string]int{}}
+
+	item, err := reader.next()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: empty", models.ErrInvalidArchive)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info, ok := item.(models.ArchiveInfo)
+	if !ok {
+		return nil, fmt.Errorf("%w: line 1: expected the archive record", models.ErrInvalidArchive)
+	}
+	if info.Version != models.ArchiveVersion {
+		return nil, fmt.Errorf("%w: version %d is not supported", models.ErrInvalidArchive, info.Version)
+	}
+	reader.Info = info
+
+	return reader, nil
+}
+
+func (r *Reader) next() (interface{}, error) {
+	for r.scanner.Scan() {
+		r.line++
+		if len(r.scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var rec record
+		if err := json.Unmarshal(r.scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", models.ErrInvalidArchive, r.line, err)
+		}
+
+		var item interface{}
+		var err error
+		switch rec.Type {
+		case "archive":
+			var info models.ArchiveInfo
+			err = json.Unmarshal(rec.Data, &info)
+			item = info
+		case "trailer":
+			var trailer models.ArchiveTrailer
+			err = json.Unmarshal(rec.Data, &trailer)
+			item = trailer
+		case "user":
+			var user models.User
+			err = json.Unmarshal(rec.Data, &user)
+			item = user
+		case "forum":
+			var forum models.Forum
+			err = json.Unmarshal(rec.Data, &forum)
+			item = forum
+		case "thread":
+			var thread models.Thread
+			err = json.Unmarshal(rec.Data, &thread)
+			item = thread
+		case "post":
+			var post models.ArchivePost
+			err = json.Unmarshal(rec.Data, &post)
+			item = post
+		case "vote":
+			var vote models.ArchiveVote
+			err = json.Unmarshal(rec.Data, &vote)
+			item = vote
+		case "post_vote":
+			var vote models.ArchivePostVote
+			err = json.Unmarshal(rec.Data, &vote)
+			item = vote
+		default:
+			err = fmt.Errorf("unknown record type %q", rec.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", models.ErrInvalidArchive, r.line, err)
+		}
+
+		if rec.Type != "archive" && rec.Type != "trailer" {
+			r.counts[rec.Type]++
+		}
+
+		return item, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: line %d: %v", models.ErrInvalidArchive, r.line+1, err)
+	}
+
+	return nil, io.EOF
+}
+
+// Next returns the next item of the archive, or io.EOF after the last one
+// once the trailer has confirmed that none is missing.
+func (r *Reader) Next() (interface{}, error) {
+	if r.ended {
+		return nil, io.EOF
+	}
+
+	item, err := r.next()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: no trailer, the archive is incomplete", models.ErrInvalidArchive)
+	}
+
+	switch item := item.(type) {
+	case models.ArchiveInfo:
+		return nil, fmt.Errorf("%w: line %d: unexpected archive record", models.ErrInvalidArchive, r.line)
+	case models.ArchiveTrailer:
+		if err := r.end(item); err != nil {
+			return nil, err
+		}
+
+		return nil, io.EOF
+	}
+
+	return item, err
+}
+
+// end checks the trailer against the records read and that nothing
+// follows it.
+func (r *Reader) end(trailer models.ArchiveTrailer) error {
+	line := r.line
+	for kind, count := range trailer.Counts {
+		if r.counts[kind] != count {
+			return fmt.Errorf("%w: line %d: %d %s records, the trailer counts %d", models.ErrInvalidArchive, line, r.counts[kind], kind, count)
+		}
+	}
+	for kind, count := range r.counts {
+		if _, ok := trailer.Counts[kind]; !ok {
+			return fmt.Errorf("%w: line %d: %d %s records, the trailer counts none", models.ErrInvalidArchive, line, count, kind)
+		}
+	}
+
+	if _, err := r.next(); err != io.EOF {
+		if err != nil {
+			return err
+		}
+
+		return fmt.Errorf("%w: line %d: record after the trailer", models.ErrInvalidArchive, r.line)
+	}
+
+	r.ended = true
+
+	return nil
+}
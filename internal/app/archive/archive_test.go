@@ -0,0 +1,79 @@
+package archive_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"tp-db-forum/internal/app/archive"
+	"tp-db-forum/internal/app/models"
+)
+
+func written(t *testing.T) []byte {
+	var buffer bytes.Buffer
+	writer := archive.NewWriter(&buffer, "forum")
+	for _, item := range []interface{}{
+		models.User{Nickname: "alice"},
+		models.Forum{Slug: "forum", User: "alice"},
+		models.Thread{Id: 1, Author: "alice", Forum: "forum"},
+		models.ArchivePost{Id: 1, Author: "alice", Thread: 1, Path: []int64{1}},
+		models.ArchivePost{Id: 2, Author: "alice", Thread: 1, Path: []int64{1, 2}},
+		models.ArchiveVote{Nickname: "alice", Voice: 1, Thread: 1},
+		models.ArchivePostVote{Nickname: "alice", Voice: -1, Post: 2},
+	} {
+		if err := writer.Write(item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func readAll(data []byte) (int, error) {
+	reader, err := archive.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+
+	items := 0
+	for {
+		_, err := reader.Next()
+		if err == io.EOF {
+			return items, nil
+		}
+		if err != nil {
+			return items, err
+		}
+		items++
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	items, err := readAll(written(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items != 7 {
+		t.Errorf("read %d items, want 7", items)
+	}
+}
+
+func TestIncompleteArchives(t *testing.T) {
+	data := written(t)
+	lines := strings.SplitAfter(string(data), "\n")
+
+	for name, archive := range map[string]string{
+		"without trailer":     strings.Join(lines[:len(lines)-2], ""),
+		"without a post":      strings.Join(append(lines[:4:4], lines[5:]...), ""),
+		"after the trailer":   string(data) + lines[1],
+		"with a record added": strings.Join(append(lines[:5:5], lines[4:]...), ""),
+	} {
+		if _, err := readAll([]byte(archive)); !errors.Is(err, models.ErrInvalidArchive) {
+			t.Errorf("%s: got %v, want an invalid archive", name, err)
+		}
+	}
+}
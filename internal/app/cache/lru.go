@@ -0,0 +1,205 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Size      int    `json:"size"`
+}
+
+type entry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// LRU is a size bounded cache whose entries also expire after ttl. It is
+// safe for concurrent use.
+//
+// Values read from a source that writers change go through Generation,
+// Invalidate and SetSince: a reader that takes the generation before reading
+// the source doesn't cache what it read if a write to the key was in flight
+// or finished in the meantime, so a row read before a commit can't outlive
+// the invalidation.
+type LRU struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	order *list.List
+	items map[string]*list.Element
+
+	// clock counts finished writes. writing holds the writes in flight per
+	// key and written the clock each key's last write finished at; it is
+	// dropped once it outgrows size, and since is the clock it was dropped
+	// at.
+	clock   uint64
+	since   uint64
+	writing map[string]int
+	written map[string]uint64
+
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+func NewLRU(size int, ttl time.Duration) *LRU {
+	return &LRU{
+		size:  size,
+		ttl:   ttl,
+		order: list.New(),
+		items: make(map[string]*list.Element, size),
+
+		writing: make(map[string]int),
+		written: make(map[string]uint64),
+	}
+}
+
+func (c *LRU) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		atomic.AddUint64(&c.misses, 1)
+
+		return nil, false
+	}
+
+	e := element.Value.(*entry)
+	if c.ttl > 0 && time.Now().After(e.expires) {
+		c.remove(element)
+		atomic.AddUint64(&c.misses, 1)
+
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	atomic.AddUint64(&c.hits, 1)
+
+	return e.value, true
+}
+
+func (c *LRU) Set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(key, value)
+}
+
+func (c *LRU) set(key string, value interface{}) {
+	expires := time.Now().Add(c.ttl)
+	if element, ok := c.items[key]; ok {
+		e := element.Value.(*entry)
+		e.value = value
+		e.expires = expires
+		c.order.MoveToFront(element)
+
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry{key: key, value: value, expires: expires})
+
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+		atomic.AddUint64(&c.evictions, 1)
+	}
+}
+
+// Generation is taken before reading a value from the source and passed to
+// SetSince along with it.
+func (c *LRU) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.clock
+}
+
+// SetSince caches value unless a write to key was in flight or finished
+// since generation was taken.
+func (c *LRU) SetSince(key string, value interface{}, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.writing[key] > 0 || generation < c.since || c.written[key] > generation {
+		return
+	}
+
+	c.set(key, value)
+}
+
+// Invalidate drops key before a write to it. The returned func is called
+// once the write is done, committed or not.
+func (c *LRU) Invalidate(key string) func() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.writing[key]++
+	if element, ok := c.items[key]; ok {
+		c.remove(element)
+	}
+
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.clock++
+		if c.writing[key]--; c.writing[key] == 0 {
+			delete(c.writing, key)
+		}
+		c.written[key] = c.clock
+		if len(c.written) > c.size {
+			c.forget()
+		}
+	}
+}
+
+func (c *LRU) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		c.remove(element)
+	}
+}
+
+func (c *LRU) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.items = make(map[string]*list.Element, c.size)
+	c.clock++
+	c.forget()
+}
+
+func (c *LRU) Stats() Stats {
+	c.mu.Lock()
+	size := c.order.Len()
+	c.mu.Unlock()
+
+	return Stats{
+		Hits:      atomic.LoadUint64(&c.hits),
+		Misses:    atomic.LoadUint64(&c.misses),
+		Evictions: atomic.LoadUint64(&c.evictions),
+		Size:      size,
+	}
+}
+
+// forget drops the per key write clocks; SetSince then refuses every
+// generation taken before.
+func (c *LRU) forget() {
+	c.written = make(map[string]uint64)
+	c.since = c.clock
+}
+
+func (c *LRU) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.items, element.Value.(*entry).key)
+}
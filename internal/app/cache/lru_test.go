@@ -0,0 +1,52 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+	"tp-db-forum/internal/app/cache"
+)
+
+func TestSetSinceAfterWrite(t *testing.T) {
+	for name, read := range map[string]func(c *cache.LRU, write func()) uint64{
+		// The reader misses and reads the row before the write commits.
+		"before the write": func(c *cache.LRU, write func()) uint64 {
+			generation := c.Generation()
+			write()
+			return generation
+		},
+		// The reader misses while the write is in flight.
+		"during the write": func(c *cache.LRU, write func()) uint64 {
+			return c.Generation()
+		},
+	} {
+		c := cache.NewLRU(10, time.Minute)
+		c.Set("forum", "old")
+
+		done := c.Invalidate("forum")
+		generation := read(c, done)
+		c.SetSince("forum", "old", generation)
+		done()
+
+		if value, ok := c.Get("forum"); ok {
+			t.Errorf("%s: cached %v", name, value)
+		}
+	}
+}
+
+func TestSetSince(t *testing.T) {
+	c := cache.NewLRU(1, time.Minute)
+	c.Invalidate("forum")()
+	c.Invalidate("thread")()
+
+	generation := c.Generation()
+	c.SetSince("forum", "new", generation)
+	if value, ok := c.Get("forum"); !ok || value != "new" {
+		t.Errorf("got %v, %v after the write", value, ok)
+	}
+
+	c.Purge()
+	c.SetSince("forum", "old", generation)
+	if _, ok := c.Get("forum"); ok {
+		t.Error("cached a value read before Purge")
+	}
+}
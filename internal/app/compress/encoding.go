@@ -0,0 +1,130 @@
+package compress
+
+import (
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	Brotli  = "br"
+	Gzip    = "gzip"
+	Deflate = "deflate"
+)
+
+// preference breaks ties between encodings the client accepts equally.
+var preference = []string{Brotli, Gzip, Deflate}
+
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+type encoders map[string]*sync.Pool
+
+func newEncoders(config Config) encoders {
+	return encoders{
+		Brotli: {New: func() interface{} {
+			return brotli.NewWriterLevel(nil, config.BrotliLevel)
+		}},
+		Gzip: {New: func() interface{} {
+			w, _ := gzip.NewWriterLevel(nil, config.GzipLevel)
+			return w
+		}},
+		Deflate: {New: func() interface{} {
+			w, _ := flate.NewWriter(nil, config.GzipLevel)
+			return w
+		}},
+	}
+}
+
+func (e encoders) get(encoding string, w io.Writer) encoder {
+	enc := e[encoding].Get().(encoder)
+	enc.Reset(w)
+
+	return enc
+}
+
+func (e encoders) put(encoding string, enc encoder) {
+	enc.Reset(nil)
+	e[encoding].Put(enc)
+}
+
+// negotiate picks the encoding for a response from the request's
+// Accept-Encoding, or returns "" when the body should be sent as is.
+func negotiate(header string) string {
+	if header == "" {
+		return ""
+	}
+
+	weights := make(map[string]float64)
+	wildcard := -1.0
+	for _, item := range strings.Split(header, ",") {
+		parts := strings.Split(item, ";")
+		coding := strings.ToLower(strings.TrimSpace(parts[0]))
+
+		weight := 1.0
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					weight = q
+				}
+			}
+		}
+
+		if coding == "*" {
+			wildcard = weight
+		} else {
+			weights[coding] = weight
+		}
+	}
+
+	best, bestWeight := "", 0.0
+	for _, coding := range preference {
+		weight, ok := weights[coding]
+		if !ok {
+			weight = wildcard
+		}
+
+		if weight > bestWeight {
+			best, bestWeight = coding, weight
+		}
+	}
+
+	return best
+}
+
+// compressible reports whether a response with the content type is worth
+// compressing. Everything this API sends is text of some kind; this is
+// here for whatever isn't.
+func compressible(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	switch {
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	case strings.HasSuffix(mediaType, "json"), strings.HasSuffix(mediaType, "xml"):
+		return true
+	case mediaType == "application/javascript", mediaType == "application/x-ndjson":
+		return true
+	}
+
+	return false
+}
+
+func bodyAllowed(request *http.Request, status int) bool {
+	return request.Method != http.MethodHead &&
+		status >= http.StatusOK &&
+		status != http.StatusNoContent &&
+		status != http.StatusNotModified
+}
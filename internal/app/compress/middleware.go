@@ -0,0 +1,228 @@
+package compress
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+	"strings"
+)
+
+type Config struct {
+	// MinSize is the smallest body that gets compressed. Responses that
+	// flush before reaching it are compressed anyway, since more is coming.
+	MinSize     int
+	GzipLevel   int
+	BrotliLevel int
+}
+
+var DefaultConfig = Config{
+	MinSize:     1024,
+	GzipLevel:   5,
+	BrotliLevel: 4,
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	request  *http.Request
+	encoders encoders
+	encoding string
+	minSize  int
+	// encodedMatch is whether If-None-Match named the tag of the body in
+	// encoding, which a 304 then repeats.
+	encodedMatch bool
+
+	status  int
+	buffer  []byte
+	started bool
+	encoder encoder
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if !w.started {
+		w.buffer = append(w.buffer, p...)
+		if len(w.buffer) >= w.minSize {
+			if err := w.start(true); err != nil {
+				return 0, err
+			}
+		}
+
+		return len(p), nil
+	}
+
+	if w.encoder != nil {
+		return w.encoder.Write(p)
+	}
+
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush sends what was written so far. A handler that flushes is streaming
+// a long body, so the response is compressed from here on whatever its
+// size.
+func (w *responseWriter) Flush() {
+	if !w.started {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		if w.start(true) != nil {
+			return
+		}
+	}
+
+	if w.encoder != nil {
+		if w.encoder.Flush() != nil {
+			return
+		}
+	}
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// start sends the headers, deciding on the encoding, and the buffered part
+// of the body.
+func (w *responseWriter) start(compress bool) error {
+	w.started = true
+
+	header := w.Header()
+	if compress && bodyAllowed(w.request, w.status) &&
+		header.Get("Content-Encoding") == "" && compressible(header.Get("Content-Type")) {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		w.encoder = w.encoders.get(w.encoding, w.ResponseWriter)
+	}
+
+	if etag := header.Get("ETag"); etag != "" &&
+		(w.encoder != nil || w.status == http.StatusNotModified && w.encodedMatch) {
+		header.Set("ETag", encodedETag(etag, w.encoding))
+	}
+
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+
+	buffer := w.buffer
+	w.buffer = nil
+	if len(buffer) == 0 {
+		return nil
+	}
+
+	var err error
+	if w.encoder != nil {
+		_, err = w.encoder.Write(buffer)
+	} else {
+		_, err = w.ResponseWriter.Write(buffer)
+	}
+
+	return err
+}
+
+func (w *responseWriter) close() {
+	if !w.started {
+		w.start(false)
+	}
+
+	if w.encoder != nil {
+		w.encoder.Close()
+		w.encoders.put(w.encoding, w.encoder)
+		w.encoder = nil
+	}
+}
+
+// Middleware compresses responses with gzip, deflate or brotli, whichever
+// the client prefers in Accept-Encoding, except on the routes with the
+// given names. Bodies smaller than config.MinSize are sent as is.
+//
+// A strong ETag must name the bytes sent, so the ETag of a compressed body
+// gets the encoding as a suffix. The suffixes are stripped from If-Match and
+// If-None-Match of every request before the handler compares them with its
+// own tags.
+func Middleware(config Config, skip ...string) mux.MiddlewareFunc {
+	names := make(map[string]bool, len(skip))
+	for _, route := range skip {
+		names[route] = true
+	}
+
+	pools := newEncoders(config)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			stripETags(request.Header, "If-Match")
+			encoded := stripETags(request.Header, "If-None-Match")
+
+			if route := mux.CurrentRoute(request); route != nil && names[route.GetName()] {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			if !strings.Contains(writer.Header().Get("Vary"), "Accept-Encoding") {
+				writer.Header().Add("Vary", "Accept-Encoding")
+			}
+
+			encoding := negotiate(request.Header.Get("Accept-Encoding"))
+			if encoding == "" {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			w := &responseWriter{
+				ResponseWriter: writer,
+				request:        request,
+				encoders:       pools,
+				encoding:       encoding,
+				minSize:        config.MinSize,
+				encodedMatch:   encoded[encoding],
+			}
+			defer w.close()
+
+			next.ServeHTTP(w, request)
+		})
+	}
+}
+
+// encodedETag tells the ETag of an encoded response apart from the one of
+// the same entity sent as is.
+func encodedETag(etag, encoding string) string {
+	if !strings.HasSuffix(etag, `"`) {
+		return etag
+	}
+
+	return etag[:len(etag)-1] + "-" + encoding + `"`
+}
+
+// stripETags removes the encoding suffixes from the entity-tags of the
+// given request header and returns the encodings they named.
+func stripETags(header http.Header, name string) map[string]bool {
+	value := header.Get(name)
+	if value == "" || value == "*" {
+		return nil
+	}
+
+	encodings := map[string]bool{}
+	tags := strings.Split(value, ",")
+	for i, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		for _, encoding := range preference {
+			if suffix := "-" + encoding + `"`; strings.HasSuffix(tag, suffix) {
+				tag = tag[:len(tag)-len(suffix)] + `"`
+				encodings[encoding] = true
+				break
+			}
+		}
+		tags[i] = tag
+	}
+
+	header.Set(name, strings.Join(tags, ", "))
+
+	return encodings
+}
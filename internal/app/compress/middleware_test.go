@@ -0,0 +1,148 @@
+package compress_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"tp-db-forum/internal/adaptor"
+	"tp-db-forum/internal/app/compress"
+	"tp-db-forum/internal/app/models"
+
+	"github.com/gorilla/mux"
+	"github.com/valyala/fasthttp"
+)
+
+// postsPayload is a /api/thread/{slug_or_id}/posts listing of limit posts.
+func postsPayload(limit int) []byte {
+	posts := make([]models.Post, limit)
+	for i := range posts {
+		posts[i] = models.Post{
+			Id:      i + 1,
+			Author:  fmt.Sprintf("user%d", i%50),
+			Created: "2020-01-01T00:00:00.000Z",
+			Forum:   "forum",
+			Message: fmt.Sprintf("post %d of the thread, with a message of a usual length for the forum", i),
+			Thread:  1,
+		}
+	}
+
+	body, _ := json.Marshal(posts)
+
+	return body
+}
+
+func newRouter(body []byte) *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/thread/{slug_or_id}/posts", func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.Header().Set("ETag", `"1"`)
+		if request.Header.Get("If-None-Match") == `"1"` {
+			writer.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		writer.Write(body)
+	}).Methods(http.MethodGet)
+	router.Use(compress.Middleware(compress.DefaultConfig))
+
+	return router
+}
+
+func TestEncodedETag(t *testing.T) {
+	large, small := postsPayload(100), postsPayload(1)
+
+	for _, test := range []struct {
+		body     []byte
+		encoding string
+		etag     string
+	}{
+		{large, "", `"1"`},
+		{large, compress.Gzip, `"1-gzip"`},
+		{large, compress.Deflate, `"1-deflate"`},
+		{large, compress.Brotli, `"1-br"`},
+		{small, "", `"1"`},
+		{small, compress.Gzip, `"1"`},
+		{small, compress.Brotli, `"1"`},
+	} {
+		router := newRouter(test.body)
+
+		request := httptest.NewRequest(http.MethodGet, "/api/thread/1/posts", nil)
+		request.Header.Set("Accept-Encoding", test.encoding)
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		etag := recorder.Header().Get("ETag")
+		if etag != test.etag {
+			t.Errorf("%d bytes in %q: ETag %s, want %s", len(test.body), test.encoding, etag, test.etag)
+		}
+
+		request = httptest.NewRequest(http.MethodGet, "/api/thread/1/posts", nil)
+		request.Header.Set("Accept-Encoding", test.encoding)
+		request.Header.Set("If-None-Match", etag)
+
+		recorder = httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		if recorder.Code != http.StatusNotModified || recorder.Header().Get("ETag") != etag {
+			t.Errorf("%d bytes in %q: revalidation got %d with ETag %s", len(test.body), test.encoding, recorder.Code, recorder.Header().Get("ETag"))
+		}
+	}
+}
+
+func TestEncodedETagWithoutEncoding(t *testing.T) {
+	router := newRouter(postsPayload(100))
+
+	request := httptest.NewRequest(http.MethodGet, "/api/thread/1/posts", nil)
+	request.Header.Set("If-None-Match", `"1-gzip"`)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusNotModified {
+		t.Errorf("revalidation without Accept-Encoding got %d", recorder.Code)
+	}
+}
+
+// benchmarkPosts serves a listing of limit posts through the fasthttp
+// adaptor, as cmd/main.go does, with the given Accept-Encoding.
+func benchmarkPosts(b *testing.B, limit int, encoding string) {
+	body := postsPayload(limit)
+	handler := adaptor.NewFastHTTPHandler(newRouter(body), nil)
+
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	var sent int
+	for i := 0; i < b.N; i++ {
+		var request fasthttp.Request
+		request.SetRequestURI("/api/thread/1/posts")
+		if encoding != "" {
+			request.Header.Set("Accept-Encoding", encoding)
+		}
+
+		var ctx fasthttp.RequestCtx
+		ctx.Init(&request, nil, nil)
+		handler(&ctx)
+
+		sent = len(ctx.Response.Body())
+	}
+
+	b.ReportMetric(float64(sent), "sent/op")
+}
+
+func BenchmarkPosts100Identity(b *testing.B)   { benchmarkPosts(b, 100, "") }
+func BenchmarkPosts100Gzip(b *testing.B)       { benchmarkPosts(b, 100, compress.Gzip) }
+func BenchmarkPosts100Deflate(b *testing.B)    { benchmarkPosts(b, 100, compress.Deflate) }
+func BenchmarkPosts100Brotli(b *testing.B)     { benchmarkPosts(b, 100, compress.Brotli) }
+func BenchmarkPosts1000Identity(b *testing.B)  { benchmarkPosts(b, 1000, "") }
+func BenchmarkPosts1000Gzip(b *testing.B)      { benchmarkPosts(b, 1000, compress.Gzip) }
+func BenchmarkPosts1000Deflate(b *testing.B)   { benchmarkPosts(b, 1000, compress.Deflate) }
+func BenchmarkPosts1000Brotli(b *testing.B)    { benchmarkPosts(b, 1000, compress.Brotli) }
+func BenchmarkPosts10000Identity(b *testing.B) { benchmarkPosts(b, 10000, "") }
+func BenchmarkPosts10000Gzip(b *testing.B)     { benchmarkPosts(b, 10000, compress.Gzip) }
+func BenchmarkPosts10000Deflate(b *testing.B)  { benchmarkPosts(b, 10000, compress.Deflate) }
+func BenchmarkPosts10000Brotli(b *testing.B)   { benchmarkPosts(b, 10000, compress.Brotli) }
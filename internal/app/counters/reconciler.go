@@ -0,0 +1,125 @@
+package counters
+
+import (
+	"log"
+	"sync"
+	"time"
+	"tp-db-forum/internal/app"
+	"tp-db-forum/internal/app/models"
+)
+
+type Config struct {
+	Interval time.Duration
+	Fix      bool
+}
+
+// A check recounts post, thread and votes in full, so it runs rarely and
+// only reports by default.
+var DefaultConfig = Config{
+	Interval: time.Hour,
+	Fix:      false,
+}
+
+// Stats is what the last reconciliation found. Drift counts the drifted
+// rows per table.column that are still wrong, so it drops to zero after a
+// fix; Fixed adds up the rows every fix so far has corrected.
+type Stats struct {
+	Runs      int64            `json:"runs"`
+	LastRun   string           `json:"last_run,omitempty"`
+	LastError string           `json:"last_error,omitempty"`
+	Drift     map[string]int64 `json:"drift"`
+	Fixed     int64            `json:"fixed"`
+}
+
+// Reconciler keeps the drift of the counters the triggers maintain as a
+// metric. It wraps the repository, so reconciliations asked for through
+// the use case are recorded the same as the scheduled ones.
+type Reconciler struct {
+	app.Repository
+
+	config Config
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+func NewReconciler(repository app.Repository, config Config) *Reconciler {
+	return &Reconciler{
+		Repository: repository,
+		config:     config,
+		stats:      Stats{Drift: map[string]int64{}},
+	}
+}
+
+func (r *Reconciler) ReconcileCounters(fix bool) (models.CounterReport, error) {
+	report, err := r.Repository.ReconcileCounters(fix)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stats.Runs++
+	r.stats.LastRun = time.Now().UTC().Format(time.RFC3339)
+	if err != nil {
+		r.stats.LastError = err.Error()
+		return report, err
+	}
+	r.stats.LastError = ""
+
+	drift := map[string]int64{}
+	for _, d := range report.Drifts {
+		drift[d.Table+"."+d.Column]++
+	}
+	if fix {
+		r.stats.Fixed += int64(len(report.Drifts))
+		drift = map[string]int64{}
+	}
+	r.stats.Drift = drift
+
+	return report, nil
+}
+
+func (r *Reconciler) Stats() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := r.stats
+	stats.Drift = make(map[string]int64, len(r.stats.Drift))
+	for counter, rows := range r.stats.Drift {
+		stats.Drift[counter] = rows
+	}
+
+	return stats
+}
+
+const maxLogged = 10
+
+// Run reconciles the counters every config.Interval until stop is closed.
+func (r *Reconciler) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		report, err := r.ReconcileCounters(r.config.Fix)
+		if err != nil {
+			log.Printf("counters: reconcile: %v", err)
+			continue
+		}
+
+		for i, d := range report.Drifts {
+			if i == maxLogged {
+				log.Printf("counters: and %d more", len(report.Drifts)-maxLogged)
+				break
+			}
+			log.Printf("counters: %s.%s of %s is %d, counted %d", d.Table, d.Column, d.Key, d.Stored, d.Actual)
+		}
+		if len(report.Drifts) != 0 && report.Fixed {
+			log.Printf("counters: fixed %d counters", len(report.Drifts))
+		}
+	}
+}
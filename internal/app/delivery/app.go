@@ -4,41 +4,71 @@ import (
 	"encoding/json"
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 	"tp-db-forum/internal/app"
+	"tp-db-forum/internal/app/events"
 	"tp-db-forum/internal/app/models"
 )
 
 type AppHandler struct {
 	appUseCase app.UseCase
+	broker     *events.Broker
 }
 
-func NewAppHandler(router *mux.Router, appUseCase app.UseCase) {
+func NewAppHandler(router *mux.Router, appUseCase app.UseCase, broker *events.Broker) {
 	handler := &AppHandler{
 		appUseCase: appUseCase,
+		broker:     broker,
 	}
 
-	router.HandleFunc("/api/user/{nickname}/create", handler.CreateUser).Methods(http.MethodPost)
+	router.HandleFunc("/api/user/batch", handler.UsersBatch).Methods(http.MethodPost)
+	router.HandleFunc("/api/user/leaderboard", handler.Leaderboard).Methods(http.MethodGet)
+	router.HandleFunc("/api/user/{nickname}/create", handler.CreateUser).Methods(http.MethodPost).Name("CreateUser")
 	router.HandleFunc("/api/user/{nickname}/profile", handler.UserProfile).Methods(http.MethodGet, http.MethodPost)
 
-	router.HandleFunc("/api/forum/create", handler.CreateForum).Methods(http.MethodPost)
+	router.HandleFunc("/api/forum/create", handler.CreateForum).Methods(http.MethodPost).Name("CreateForum")
+	router.HandleFunc("/api/forum/import", handler.ForumImport).Methods(http.MethodPost)
 	router.HandleFunc("/api/forum/{slug}/details", handler.ForumDetails).Methods(http.MethodGet)
-	router.HandleFunc("/api/forum/{slug}/create", handler.CreateThread).Methods(http.MethodPost)
-	router.HandleFunc("/api/forum/{slug}/threads", handler.ForumThreads).Methods(http.MethodGet)
-	router.HandleFunc("/api/forum/{slug}/users", handler.ForumUsers).Methods(http.MethodGet)
-
-	router.HandleFunc("/api/thread/{slug_or_id}/create", handler.CreatePosts).Methods(http.MethodPost)
-	router.HandleFunc("/api/thread/{slug_or_id}/vote", handler.VoteThread).Methods(http.MethodPost)
+	router.HandleFunc("/api/forum/{slug}/create", handler.CreateThread).Methods(http.MethodPost).Name("CreateThread")
+	router.HandleFunc("/api/forum/{slug}/threads", handler.ForumThreads).Methods(http.MethodGet).Name("ForumThreads")
+	router.HandleFunc("/api/forum/{slug}/users", handler.ForumUsers).Methods(http.MethodGet).Name("ForumUsers")
+	router.HandleFunc("/api/forum/{slug}/events", handler.ForumEvents).Methods(http.MethodGet).Name("ForumEvents")
+	router.HandleFunc("/api/forum/{slug}/feed.atom", handler.ForumFeed).Methods(http.MethodGet)
+	router.HandleFunc("/api/forum/{slug}/feed.rss", handler.ForumFeed).Methods(http.MethodGet)
+	router.HandleFunc("/api/forum/{slug}/export", handler.ForumExport).Methods(http.MethodGet).Name("ForumExport")
+	router.HandleFunc("/api/forum/{slug}/stats", handler.ForumStats).Methods(http.MethodGet)
+	router.HandleFunc("/api/forum/{slug}/leaderboard", handler.Leaderboard).Methods(http.MethodGet)
+
+	router.HandleFunc("/api/thread/batch", handler.ThreadsBatch).Methods(http.MethodPost)
+	router.HandleFunc("/api/thread/{slug_or_id}/create", handler.CreatePosts).Methods(http.MethodPost).Name("CreatePosts")
+	router.HandleFunc("/api/thread/{slug_or_id}/vote", handler.VoteThread).Methods(http.MethodPost).Name("VoteThread")
 	router.HandleFunc("/api/thread/{slug_or_id}/details", handler.ThreadDetails).Methods(http.MethodGet, http.MethodPost)
 
-	router.HandleFunc("/api/thread/{slug_or_id}/posts", handler.ThreadPosts).Methods(http.MethodGet)
+	router.HandleFunc("/api/thread/{slug_or_id}/posts", handler.ThreadPosts).Methods(http.MethodGet).Name("ThreadPosts")
+	router.HandleFunc("/api/thread/{slug_or_id}/feed.atom", handler.ThreadFeed).Methods(http.MethodGet)
+	router.HandleFunc("/api/thread/{slug_or_id}/feed.rss", handler.ThreadFeed).Methods(http.MethodGet)
 
+	router.HandleFunc("/api/post/batch", handler.PostsBatch).Methods(http.MethodPost)
 	router.HandleFunc("/api/post/{id}/details", handler.PostDetails).Methods(http.MethodGet, http.MethodPost)
+	router.HandleFunc("/api/post/{id}/vote", handler.VotePost).Methods(http.MethodPost).Name("VotePost")
 
 	router.HandleFunc("/api/service/status", handler.StatusHandler).Methods(http.MethodGet)
+	router.HandleFunc("/api/service/status/extended", handler.ExtendedStatusHandler).Methods(http.MethodGet)
 	router.HandleFunc("/api/service/clear", handler.ClearHandler).Methods(http.MethodPost)
+	router.HandleFunc("/api/service/load", handler.BulkLoadHandler).Methods(http.MethodPost)
+
+	router.HandleFunc("/api/admin/webhooks", handler.CreateWebhook).Methods(http.MethodPost)
+	router.HandleFunc("/api/admin/webhooks", handler.Webhooks).Methods(http.MethodGet)
+	router.HandleFunc("/api/admin/webhooks/dead", handler.DeadDeliveries).Methods(http.MethodGet)
+	router.HandleFunc("/api/admin/webhooks/deliveries/{id}/replay", handler.ReplayDelivery).Methods(http.MethodPost)
+	router.HandleFunc("/api/admin/webhooks/{id}", handler.DeleteWebhook).Methods(http.MethodDelete)
+	router.HandleFunc("/api/admin/outbox/{id}/replay", handler.ReplayEvent).Methods(http.MethodPost)
+	router.HandleFunc("/api/admin/counters/reconcile", handler.ReconcileCounters).Methods(http.MethodPost)
+	router.HandleFunc("/api/admin/forum-users/reconcile", handler.ReconcileForumUsers).Methods(http.MethodPost)
 }
 
 func errorMarshal(message string) ([]byte, error) {
@@ -91,7 +121,7 @@ func (h AppHandler) UserProfile(writer http.ResponseWriter, request *http.Reques
 	nickname := strings.TrimSuffix(strings.TrimPrefix(request.URL.Path, "/api/user/"), "/profile")
 
 	if request.Method == "GET" {
-		user, err := h.appUseCase.CheckUserByNickname(nickname)
+		profile, err := h.appUseCase.CheckUserProfile(nickname)
 		if err != nil {
 			body, err := errorMarshal("Can't find user\n")
 			if err != nil {
@@ -103,13 +133,23 @@ func (h AppHandler) UserProfile(writer http.ResponseWriter, request *http.Reques
 
 			return
 		}
-		body, err := json.Marshal(user)
+		body, err := json.Marshal(profile)
 		if err != nil {
 			return
 		}
 
-		writer.WriteHeader(http.StatusOK)
-		writer.Write(body)
+		writeWithETag(writer, request, body, versionETag(profile.Version, profile.Reputation), time.Time{})
+
+		return
+	}
+
+	version, ok := ifMatchVersion(request, func() (int64, error) {
+		user, err := h.appUseCase.CheckUserByNickname(nickname)
+
+		return user.Version, err
+	})
+	if !ok {
+		writePreconditionFailed(writer, "user was modified")
 
 		return
 	}
@@ -120,9 +160,16 @@ func (h AppHandler) UserProfile(writer http.ResponseWriter, request *http.Reques
 		return
 	}
 	user.Nickname = nickname
+	user.Version = version
 
 	result, err := h.appUseCase.EditUser(user)
 	if err != nil {
+		if err == models.ErrVersionMismatch {
+			writePreconditionFailed(writer, "user was modified")
+
+			return
+		}
+
 		if pgErr, ok := err.(pgx.PgError); ok && pgErr.Code == "23505" {
 			body, err := errorMarshal("Conflict email\n")
 			if err != nil {
@@ -152,6 +199,7 @@ func (h AppHandler) UserProfile(writer http.ResponseWriter, request *http.Reques
 		return
 	}
 
+	writer.Header().Set("ETag", versionETag(result.Version))
 	writer.WriteHeader(http.StatusOK)
 	writer.Write(body)
 }
@@ -237,8 +285,7 @@ func (h AppHandler) ForumDetails(writer http.ResponseWriter, request *http.Reque
 		return
 	}
 
-	writer.WriteHeader(http.StatusOK)
-	writer.Write(body)
+	writeWithETag(writer, request, body, versionETag(forum.Version), time.Time{})
 }
 
 func (h AppHandler) CreateThread(writer http.ResponseWriter, request *http.Request) {
@@ -306,6 +353,8 @@ func (h AppHandler) CreateThread(writer http.ResponseWriter, request *http.Reque
 			Votes:   newThread.Votes,
 		}
 
+		h.broker.Publish(forum.Slug, "thread", threadWithoutSlug)
+
 		body, err := json.Marshal(threadWithoutSlug)
 		if err != nil {
 			return
@@ -319,6 +368,8 @@ func (h AppHandler) CreateThread(writer http.ResponseWriter, request *http.Reque
 
 	newThread.Forum = forum.Slug
 
+	h.broker.Publish(forum.Slug, "thread", newThread)
+
 	body, err := json.Marshal(newThread)
 	if err != nil {
 		return
@@ -383,6 +434,17 @@ func (h AppHandler) CreatePosts(writer http.ResponseWriter, request *http.Reques
 	author := posts[0].Author
 
 	resultPosts, err := h.appUseCase.CreatePosts(posts, id)
+	if err == models.ErrThreadLocked {
+		body, err := errorMarshal("Thread is locked")
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(http.StatusForbidden)
+		writer.Write(body)
+
+		return
+	}
 	if len(resultPosts) == 0 {
 		err = pgx.ErrNoRows
 	}
@@ -439,6 +501,8 @@ func (h AppHandler) CreatePosts(writer http.ResponseWriter, request *http.Reques
 		}
 	}
 
+	h.broker.Publish(resultPosts[0].Forum, "posts", resultPosts)
+
 	body, err := json.Marshal(resultPosts)
 	if err != nil {
 		return
@@ -480,8 +544,7 @@ func (h AppHandler) ThreadDetails(writer http.ResponseWriter, request *http.Requ
 				return
 			}
 
-			writer.WriteHeader(http.StatusOK)
-			writer.Write(body)
+			writeWithETag(writer, request, body, versionETag(thread.Version), time.Time{})
 
 			return
 		}
@@ -491,8 +554,25 @@ func (h AppHandler) ThreadDetails(writer http.ResponseWriter, request *http.Requ
 			return
 		}
 
-		writer.WriteHeader(http.StatusOK)
-		writer.Write(body)
+		writeWithETag(writer, request, body, versionETag(thread.Version), time.Time{})
+
+		return
+	}
+
+	version, ok := ifMatchVersion(request, func() (int64, error) {
+		id, err := strconv.Atoi(slugOrId)
+		if err != nil {
+			thread, err := h.appUseCase.CheckThreadBySlug(slugOrId)
+
+			return thread.Version, err
+		}
+
+		thread, err := h.appUseCase.CheckThreadById(id)
+
+		return thread.Version, err
+	})
+	if !ok {
+		writePreconditionFailed(writer, "thread was modified")
 
 		return
 	}
@@ -509,7 +589,14 @@ func (h AppHandler) ThreadDetails(writer http.ResponseWriter, request *http.Requ
 		thread.Id = id
 	}
 
+	thread.Version = version
+
 	newThread, err := h.appUseCase.EditThread(thread)
+	if err == models.ErrVersionMismatch {
+		writePreconditionFailed(writer, "thread was modified")
+
+		return
+	}
 	if err != nil {
 		body, err := errorMarshal("can't find thread")
 		if err != nil {
@@ -522,6 +609,8 @@ func (h AppHandler) ThreadDetails(writer http.ResponseWriter, request *http.Requ
 		return
 	}
 
+	writer.Header().Set("ETag", versionETag(newThread.Version))
+
 	if models.IsUUID(newThread.Slug) {
 		result := models.ThreadToWithout(newThread)
 
@@ -607,6 +696,24 @@ func (h AppHandler) VoteThread(writer http.ResponseWriter, request *http.Request
 	}
 
 	thread, err = h.appUseCase.CheckThreadById(id)
+	if err != nil {
+		body, err := errorMarshal("can't find thread")
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(http.StatusNotFound)
+		writer.Write(body)
+
+		return
+	}
+
+	h.broker.Publish(thread.Forum, "vote", map[string]interface{}{
+		"thread":   thread.Id,
+		"nickname": vote.Nickname,
+		"voice":    vote.Voice,
+		"votes":    thread.Votes,
+	})
 
 	if models.IsUUID(thread.Slug) {
 		result := models.ThreadToWithout(thread)
@@ -631,8 +738,72 @@ func (h AppHandler) VoteThread(writer http.ResponseWriter, request *http.Request
 	writer.Write(body)
 }
 
+func (h AppHandler) VotePost(writer http.ResponseWriter, request *http.Request) {
+	id, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(request.URL.Path, "/api/post/"), "/vote"))
+	if err != nil {
+		return
+	}
+
+	var vote models.Vote
+	err = json.NewDecoder(request.Body).Decode(&vote)
+	if err != nil {
+		return
+	}
+
+	vote.IdPost = id
+
+	_, err = h.appUseCase.AddPostVote(vote)
+	if err != nil {
+		if pgErr, ok := err.(pgx.PgError); ok && pgErr.Code == "23505" {
+			_, err = h.appUseCase.UpdatePostVote(vote)
+		}
+	}
+	if err != nil {
+		body, err := errorMarshal("can't find post or user")
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(http.StatusNotFound)
+		writer.Write(body)
+
+		return
+	}
+
+	data, err := h.appUseCase.CheckPostById(id, nil)
+	if err != nil {
+		body, err := errorMarshal("can't find post")
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(http.StatusNotFound)
+		writer.Write(body)
+
+		return
+	}
+	post := data["post"].(models.Post)
+
+	h.broker.Publish(post.Forum, "post_vote", map[string]interface{}{
+		"post":     post.Id,
+		"thread":   post.Thread,
+		"nickname": vote.Nickname,
+		"voice":    vote.Voice,
+		"votes":    post.Votes,
+	})
+
+	body, err := json.Marshal(post)
+	if err != nil {
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+	writer.Write(body)
+}
+
 func (h AppHandler) StatusHandler(writer http.ResponseWriter, request *http.Request) {
-	info, err := h.appUseCase.GetServiceStatus()
+	estimate, _ := strconv.ParseBool(request.URL.Query().Get("estimate"))
+	info, err := h.appUseCase.GetServiceStatus(estimate)
 	if err != nil {
 		body, err := errorMarshal("vse ploho")
 		if err != nil {
@@ -641,6 +812,32 @@ func (h AppHandler) StatusHandler(writer http.ResponseWriter, request *http.Requ
 
 		writer.WriteHeader(http.StatusNotFound)
 		writer.Write(body)
+
+		return
+	}
+
+	body, err := json.Marshal(info)
+	if err != nil {
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+	writer.Write(body)
+}
+
+func (h AppHandler) ExtendedStatusHandler(writer http.ResponseWriter, request *http.Request) {
+	estimate, _ := strconv.ParseBool(request.URL.Query().Get("estimate"))
+	info, err := h.appUseCase.CheckServiceInfo(estimate)
+	if err != nil {
+		body, err := errorMarshal("can't load service status")
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(http.StatusInternalServerError)
+		writer.Write(body)
+
+		return
 	}
 
 	body, err := json.Marshal(info)
@@ -684,9 +881,38 @@ func (h AppHandler) ForumUsers(writer http.ResponseWriter, request *http.Request
 	}
 	parameters.Desc = desc
 
+	parameters.Cursor, err = parseCursor(request)
+	if err != nil {
+		writeInvalidCursor(writer)
+
+		return
+	}
+
+	format := requestedFormat(request)
+	if !checkCursorFormat(writer, format, parameters.Cursor) {
+		return
+	}
+
+	requested := parameters.Limit
+	parameters.Limit = listLimit(writer, parameters.Limit)
+
 	slug := strings.TrimSuffix(strings.TrimPrefix(request.URL.Path, "/api/forum/"), "/users")
 
-	users, err := h.appUseCase.CheckUsersByForum(slug, parameters)
+	if shouldStream(request, format, requested, parameters.Cursor) {
+		stream, ok := startListStream(writer, request, format, models.User{})
+		if ok {
+			h.streamForumUsers(writer, request, stream, slug, parameters)
+		}
+
+		return
+	}
+
+	users, page, err := h.appUseCase.CheckUsersPageByForum(slug, parameters)
+	if err == models.ErrInvalidCursor {
+		writeInvalidCursor(writer)
+
+		return
+	}
 	if err != nil {
 		body, err := errorMarshal("can't find something")
 		if err != nil {
@@ -712,24 +938,44 @@ func (h AppHandler) ForumUsers(writer http.ResponseWriter, request *http.Request
 
 			return
 		}
-		body, err := json.Marshal([]int{})
-		if err != nil {
-			return
-		}
+		writeList(writer, request, []int{}, models.Page{}, func(estimate bool) (int, error) {
+			return h.appUseCase.CountUsersByForum(slug, estimate)
+		})
 
-		writer.WriteHeader(http.StatusOK)
-		writer.Write(body)
+		return
+	}
+
+	writeList(writer, request, users, page, func(estimate bool) (int, error) {
+		return h.appUseCase.CountUsersByForum(slug, estimate)
+	})
+}
+
+func (h AppHandler) streamForumUsers(writer http.ResponseWriter, request *http.Request, stream *listStream, slug string, parameters models.QueryParameters) {
+	err := h.appUseCase.StreamUsersByForum(slug, parameters, func(user models.User) error {
+		return stream.Write(user)
+	})
+	if err != nil && stream.count != 0 {
+		log.Printf("forum users: %s: %v", request.URL.RequestURI(), err)
 
 		return
 	}
 
-	body, err := json.Marshal(users)
+	if err == nil && stream.count == 0 {
+		_, err = h.appUseCase.CheckForumBySlug(slug)
+	}
 	if err != nil {
+		body, err := errorMarshal("can't find something")
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(http.StatusNotFound)
+		writer.Write(body)
+
 		return
 	}
 
-	writer.WriteHeader(http.StatusOK)
-	writer.Write(body)
+	stream.Close()
 }
 
 func (h AppHandler) ForumThreads(writer http.ResponseWriter, request *http.Request) {
@@ -749,19 +995,44 @@ func (h AppHandler) ForumThreads(writer http.ResponseWriter, request *http.Reque
 	}
 	parameters.Desc = desc
 
+	parameters.Cursor, err = parseCursor(request)
+	if err != nil {
+		writeInvalidCursor(writer)
+
+		return
+	}
+
+	format := requestedFormat(request)
+	if !checkCursorFormat(writer, format, parameters.Cursor) {
+		return
+	}
+
+	requested := parameters.Limit
+	parameters.Limit = listLimit(writer, parameters.Limit)
+
 	slug := strings.TrimSuffix(strings.TrimPrefix(request.URL.Path, "/api/forum/"), "/threads")
 
-	threads, err := h.appUseCase.CheckThreadsByForum(slug, parameters)
+	if shouldStream(request, format, requested, parameters.Cursor) {
+		stream, ok := startListStream(writer, request, format, models.Thread{})
+		if ok {
+			h.streamForumThreads(writer, request, stream, slug, parameters)
+		}
+
+		return
+	}
+
+	threads, page, err := h.appUseCase.CheckThreadsPageByForum(slug, parameters)
+	if err == models.ErrInvalidCursor {
+		writeInvalidCursor(writer)
+
+		return
+	}
 	if err == pgx.ErrNoRows || len(threads) == 0 {
 		_, err := h.appUseCase.CheckThreadByForum(slug)
 		if err == nil {
-			body, err := json.Marshal([]int{})
-			if err != nil {
-				return
-			}
-
-			writer.WriteHeader(http.StatusOK)
-			writer.Write(body)
+			writeList(writer, request, []int{}, models.Page{}, func(estimate bool) (int, error) {
+				return h.appUseCase.CountThreadsByForum(slug, estimate)
+			})
 
 			return
 		}
@@ -788,13 +1059,40 @@ func (h AppHandler) ForumThreads(writer http.ResponseWriter, request *http.Reque
 		}
 	}
 
-	body, err := json.Marshal(result)
-	if err != nil {
+	writeList(writer, request, result, page, func(estimate bool) (int, error) {
+		return h.appUseCase.CountThreadsByForum(slug, estimate)
+	})
+}
+
+func (h AppHandler) streamForumThreads(writer http.ResponseWriter, request *http.Request, stream *listStream, slug string, parameters models.QueryParameters) {
+	err := h.appUseCase.StreamThreadsByForum(slug, parameters, func(thread models.Thread) error {
+		if models.IsUUID(thread.Slug) {
+			return stream.Write(models.ThreadToWithout(thread))
+		}
+
+		return stream.Write(thread)
+	})
+	if err != nil && stream.count != 0 {
+		log.Printf("forum threads: %s: %v", request.URL.RequestURI(), err)
+
 		return
 	}
 
-	writer.WriteHeader(http.StatusOK)
-	writer.Write(body)
+	if stream.count == 0 {
+		if _, err := h.appUseCase.CheckThreadByForum(slug); err != nil {
+			body, err := errorMarshal("can't find something bad")
+			if err != nil {
+				return
+			}
+
+			writer.WriteHeader(http.StatusNotFound)
+			writer.Write(body)
+
+			return
+		}
+	}
+
+	stream.Close()
 }
 
 func (h AppHandler) PostDetails(writer http.ResponseWriter, request *http.Request) {
@@ -824,8 +1122,22 @@ func (h AppHandler) PostDetails(writer http.ResponseWriter, request *http.Reques
 			return
 		}
 
-		writer.WriteHeader(http.StatusOK)
-		writer.Write(body)
+		etag, modified := postDetailsValidators(data)
+		writeWithETag(writer, request, body, etag, modified)
+
+		return
+	}
+
+	version, ok := ifMatchVersion(request, func() (int64, error) {
+		data, err := h.appUseCase.CheckPostById(id, nil)
+		if err != nil {
+			return 0, err
+		}
+
+		return data["post"].(models.Post).Version, nil
+	})
+	if !ok {
+		writePreconditionFailed(writer, "post was modified")
 
 		return
 	}
@@ -836,7 +1148,12 @@ func (h AppHandler) PostDetails(writer http.ResponseWriter, request *http.Reques
 		return
 	}
 
-	post, err = h.appUseCase.EditPost(id, post.Message)
+	post, err = h.appUseCase.EditPost(id, post.Message, version)
+	if err == models.ErrVersionMismatch {
+		writePreconditionFailed(writer, "post was modified")
+
+		return
+	}
 	if err != nil {
 		body, err := errorMarshal("can't find something")
 		if err != nil {
@@ -854,6 +1171,7 @@ func (h AppHandler) PostDetails(writer http.ResponseWriter, request *http.Reques
 		return
 	}
 
+	writer.Header().Set("ETag", versionETag(post.Version))
 	writer.WriteHeader(http.StatusOK)
 	writer.Write(body)
 }
@@ -876,6 +1194,21 @@ func (h AppHandler) ThreadPosts(writer http.ResponseWriter, request *http.Reques
 		sort = "flat"
 	}
 
+	cursor, err := parseCursor(request)
+	if err != nil {
+		writeInvalidCursor(writer)
+
+		return
+	}
+
+	format := requestedFormat(request)
+	if !checkCursorFormat(writer, format, cursor) {
+		return
+	}
+
+	requested := limit
+	limit = listLimit(writer, limit)
+
 	slugOrId := strings.TrimSuffix(strings.TrimPrefix(request.URL.Path, "/api/thread/"), "/posts")
 	var thread models.Thread
 	id, err := strconv.Atoi(slugOrId)
@@ -885,7 +1218,21 @@ func (h AppHandler) ThreadPosts(writer http.ResponseWriter, request *http.Reques
 		thread.Id = id
 	}
 
-	posts, err := h.appUseCase.CheckPostsByThread(thread, limit, since, sort, desc)
+	if shouldStream(request, format, requested, cursor) {
+		stream, ok := startListStream(writer, request, format, models.Post{})
+		if ok {
+			h.streamThreadPosts(writer, request, stream, thread, limit, since, sort, desc)
+		}
+
+		return
+	}
+
+	posts, page, err := h.appUseCase.CheckPostsPageByThread(thread, limit, since, sort, desc, cursor)
+	if err == models.ErrInvalidCursor {
+		writeInvalidCursor(writer)
+
+		return
+	}
 	if err != nil {
 		body, err := errorMarshal("can't find something this")
 		if err != nil {
@@ -899,50 +1246,65 @@ func (h AppHandler) ThreadPosts(writer http.ResponseWriter, request *http.Reques
 	}
 
 	if posts == nil {
-		if thread.Id == 0 {
-			_, err := h.appUseCase.CheckThreadBySlug(thread.Slug)
-			if err == pgx.ErrNoRows {
-				body, err := errorMarshal("can't find something")
-				if err != nil {
-					return
-				}
-
-				writer.WriteHeader(http.StatusNotFound)
-				writer.Write(body)
-
+		if h.threadMissing(thread) {
+			body, err := errorMarshal("can't find something")
+			if err != nil {
 				return
 			}
-		} else {
-			_, err := h.appUseCase.CheckThreadById(thread.Id)
-			if err == pgx.ErrNoRows {
-				body, err := errorMarshal("can't find something")
-				if err != nil {
-					return
-				}
 
-				writer.WriteHeader(http.StatusNotFound)
-				writer.Write(body)
+			writer.WriteHeader(http.StatusNotFound)
+			writer.Write(body)
 
-				return
-			}
+			return
 		}
 
-		body, err := json.Marshal([]int{})
+		writeList(writer, request, []int{}, models.Page{}, func(estimate bool) (int, error) {
+			return h.appUseCase.CountPostsByThread(thread, estimate)
+		})
+
+		return
+	}
+
+	writeList(writer, request, posts, page, func(estimate bool) (int, error) {
+		return h.appUseCase.CountPostsByThread(thread, estimate)
+	})
+}
+
+// streamThreadPosts writes the posts as they are read from the database.
+// Once the first one is sent the status can't change anymore, so an error
+// after that only cuts the listing short.
+func (h AppHandler) streamThreadPosts(writer http.ResponseWriter, request *http.Request, stream *listStream, thread models.Thread, limit, since int, sort string, desc bool) {
+	err := h.appUseCase.StreamPostsByThread(thread, limit, since, sort, desc, func(post models.Post) error {
+		return stream.Write(post)
+	})
+	if err != nil && stream.count != 0 {
+		log.Printf("thread posts: %s: %v", request.URL.RequestURI(), err)
+
+		return
+	}
+
+	if err != nil || (stream.count == 0 && h.threadMissing(thread)) {
+		body, err := errorMarshal("can't find something")
 		if err != nil {
 			return
 		}
 
-		writer.WriteHeader(http.StatusOK)
+		writer.WriteHeader(http.StatusNotFound)
 		writer.Write(body)
 
 		return
 	}
 
-	body, err := json.Marshal(posts)
-	if err != nil {
-		return
+	stream.Close()
+}
+
+func (h AppHandler) threadMissing(thread models.Thread) bool {
+	var err error
+	if thread.Id == 0 {
+		_, err = h.appUseCase.CheckThreadBySlug(thread.Slug)
+	} else {
+		_, err = h.appUseCase.CheckThreadById(thread.Id)
 	}
 
-	writer.WriteHeader(http.StatusOK)
-	writer.Write(body)
+	return err == pgx.ErrNoRows
 }
@@ -0,0 +1,117 @@
+package delivery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"tp-db-forum/internal/app/archive"
+	"tp-db-forum/internal/app/models"
+)
+
+// flushWriter passes every write on to the client right away, so a long
+// export isn't held in memory.
+type flushWriter struct {
+	writer  http.ResponseWriter
+	written bool
+}
+
+func (w *flushWriter) Write(p []byte) (int, error) {
+	w.written = true
+
+	n, err := w.writer.Write(p)
+	if flusher, ok := w.writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	return n, err
+}
+
+func (h AppHandler) ForumExport(writer http.ResponseWriter, request *http.Request) {
+	slug := strings.TrimSuffix(strings.TrimPrefix(request.URL.Path, "/api/forum/"), "/export")
+
+	writer.Header().Set("Content-Type", archive.MediaType)
+	writer.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.forum.ndjson"`, slug))
+
+	out := &flushWriter{writer: writer}
+	err := h.appUseCase.ExportForum(slug, out)
+	if err == nil {
+		return
+	}
+
+	// Once part of the archive is sent the status can't change, but the
+	// archive then ends without its trailer and is refused on import.
+	if out.written {
+		log.Printf("forum export: %s: %v", slug, err)
+
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Del("Content-Disposition")
+
+	status, message := http.StatusInternalServerError, "can't export forum"
+	if err == pgx.ErrNoRows {
+		status, message = http.StatusNotFound, "Can't find forum"
+	}
+
+	body, err := errorMarshal(message)
+	if err != nil {
+		return
+	}
+
+	writer.WriteHeader(status)
+	writer.Write(body)
+}
+
+func (h AppHandler) ForumImport(writer http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	dryRun, _ := strconv.ParseBool(query.Get("dry_run"))
+	options := models.ImportOptions{
+		Slug:   query.Get("slug"),
+		Users:  query.Get("users"),
+		Slugs:  query.Get("slugs"),
+		DryRun: dryRun,
+	}
+
+	report, err := h.appUseCase.ImportForum(request.Body, options)
+	if err != nil {
+		status, message := http.StatusInternalServerError, "can't import forum"
+		switch pgErr, ok := err.(pgx.PgError); {
+		case errors.Is(err, models.ErrInvalidArchive), errors.Is(err, models.ErrInvalidImportOptions):
+			status, message = http.StatusBadRequest, err.Error()
+		case errors.Is(err, models.ErrArchiveConflict):
+			status, message = http.StatusConflict, err.Error()
+		case ok && (pgErr.Code == "23503" || pgErr.Code == "00409"):
+			status, message = http.StatusBadRequest, "invalid archive: "+pgErr.Message
+		case ok && pgErr.Code == "23505":
+			status, message = http.StatusConflict, "archive conflicts with existing data: "+pgErr.Message
+		}
+
+		body, err := errorMarshal(message)
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(status)
+		writer.Write(body)
+
+		return
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		return
+	}
+
+	if report.DryRun {
+		writer.WriteHeader(http.StatusOK)
+	} else {
+		writer.WriteHeader(http.StatusCreated)
+	}
+	writer.Write(body)
+}
@@ -0,0 +1,197 @@
+package delivery
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"tp-db-forum/internal/app/models"
+)
+
+const maxBatchKeys = 100
+
+func writeBatchError(writer http.ResponseWriter, message string) {
+	body, err := errorMarshal(message)
+	if err != nil {
+		return
+	}
+
+	writer.WriteHeader(http.StatusBadRequest)
+	writer.Write(body)
+}
+
+func writeBatch(writer http.ResponseWriter, result models.BatchResult) {
+	body, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+	writer.Write(body)
+}
+
+// decodeBatchKeys reads the JSON array of keys of a batch request. When ok
+// is false the error response has already been written.
+func decodeBatchKeys(writer http.ResponseWriter, request *http.Request) (keys []string, ok bool) {
+	err := json.NewDecoder(request.Body).Decode(&keys)
+	if err != nil {
+		writeBatchError(writer, "body must be a JSON array of keys")
+
+		return nil, false
+	}
+
+	if len(keys) > maxBatchKeys {
+		writeBatchError(writer, "too many keys, at most "+strconv.Itoa(maxBatchKeys)+" are allowed")
+
+		return nil, false
+	}
+
+	return keys, true
+}
+
+func (h AppHandler) UsersBatch(writer http.ResponseWriter, request *http.Request) {
+	nicknames, ok := decodeBatchKeys(writer, request)
+	if !ok {
+		return
+	}
+
+	users, err := h.appUseCase.CheckUsersByNicknames(nicknames)
+	if err != nil {
+		body, err := errorMarshal("can't load users")
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(http.StatusInternalServerError)
+		writer.Write(body)
+
+		return
+	}
+
+	found := make(map[string]models.User, len(users))
+	for _, user := range users {
+		found[strings.ToLower(user.Nickname)] = user
+	}
+
+	result := models.BatchResult{Items: []interface{}{}, Missing: []string{}}
+	seen := make(map[string]bool, len(nicknames))
+	for _, nickname := range nicknames {
+		key := strings.ToLower(nickname)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if user, ok := found[key]; ok {
+			result.Items = append(result.Items, user)
+		} else {
+			result.Missing = append(result.Missing.([]string), nickname)
+		}
+	}
+
+	writeBatch(writer, result)
+}
+
+func (h AppHandler) ThreadsBatch(writer http.ResponseWriter, request *http.Request) {
+	keys, ok := decodeBatchKeys(writer, request)
+	if !ok {
+		return
+	}
+
+	threads, err := h.appUseCase.CheckThreadsByKeys(keys)
+	if err != nil {
+		body, err := errorMarshal("can't load threads")
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(http.StatusInternalServerError)
+		writer.Write(body)
+
+		return
+	}
+
+	found := make(map[string]models.Thread, 2*len(threads))
+	for _, thread := range threads {
+		found[strconv.Itoa(thread.Id)] = thread
+		if !models.IsUUID(thread.Slug) {
+			found[strings.ToLower(thread.Slug)] = thread
+		}
+	}
+
+	result := models.BatchResult{Items: []interface{}{}, Missing: []string{}}
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		lower := strings.ToLower(key)
+		if seen[lower] {
+			continue
+		}
+		seen[lower] = true
+
+		thread, ok := found[lower]
+		if !ok {
+			result.Missing = append(result.Missing.([]string), key)
+
+			continue
+		}
+
+		if models.IsUUID(thread.Slug) {
+			result.Items = append(result.Items, models.ThreadToWithout(thread))
+		} else {
+			result.Items = append(result.Items, thread)
+		}
+	}
+
+	writeBatch(writer, result)
+}
+
+func (h AppHandler) PostsBatch(writer http.ResponseWriter, request *http.Request) {
+	var ids []int
+	err := json.NewDecoder(request.Body).Decode(&ids)
+	if err != nil {
+		writeBatchError(writer, "body must be a JSON array of post ids")
+
+		return
+	}
+
+	if len(ids) > maxBatchKeys {
+		writeBatchError(writer, "too many keys, at most "+strconv.Itoa(maxBatchKeys)+" are allowed")
+
+		return
+	}
+
+	posts, err := h.appUseCase.CheckPostsByIds(ids)
+	if err != nil {
+		body, err := errorMarshal("can't load posts")
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(http.StatusInternalServerError)
+		writer.Write(body)
+
+		return
+	}
+
+	found := make(map[int]models.Post, len(posts))
+	for _, post := range posts {
+		found[post.Id] = post
+	}
+
+	result := models.BatchResult{Items: []interface{}{}, Missing: []int{}}
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if post, ok := found[id]; ok {
+			result.Items = append(result.Items, post)
+		} else {
+			result.Missing = append(result.Missing.([]int), id)
+		}
+	}
+
+	writeBatch(writer, result)
+}
@@ -0,0 +1,81 @@
+package delivery
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/jackc/pgx"
+	"io"
+	"net/http"
+	"strings"
+	"tp-db-forum/internal/app/models"
+)
+
+// maxBulkMemory is how much of an upload is kept in memory; the rest of the
+// files goes to temporary ones.
+const maxBulkMemory = 32 << 20
+
+// BulkLoadHandler loads the CSV files of a multipart form: users, forums,
+// threads, posts, votes and post_votes, as described at models.BulkSources.
+func (h AppHandler) BulkLoadHandler(writer http.ResponseWriter, request *http.Request) {
+	writeError := func(status int, message string) {
+		body, err := errorMarshal(message)
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(status)
+		writer.Write(body)
+	}
+
+	if err := request.ParseMultipartForm(maxBulkMemory); err != nil {
+		writeError(http.StatusBadRequest, "expected a multipart form with the files to load")
+		return
+	}
+	defer request.MultipartForm.RemoveAll()
+
+	var sources models.BulkSources
+	for name, source := range map[string]*io.Reader{
+		"users":      &sources.Users,
+		"forums":     &sources.Forums,
+		"threads":    &sources.Threads,
+		"posts":      &sources.Posts,
+		"votes":      &sources.Votes,
+		"post_votes": &sources.PostVotes,
+	} {
+		file, _, err := request.FormFile(name)
+		if err == http.ErrMissingFile {
+			continue
+		}
+		if err != nil {
+			writeError(http.StatusBadRequest, "can't read "+name)
+			return
+		}
+		defer file.Close()
+
+		*source = file
+	}
+
+	report, err := h.appUseCase.BulkLoad(sources)
+	if err != nil {
+		status, message := http.StatusInternalServerError, "can't load"
+		switch pgErr, ok := err.(pgx.PgError); {
+		case errors.Is(err, models.ErrBulkIntegrity):
+			status, message = http.StatusBadRequest, err.Error()
+		case ok && (strings.HasPrefix(pgErr.Code, "22") || pgErr.Code == "23502"):
+			status, message = http.StatusBadRequest, "invalid data: "+pgErr.Message
+		case ok && pgErr.Code == "23505":
+			status, message = http.StatusConflict, "conflicts with existing data: "+pgErr.Message
+		}
+
+		writeError(status, message)
+		return
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		return
+	}
+
+	writer.WriteHeader(http.StatusCreated)
+	writer.Write(body)
+}
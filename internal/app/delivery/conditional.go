@@ -0,0 +1,174 @@
+package delivery
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"tp-db-forum/internal/app/models"
+)
+
+func bodyETag(body []byte) string {
+	sum := sha1.Sum(body)
+
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// versionETag builds a strong ETag from the row versions of the entities a
+// representation is made of.
+func versionETag(versions ...int64) string {
+	parts := make([]string, 0, len(versions))
+	for _, version := range versions {
+		parts = append(parts, strconv.FormatInt(version, 10))
+	}
+
+	return `"` + strings.Join(parts, ".") + `"`
+}
+
+// postDetailsValidators derives the validators of a post details response.
+// The ETag covers the post and every related entity asked for, with 0 in
+// place of the ones that weren't. A post that was never edited hasn't
+// changed since it was created, so alone it also gets a Last-Modified.
+func postDetailsValidators(data map[string]interface{}) (string, time.Time) {
+	post := data["post"].(models.Post)
+	if len(data) == 1 {
+		var modified time.Time
+		if !post.IsEdited {
+			modified = parseCreated(post.Created)
+		}
+
+		return versionETag(post.Version), modified
+	}
+
+	versions := []int64{post.Version}
+	for _, key := range []string{"author", "forum", "thread"} {
+		var version int64
+		switch related := data[key].(type) {
+		case models.User:
+			version = related.Version
+		case models.Forum:
+			version = related.Version
+		case models.Thread:
+			version = related.Version
+		case models.ThreadWithoutSlug:
+			version = related.Version
+		}
+
+		versions = append(versions, version)
+	}
+
+	return versionETag(versions...), time.Time{}
+}
+
+// ifMatchVersion reads the version an update is conditional on. It is 0 when
+// If-Match is absent or "*", so the update applies to any version; ok is
+// false when the header names no version of the entity, which can never
+// match. ETags of several versions name the entity by their first one.
+// When the header lists several versions, current looks up the entity's
+// version so that the update is conditional on the listed one that matches;
+// if none does, the first is returned and the update fails on it.
+func ifMatchVersion(request *http.Request, current func() (int64, error)) (version int64, ok bool) {
+	header := request.Header.Get("If-Match")
+	if header == "" {
+		return 0, true
+	}
+
+	var versions []int64
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return 0, true
+		}
+
+		if len(candidate) < 2 || candidate[0] != '"' || candidate[len(candidate)-1] != '"' {
+			continue
+		}
+
+		version, err := strconv.ParseInt(strings.Split(candidate[1:len(candidate)-1], ".")[0], 10, 64)
+		if err == nil && version > 0 {
+			versions = append(versions, version)
+		}
+	}
+
+	switch len(versions) {
+	case 0:
+		return 0, false
+	case 1:
+		return versions[0], true
+	}
+
+	if version, err := current(); err == nil {
+		for _, candidate := range versions {
+			if candidate == version {
+				return candidate, true
+			}
+		}
+	}
+
+	return versions[0], true
+}
+
+func writePreconditionFailed(writer http.ResponseWriter, message string) {
+	body, err := errorMarshal(message)
+	if err != nil {
+		return
+	}
+
+	writer.WriteHeader(http.StatusPreconditionFailed)
+	writer.Write(body)
+}
+
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// notModified reports whether the request's validators match the current
+// representation. If-None-Match takes precedence over If-Modified-Since.
+func notModified(request *http.Request, etag string, modified time.Time) bool {
+	if header := request.Header.Get("If-None-Match"); header != "" {
+		return etagMatches(header, etag)
+	}
+
+	if header := request.Header.Get("If-Modified-Since"); header != "" && !modified.IsZero() {
+		since, err := http.ParseTime(header)
+		if err != nil {
+			return false
+		}
+
+		return !modified.Truncate(time.Second).After(since)
+	}
+
+	return false
+}
+
+// writeConditional writes body with ETag and, if modified is set,
+// Last-Modified headers, answering 304 Not Modified to a matching
+// conditional GET.
+func writeConditional(writer http.ResponseWriter, request *http.Request, body []byte, modified time.Time) {
+	writeWithETag(writer, request, body, bodyETag(body), modified)
+}
+
+func writeWithETag(writer http.ResponseWriter, request *http.Request, body []byte, etag string, modified time.Time) {
+	writer.Header().Set("ETag", etag)
+	if !modified.IsZero() {
+		writer.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(request, etag, modified) {
+		writer.WriteHeader(http.StatusNotModified)
+
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+	writer.Write(body)
+}
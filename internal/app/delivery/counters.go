@@ -0,0 +1,68 @@
+package delivery
+
+import (
+	"encoding/json"
+	"github.com/jackc/pgx"
+	"net/http"
+	"strconv"
+)
+
+// ReconcileCounters recounts the counters the triggers maintain and lists
+// the ones that drifted; with fix=true it corrects them too.
+func (h AppHandler) ReconcileCounters(writer http.ResponseWriter, request *http.Request) {
+	fix, _ := strconv.ParseBool(request.URL.Query().Get("fix"))
+
+	report, err := h.appUseCase.ReconcileCounters(fix)
+	if err != nil {
+		body, err := errorMarshal("can't reconcile counters")
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(http.StatusInternalServerError)
+		writer.Write(body)
+
+		return
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+	writer.Write(body)
+}
+
+// ReconcileForumUsers checks users_forum of the forum given by the forum
+// parameter, or of all of them, and with fix=true rebuilds what's off.
+func (h AppHandler) ReconcileForumUsers(writer http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	fix, _ := strconv.ParseBool(query.Get("fix"))
+
+	report, err := h.appUseCase.ReconcileForumUsers(query.Get("forum"), fix)
+	if err != nil {
+		status, message := http.StatusInternalServerError, "can't reconcile forum users"
+		if err == pgx.ErrNoRows {
+			status, message = http.StatusNotFound, "Can't find forum"
+		}
+
+		body, err := errorMarshal(message)
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(status)
+		writer.Write(body)
+
+		return
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+	writer.Write(body)
+}
@@ -0,0 +1,116 @@
+package delivery
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"tp-db-forum/internal/app/models"
+)
+
+const pageMediaType = "application/vnd.forum.page+json"
+
+func parseCursor(request *http.Request) (*models.Cursor, error) {
+	token := request.URL.Query().Get("cursor")
+	if token == "" {
+		return nil, nil
+	}
+
+	cursor, err := models.DecodeCursor(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cursor, nil
+}
+
+func writeInvalidCursor(writer http.ResponseWriter) {
+	body, err := errorMarshal("invalid cursor")
+	if err != nil {
+		return
+	}
+
+	writer.WriteHeader(http.StatusBadRequest)
+	writer.Write(body)
+}
+
+func wantsEnvelope(request *http.Request) bool {
+	if envelope, err := strconv.ParseBool(request.URL.Query().Get("envelope")); err == nil {
+		return envelope
+	}
+
+	return strings.Contains(request.Header.Get("Accept"), pageMediaType)
+}
+
+func pageLink(request *http.Request, cursor string) string {
+	u := *request.URL
+
+	query := u.Query()
+	query.Del("since")
+	query.Set("cursor", cursor)
+	u.RawQuery = query.Encode()
+
+	return u.RequestURI()
+}
+
+func setPageHeaders(writer http.ResponseWriter, request *http.Request, page models.Page) {
+	var links []string
+	if page.Next != "" {
+		writer.Header().Set("X-Next-Cursor", page.Next)
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageLink(request, page.Next)))
+	}
+	if page.Prev != "" {
+		writer.Header().Set("X-Prev-Cursor", page.Prev)
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, pageLink(request, page.Prev)))
+	}
+
+	if len(links) != 0 {
+		writer.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
+
+// writeList writes a page of a listing either as a bare JSON array or, when
+// the client asks for it, wrapped in models.PageEnvelope. count is only
+// called when the client asks for the total with total=exact or
+// total=estimate.
+func writeList(writer http.ResponseWriter, request *http.Request, items interface{}, page models.Page, count func(estimate bool) (int, error)) {
+	setPageHeaders(writer, request, page)
+
+	if !wantsEnvelope(request) {
+		body, err := json.Marshal(items)
+		if err != nil {
+			return
+		}
+
+		writeConditional(writer, request, body, time.Time{})
+
+		return
+	}
+
+	envelope := models.PageEnvelope{
+		Items:   items,
+		HasMore: page.HasMore,
+		Next:    page.Next,
+		Prev:    page.Prev,
+	}
+
+	switch request.URL.Query().Get("total") {
+	case "exact", "estimate":
+		estimate := request.URL.Query().Get("total") == "estimate"
+
+		total, err := count(estimate)
+		if err == nil {
+			envelope.Total = &total
+			envelope.TotalEstimated = estimate
+		}
+	}
+
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return
+	}
+
+	writeConditional(writer, request, body, time.Time{})
+}
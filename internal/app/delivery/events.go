@@ -0,0 +1,96 @@
+package delivery
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"tp-db-forum/internal/app/events"
+)
+
+const eventsHeartbeat = 15 * time.Second
+
+func writeEvent(writer http.ResponseWriter, event events.Event) error {
+	_, err := fmt.Fprintf(writer, "id: %d\nevent: %s\ndata: %s\n\n", event.Id, event.Type, event.Data)
+
+	return err
+}
+
+func (h AppHandler) ForumEvents(writer http.ResponseWriter, request *http.Request) {
+	slug := strings.TrimSuffix(strings.TrimPrefix(request.URL.Path, "/api/forum/"), "/events")
+
+	forum, err := h.appUseCase.CheckForumBySlug(slug)
+	if err != nil {
+		body, err := errorMarshal("Can't find forum")
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(http.StatusNotFound)
+		writer.Write(body)
+
+		return
+	}
+
+	flusher, ok := writer.(http.Flusher)
+	if !ok {
+		body, err := errorMarshal("streaming is not supported")
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(http.StatusInternalServerError)
+		writer.Write(body)
+
+		return
+	}
+
+	lastEventId := request.Header.Get("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = request.URL.Query().Get("last_event_id")
+	}
+	lastId, err := strconv.ParseUint(lastEventId, 10, 64)
+	if err != nil {
+		lastId = 0
+	}
+
+	backlog, stream, unsubscribe := h.broker.Subscribe(forum.Slug, lastId)
+	defer unsubscribe()
+
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.Header().Set("X-Accel-Buffering", "no")
+	writer.WriteHeader(http.StatusOK)
+
+	for _, event := range backlog {
+		if err := writeEvent(writer, event); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event, ok := <-stream:
+			if !ok {
+				return
+			}
+
+			if err := writeEvent(writer, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := writer.Write([]byte(": ping\n\n")); err != nil {
+				return
+			}
+		case <-request.Context().Done():
+			return
+		}
+
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,315 @@
+package delivery
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"tp-db-forum/internal/app/models"
+)
+
+const feedSize = 50
+
+// Feed and entry ids are tag URIs (RFC 4151), so they stay the same whatever
+// host the feed is fetched through; only the links follow the request.
+const feedTagPrefix = "tag:tp-db-forum,2020:"
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Id        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Updated   string     `xml:"updated"`
+	Published string     `xml:"published"`
+	Author    atomAuthor `xml:"author"`
+	Link      atomLink   `xml:"link"`
+	Content   atomText   `xml:"content"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Id      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssGuid struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Guid        rssGuid `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Creator     string  `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Description string  `xml:"description"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type feedEntry struct {
+	id      string
+	link    string
+	title   string
+	author  string
+	content string
+	created time.Time
+}
+
+type feed struct {
+	id      string
+	link    string
+	self    string
+	title   string
+	entries []feedEntry
+}
+
+func baseUrl(request *http.Request) string {
+	scheme := request.Header.Get("X-Forwarded-Proto")
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	return scheme + "://" + request.Host
+}
+
+func parseCreated(created string) time.Time {
+	t, err := time.Parse(time.RFC3339, created)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t.UTC()
+}
+
+func (f feed) updated() time.Time {
+	updated := time.Unix(0, 0).UTC()
+	for _, entry := range f.entries {
+		if entry.created.After(updated) {
+			updated = entry.created
+		}
+	}
+
+	return updated
+}
+
+func (f feed) atom() ([]byte, error) {
+	updated := f.updated()
+
+	result := atomFeed{
+		Id:      f.id,
+		Title:   f.title,
+		Updated: updated.Format(time.RFC3339),
+		Links: []atomLink{
+			{Rel: "self", Href: f.self},
+			{Rel: "alternate", Href: f.link},
+		},
+	}
+
+	for _, entry := range f.entries {
+		result.Entries = append(result.Entries, atomEntry{
+			Id:        entry.id,
+			Title:     entry.title,
+			Updated:   entry.created.Format(time.RFC3339),
+			Published: entry.created.Format(time.RFC3339),
+			Author:    atomAuthor{Name: entry.author},
+			Link:      atomLink{Rel: "alternate", Href: entry.link},
+			Content:   atomText{Type: "text", Body: entry.content},
+		})
+	}
+
+	body, err := xml.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), body...), nil
+}
+
+func (f feed) rss() ([]byte, error) {
+	result := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         f.title,
+			Link:          f.link,
+			Description:   f.title,
+			LastBuildDate: f.updated().Format(time.RFC1123Z),
+		},
+	}
+
+	for _, entry := range f.entries {
+		result.Channel.Items = append(result.Channel.Items, rssItem{
+			Title:       entry.title,
+			Link:        entry.link,
+			Guid:        rssGuid{IsPermaLink: false, Value: entry.id},
+			PubDate:     entry.created.Format(time.RFC1123Z),
+			Creator:     entry.author,
+			Description: entry.content,
+		})
+	}
+
+	body, err := xml.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), body...), nil
+}
+
+func writeFeed(writer http.ResponseWriter, request *http.Request, f feed) {
+	var body []byte
+	var err error
+	if strings.HasSuffix(request.URL.Path, ".rss") {
+		writer.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+		body, err = f.rss()
+	} else {
+		writer.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+		body, err = f.atom()
+	}
+	if err != nil {
+		return
+	}
+
+	writeConditional(writer, request, body, f.updated())
+}
+
+func (h AppHandler) ForumFeed(writer http.ResponseWriter, request *http.Request) {
+	path := strings.TrimSuffix(strings.TrimSuffix(request.URL.Path, ".atom"), ".rss")
+	slug := strings.TrimSuffix(strings.TrimPrefix(path, "/api/forum/"), "/feed")
+
+	forum, err := h.appUseCase.CheckForumBySlug(slug)
+	if err != nil {
+		body, err := errorMarshal("Can't find forum")
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(http.StatusNotFound)
+		writer.Write(body)
+
+		return
+	}
+
+	threads, err := h.appUseCase.CheckThreadsByForum(forum.Slug, models.QueryParameters{Limit: feedSize, Desc: true})
+	if err != nil {
+		body, err := errorMarshal("can't load threads")
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(http.StatusInternalServerError)
+		writer.Write(body)
+
+		return
+	}
+
+	base := baseUrl(request)
+	f := feed{
+		id:    feedTagPrefix + "forum/" + forum.Slug,
+		link:  fmt.Sprintf("%s/api/forum/%s/details", base, forum.Slug),
+		self:  base + request.URL.Path,
+		title: forum.Title,
+	}
+
+	for _, thread := range threads {
+		f.entries = append(f.entries, feedEntry{
+			id:      fmt.Sprintf("%sthread/%d", feedTagPrefix, thread.Id),
+			link:    fmt.Sprintf("%s/api/thread/%d/details", base, thread.Id),
+			title:   thread.Title,
+			author:  thread.Author,
+			content: thread.Message,
+			created: parseCreated(thread.Created),
+		})
+	}
+
+	writeFeed(writer, request, f)
+}
+
+func (h AppHandler) ThreadFeed(writer http.ResponseWriter, request *http.Request) {
+	path := strings.TrimSuffix(strings.TrimSuffix(request.URL.Path, ".atom"), ".rss")
+	slugOrId := strings.TrimSuffix(strings.TrimPrefix(path, "/api/thread/"), "/feed")
+
+	var thread models.Thread
+	id, err := strconv.Atoi(slugOrId)
+	if err != nil {
+		thread, err = h.appUseCase.CheckThreadBySlug(slugOrId)
+	} else {
+		thread, err = h.appUseCase.CheckThreadById(id)
+	}
+
+	if err != nil {
+		body, err := errorMarshal("can't find thread")
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(http.StatusNotFound)
+		writer.Write(body)
+
+		return
+	}
+
+	posts, err := h.appUseCase.CheckPostsByThread(models.Thread{Id: thread.Id}, feedSize, 0, "flat", true)
+	if err != nil {
+		body, err := errorMarshal("can't load posts")
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(http.StatusInternalServerError)
+		writer.Write(body)
+
+		return
+	}
+
+	base := baseUrl(request)
+	f := feed{
+		id:    fmt.Sprintf("%sthread/%d/posts", feedTagPrefix, thread.Id),
+		link:  fmt.Sprintf("%s/api/thread/%d/details", base, thread.Id),
+		self:  base + request.URL.Path,
+		title: thread.Title,
+	}
+
+	for _, post := range posts {
+		f.entries = append(f.entries, feedEntry{
+			id:      fmt.Sprintf("%spost/%d", feedTagPrefix, post.Id),
+			link:    fmt.Sprintf("%s/api/post/%d/details", base, post.Id),
+			title:   fmt.Sprintf("%s in %s", post.Author, thread.Title),
+			author:  post.Author,
+			content: post.Message,
+			created: parseCreated(post.Created),
+		})
+	}
+
+	writeFeed(writer, request, f)
+}
@@ -0,0 +1,78 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/graphql-go/graphql"
+	"net/http"
+	"tp-db-forum/internal/app"
+	"tp-db-forum/internal/app/events"
+	"tp-db-forum/internal/app/models"
+)
+
+type GraphQLHandler struct {
+	appUseCase app.UseCase
+	schema     graphql.Schema
+}
+
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func NewGraphQLHandler(router *mux.Router, appUseCase app.UseCase, broker *events.Broker) error {
+	schema, err := newSchema(resolver{appUseCase: appUseCase, broker: broker})
+	if err != nil {
+		return err
+	}
+
+	handler := &GraphQLHandler{
+		appUseCase: appUseCase,
+		schema:     schema,
+	}
+
+	router.HandleFunc("/graphql", handler.Query).Methods(http.MethodGet, http.MethodPost)
+
+	return nil
+}
+
+func (h GraphQLHandler) Query(writer http.ResponseWriter, request *http.Request) {
+	var body graphQLRequest
+	if request.Method == http.MethodGet {
+		body.Query = request.URL.Query().Get("query")
+		body.OperationName = request.URL.Query().Get("operationName")
+		if variables := request.URL.Query().Get("variables"); variables != "" {
+			json.Unmarshal([]byte(variables), &body.Variables)
+		}
+	} else if err := json.NewDecoder(request.Body).Decode(&body); err != nil {
+		response, err := json.Marshal(models.Error{Message: "invalid request body"})
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write(response)
+
+		return
+	}
+
+	ctx := context.WithValue(request.Context(), loadersKey{}, newLoaders(h.appUseCase))
+
+	result := graphql.Do(graphql.Params{
+		Schema:         h.schema,
+		RequestString:  body.Query,
+		OperationName:  body.OperationName,
+		VariableValues: body.Variables,
+		Context:        ctx,
+	})
+
+	response, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+	writer.Write(response)
+}
@@ -0,0 +1,134 @@
+package gql
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"tp-db-forum/internal/app"
+)
+
+// loader collects the keys requested by resolvers of one execution level and
+// fetches them with a single query when the first of the returned thunks is
+// called by the executor.
+type loader struct {
+	mu      sync.Mutex
+	pending []string
+	cache   map[string]interface{}
+	fetch   func(keys []string) (map[string]interface{}, error)
+}
+
+func newLoader(fetch func(keys []string) (map[string]interface{}, error)) *loader {
+	return &loader{
+		cache: make(map[string]interface{}),
+		fetch: fetch,
+	}
+}
+
+func (l *loader) load(key string) func() (interface{}, error) {
+	l.mu.Lock()
+	if _, ok := l.cache[key]; !ok {
+		l.cache[key] = nil
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if len(l.pending) != 0 {
+			keys := l.pending
+			l.pending = nil
+
+			values, err := l.fetch(keys)
+			if err != nil {
+				return nil, err
+			}
+
+			for key, value := range values {
+				l.cache[key] = value
+			}
+		}
+
+		return l.cache[key], nil
+	}
+}
+
+type loaders struct {
+	users   *loader
+	forums  *loader
+	threads *loader
+}
+
+type loadersKey struct{}
+
+func newLoaders(useCase app.UseCase) *loaders {
+	return &loaders{
+		users: newLoader(func(keys []string) (map[string]interface{}, error) {
+			users, err := useCase.CheckUsersByNicknames(keys)
+			if err != nil {
+				return nil, err
+			}
+
+			result := make(map[string]interface{}, len(users))
+			for _, user := range users {
+				result[strings.ToLower(user.Nickname)] = user
+			}
+
+			return result, nil
+		}),
+		forums: newLoader(func(keys []string) (map[string]interface{}, error) {
+			forums, err := useCase.CheckForumsBySlugs(keys)
+			if err != nil {
+				return nil, err
+			}
+
+			result := make(map[string]interface{}, len(forums))
+			for _, forum := range forums {
+				result[strings.ToLower(forum.Slug)] = forum
+			}
+
+			return result, nil
+		}),
+		threads: newLoader(func(keys []string) (map[string]interface{}, error) {
+			ids := make([]int, 0, len(keys))
+			for _, key := range keys {
+				id, err := strconv.Atoi(key)
+				if err != nil {
+					continue
+				}
+
+				ids = append(ids, id)
+			}
+
+			threads, err := useCase.CheckThreadsByIds(ids)
+			if err != nil {
+				return nil, err
+			}
+
+			result := make(map[string]interface{}, len(threads))
+			for _, thread := range threads {
+				result[strconv.Itoa(thread.Id)] = thread
+			}
+
+			return result, nil
+		}),
+	}
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+func loadUser(ctx context.Context, nickname string) func() (interface{}, error) {
+	return loadersFrom(ctx).users.load(strings.ToLower(nickname))
+}
+
+func loadForum(ctx context.Context, slug string) func() (interface{}, error) {
+	return loadersFrom(ctx).forums.load(strings.ToLower(slug))
+}
+
+func loadThread(ctx context.Context, id int) func() (interface{}, error) {
+	return loadersFrom(ctx).threads.load(strconv.Itoa(id))
+}
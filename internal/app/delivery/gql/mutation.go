@@ -0,0 +1,214 @@
+package gql
+
+import (
+	"errors"
+	"github.com/graphql-go/graphql"
+	"github.com/jackc/pgx"
+	"tp-db-forum/internal/app/models"
+)
+
+func stringArg(args map[string]interface{}, name string) string {
+	value, _ := args[name].(string)
+
+	return value
+}
+
+func userFromArgs(p graphql.ResolveParams) models.User {
+	input := p.Args["input"].(map[string]interface{})
+
+	return models.User{
+		Nickname: p.Args["nickname"].(string),
+		FullName: stringArg(input, "fullname"),
+		About:    stringArg(input, "about"),
+		Email:    stringArg(input, "email"),
+	}
+}
+
+func (r resolver) createUser(p graphql.ResolveParams) (interface{}, error) {
+	user, err := r.appUseCase.CreateUser(userFromArgs(p))
+	if err != nil {
+		if pgErr, ok := err.(pgx.PgError); ok && pgErr.Code == "23505" {
+			return nil, errors.New("user already exists")
+		}
+
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r resolver) updateUser(p graphql.ResolveParams) (interface{}, error) {
+	user, err := r.appUseCase.EditUser(userFromArgs(p))
+	if err != nil {
+		if pgErr, ok := err.(pgx.PgError); ok && pgErr.Code == "23505" {
+			return nil, errors.New("conflict email")
+		}
+
+		return nil, notFound(err, "user")
+	}
+
+	return user, nil
+}
+
+func (r resolver) createForum(p graphql.ResolveParams) (interface{}, error) {
+	user, err := r.appUseCase.CheckUserByNickname(p.Args["user"].(string))
+	if err != nil {
+		return nil, notFound(err, "user")
+	}
+
+	forum, err := r.appUseCase.CreateForum(models.Forum{
+		Slug:  p.Args["slug"].(string),
+		Title: p.Args["title"].(string),
+		User:  user.Nickname,
+	})
+	if err != nil {
+		if pgErr, ok := err.(pgx.PgError); ok && pgErr.Code == "23505" {
+			return nil, errors.New("forum already exists")
+		}
+
+		return nil, err
+	}
+
+	return forum, nil
+}
+
+func (r resolver) createThread(p graphql.ResolveParams) (interface{}, error) {
+	forum, err := r.appUseCase.CheckForumBySlug(p.Args["forum"].(string))
+	if err != nil {
+		return nil, notFound(err, "forum")
+	}
+
+	thread, err := r.appUseCase.CreateForumThread(models.Thread{
+		Forum:   forum.Slug,
+		Author:  p.Args["author"].(string),
+		Title:   p.Args["title"].(string),
+		Message: p.Args["message"].(string),
+		Slug:    stringArg(p.Args, "slug"),
+		Created: stringArg(p.Args, "created"),
+	})
+	if err != nil {
+		if pgErr, ok := err.(pgx.PgError); ok {
+			switch pgErr.Code {
+			case "23505":
+				return nil, errors.New("thread already exists")
+			case "23503":
+				return nil, errors.New("can't find user")
+			}
+		}
+
+		return nil, err
+	}
+
+	r.broker.Publish(forum.Slug, "thread", thread)
+
+	return thread, nil
+}
+
+func (r resolver) updateThread(p graphql.ResolveParams) (interface{}, error) {
+	thread, err := r.threadBySlugOrId(p.Args["slugOrId"].(string))
+	if err != nil {
+		return nil, notFound(err, "thread")
+	}
+
+	thread, err = r.appUseCase.EditThread(models.Thread{
+		Id:      thread.Id,
+		Title:   stringArg(p.Args, "title"),
+		Message: stringArg(p.Args, "message"),
+	})
+	if err != nil {
+		return nil, notFound(err, "thread")
+	}
+
+	return thread, nil
+}
+
+func (r resolver) createPosts(p graphql.ResolveParams) (interface{}, error) {
+	thread, err := r.threadBySlugOrId(p.Args["thread"].(string))
+	if err != nil {
+		return nil, notFound(err, "thread")
+	}
+
+	inputs := p.Args["posts"].([]interface{})
+	if len(inputs) == 0 {
+		return []models.Post{}, nil
+	}
+
+	posts := make([]models.Post, 0, len(inputs))
+	for _, item := range inputs {
+		input := item.(map[string]interface{})
+
+		post := models.Post{
+			Author:  input["author"].(string),
+			Message: input["message"].(string),
+		}
+		if parent, ok := input["parent"].(int); ok && parent != 0 {
+			post.Parent.Valid = true
+			post.Parent.Int64 = int64(parent)
+		}
+
+		posts = append(posts, post)
+	}
+
+	result, err := r.appUseCase.CreatePosts(posts, thread.Id)
+	if err != nil || len(result) == 0 {
+		if err == models.ErrThreadLocked {
+			return nil, err
+		}
+
+		if pgErr, ok := err.(pgx.PgError); ok && pgErr.Code == "00409" {
+			return nil, errors.New("parent post was created in another thread")
+		}
+
+		if _, err := r.appUseCase.CheckUserByNickname(posts[0].Author); err == pgx.ErrNoRows {
+			return nil, errors.New("can't find user")
+		}
+
+		return nil, errors.New("conflict")
+	}
+
+	r.broker.Publish(result[0].Forum, "posts", result)
+
+	return result, nil
+}
+
+func (r resolver) updatePost(p graphql.ResolveParams) (interface{}, error) {
+	post, err := r.appUseCase.EditPost(p.Args["id"].(int), p.Args["message"].(string), 0)
+	if err != nil {
+		return nil, notFound(err, "post")
+	}
+
+	return post, nil
+}
+
+func (r resolver) vote(p graphql.ResolveParams) (interface{}, error) {
+	thread, err := r.threadBySlugOrId(p.Args["thread"].(string))
+	if err != nil {
+		return nil, notFound(err, "thread")
+	}
+
+	vote := models.Vote{
+		Nickname: p.Args["nickname"].(string),
+		Voice:    p.Args["voice"].(int),
+		IdThread: thread.Id,
+	}
+
+	_, err = r.appUseCase.AddVote(vote)
+	if pgErr, ok := err.(pgx.PgError); ok && pgErr.Code == "23505" {
+		_, err = r.appUseCase.UpdateVote(vote)
+	}
+	if err != nil {
+		return nil, errors.New("can't find user")
+	}
+
+	thread, err = r.appUseCase.CheckThreadById(thread.Id)
+	if err == nil {
+		r.broker.Publish(thread.Forum, "vote", map[string]interface{}{
+			"thread":   thread.Id,
+			"nickname": vote.Nickname,
+			"voice":    vote.Voice,
+			"votes":    thread.Votes,
+		})
+	}
+
+	return vote, nil
+}
@@ -0,0 +1,379 @@
+package gql
+
+import (
+	"errors"
+	"github.com/graphql-go/graphql"
+	"github.com/jackc/pgx"
+	"strconv"
+	"tp-db-forum/internal/app"
+	"tp-db-forum/internal/app/events"
+	"tp-db-forum/internal/app/models"
+)
+
+type resolver struct {
+	appUseCase app.UseCase
+	broker     *events.Broker
+}
+
+func (r resolver) threadBySlugOrId(slugOrId string) (models.Thread, error) {
+	id, err := strconv.Atoi(slugOrId)
+	if err != nil {
+		return r.appUseCase.CheckThreadBySlug(slugOrId)
+	}
+
+	return r.appUseCase.CheckThreadById(id)
+}
+
+func notFound(err error, what string) error {
+	if err == pgx.ErrNoRows {
+		return errors.New("can't find " + what)
+	}
+
+	return err
+}
+
+func listArgs() graphql.FieldConfigArgument {
+	return graphql.FieldConfigArgument{
+		"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 100},
+		"since": &graphql.ArgumentConfig{Type: graphql.String},
+		"desc":  &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: false},
+	}
+}
+
+func queryParameters(args map[string]interface{}) models.QueryParameters {
+	parameters := models.QueryParameters{
+		Limit: args["limit"].(int),
+		Desc:  args["desc"].(bool),
+	}
+	if since, ok := args["since"].(string); ok {
+		parameters.Since = since
+	}
+
+	return parameters
+}
+
+func newSchema(r resolver) (graphql.Schema, error) {
+	postSort := graphql.NewEnum(graphql.EnumConfig{
+		Name: "PostSort",
+		Values: graphql.EnumValueConfigMap{
+			"FLAT":        &graphql.EnumValueConfig{Value: "flat"},
+			"TREE":        &graphql.EnumValueConfig{Value: "tree"},
+			"PARENT_TREE": &graphql.EnumValueConfig{Value: "parent_tree"},
+			"SCORE_TREE":  &graphql.EnumValueConfig{Value: "score_tree"},
+		},
+	})
+
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"nickname": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"fullname": &graphql.Field{Type: graphql.String},
+			"about":    &graphql.Field{Type: graphql.String},
+			"email":    &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	forumType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Forum",
+		Fields: graphql.Fields{
+			"slug":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"title": &graphql.Field{Type: graphql.String},
+			"posts": &graphql.Field{Type: graphql.Int},
+			"user": &graphql.Field{
+				Type: userType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadUser(p.Context, p.Source.(models.Forum).User), nil
+				},
+			},
+		},
+	})
+
+	threadType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Thread",
+		Fields: graphql.Fields{
+			"id":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"title":   &graphql.Field{Type: graphql.String},
+			"message": &graphql.Field{Type: graphql.String},
+			"created": &graphql.Field{Type: graphql.String},
+			"votes":   &graphql.Field{Type: graphql.Int},
+			"slug": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					thread := p.Source.(models.Thread)
+					if models.IsUUID(thread.Slug) {
+						return nil, nil
+					}
+
+					return thread.Slug, nil
+				},
+			},
+			"author": &graphql.Field{
+				Type: userType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadUser(p.Context, p.Source.(models.Thread).Author), nil
+				},
+			},
+			"forum": &graphql.Field{
+				Type: forumType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadForum(p.Context, p.Source.(models.Thread).Forum), nil
+				},
+			},
+		},
+	})
+
+	postType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Post",
+		Fields: graphql.Fields{
+			"id":       &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"message":  &graphql.Field{Type: graphql.String},
+			"created":  &graphql.Field{Type: graphql.String},
+			"isEdited": &graphql.Field{Type: graphql.Boolean},
+			"votes":    &graphql.Field{Type: graphql.Int},
+			"parent": &graphql.Field{
+				Type: graphql.Int,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					parent := p.Source.(models.Post).Parent
+					if !parent.Valid || parent.Int64 == 0 {
+						return nil, nil
+					}
+
+					return int(parent.Int64), nil
+				},
+			},
+			"author": &graphql.Field{
+				Type: userType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadUser(p.Context, p.Source.(models.Post).Author), nil
+				},
+			},
+			"forum": &graphql.Field{
+				Type: forumType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadForum(p.Context, p.Source.(models.Post).Forum), nil
+				},
+			},
+			"thread": &graphql.Field{
+				Type: threadType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadThread(p.Context, p.Source.(models.Post).Thread), nil
+				},
+			},
+		},
+	})
+
+	voteType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Vote",
+		Fields: graphql.Fields{
+			"nickname": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"voice":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"user": &graphql.Field{
+				Type: userType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadUser(p.Context, p.Source.(models.Vote).Nickname), nil
+				},
+			},
+			"thread": &graphql.Field{
+				Type: threadType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadThread(p.Context, p.Source.(models.Vote).IdThread), nil
+				},
+			},
+		},
+	})
+
+	forumType.AddFieldConfig("threads", &graphql.Field{
+		Type: graphql.NewList(threadType),
+		Args: listArgs(),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return r.appUseCase.CheckThreadsByForum(p.Source.(models.Forum).Slug, queryParameters(p.Args))
+		},
+	})
+	forumType.AddFieldConfig("threadCount", &graphql.Field{
+		Type: graphql.Int,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(models.Forum).Threads, nil
+		},
+	})
+	forumType.AddFieldConfig("users", &graphql.Field{
+		Type: graphql.NewList(userType),
+		Args: listArgs(),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return r.appUseCase.CheckUsersByForum(p.Source.(models.Forum).Slug, queryParameters(p.Args))
+		},
+	})
+
+	threadType.AddFieldConfig("posts", &graphql.Field{
+		Type: graphql.NewList(postType),
+		Args: graphql.FieldConfigArgument{
+			"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 100},
+			"since": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+			"sort":  &graphql.ArgumentConfig{Type: postSort, DefaultValue: "flat"},
+			"desc":  &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: false},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return r.appUseCase.CheckPostsByThread(
+				models.Thread{Id: p.Source.(models.Thread).Id},
+				p.Args["limit"].(int),
+				p.Args["since"].(int),
+				p.Args["sort"].(string),
+				p.Args["desc"].(bool),
+			)
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"user": &graphql.Field{
+				Type: userType,
+				Args: graphql.FieldConfigArgument{
+					"nickname": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadUser(p.Context, p.Args["nickname"].(string)), nil
+				},
+			},
+			"forum": &graphql.Field{
+				Type: forumType,
+				Args: graphql.FieldConfigArgument{
+					"slug": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadForum(p.Context, p.Args["slug"].(string)), nil
+				},
+			},
+			"thread": &graphql.Field{
+				Type: threadType,
+				Args: graphql.FieldConfigArgument{
+					"slugOrId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					thread, err := r.threadBySlugOrId(p.Args["slugOrId"].(string))
+					if err == pgx.ErrNoRows {
+						return nil, nil
+					}
+
+					return thread, err
+				},
+			},
+			"post": &graphql.Field{
+				Type: postType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					data, err := r.appUseCase.CheckPostById(p.Args["id"].(int), nil)
+					if err == pgx.ErrNoRows {
+						return nil, nil
+					}
+					if err != nil {
+						return nil, err
+					}
+
+					return data["post"], nil
+				},
+			},
+		},
+	})
+
+	userInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "UserInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"fullname": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"about":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"email":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+
+	postInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "PostInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"author":  &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"message": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"parent":  &graphql.InputObjectFieldConfig{Type: graphql.Int},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createUser": &graphql.Field{
+				Type: userType,
+				Args: graphql.FieldConfigArgument{
+					"nickname": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"input":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(userInput)},
+				},
+				Resolve: r.createUser,
+			},
+			"updateUser": &graphql.Field{
+				Type: userType,
+				Args: graphql.FieldConfigArgument{
+					"nickname": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"input":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(userInput)},
+				},
+				Resolve: r.updateUser,
+			},
+			"createForum": &graphql.Field{
+				Type: forumType,
+				Args: graphql.FieldConfigArgument{
+					"slug":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"title": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"user":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: r.createForum,
+			},
+			"createThread": &graphql.Field{
+				Type: threadType,
+				Args: graphql.FieldConfigArgument{
+					"forum":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"author":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"title":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"message": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"slug":    &graphql.ArgumentConfig{Type: graphql.String},
+					"created": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: r.createThread,
+			},
+			"updateThread": &graphql.Field{
+				Type: threadType,
+				Args: graphql.FieldConfigArgument{
+					"slugOrId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"title":    &graphql.ArgumentConfig{Type: graphql.String},
+					"message":  &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: r.updateThread,
+			},
+			"createPosts": &graphql.Field{
+				Type: graphql.NewList(postType),
+				Args: graphql.FieldConfigArgument{
+					"thread": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"posts":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(postInput)))},
+				},
+				Resolve: r.createPosts,
+			},
+			"updatePost": &graphql.Field{
+				Type: postType,
+				Args: graphql.FieldConfigArgument{
+					"id":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"message": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: r.updatePost,
+			},
+			"vote": &graphql.Field{
+				Type: voteType,
+				Args: graphql.FieldConfigArgument{
+					"thread":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"nickname": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"voice":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: r.vote,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    query,
+		Mutation: mutation,
+	})
+}
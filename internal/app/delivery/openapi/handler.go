@@ -0,0 +1,170 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"tp-db-forum/internal/app/models"
+)
+
+const (
+	ModeLog    = "log"
+	ModeStrict = "strict"
+)
+
+func NewOpenAPIHandler(router *mux.Router) error {
+	body, err := json.Marshal(Document())
+	if err != nil {
+		return err
+	}
+
+	router.HandleFunc("/api/openapi.json", func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+		writer.Write(body)
+	}).Methods(http.MethodGet)
+
+	return nil
+}
+
+// Undocumented lists the routes of router, as "METHOD /path/{template}",
+// that the document doesn't describe.
+func Undocumented(router *mux.Router, document map[string]interface{}) []string {
+	paths := document["paths"].(object)
+
+	var missing []string
+	router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		pathItem, _ := paths[template].(object)
+		for _, method := range methods {
+			if _, ok := pathItem[strings.ToLower(method)]; !ok {
+				missing = append(missing, method+" "+template)
+			}
+		}
+
+		return nil
+	})
+	sort.Strings(missing)
+
+	return missing
+}
+
+func isJSON(media string) bool {
+	return media == jsonMediaType || media == pageMediaType
+}
+
+// streamed reports whether the operation succeeds with something other than
+// JSON, like the event stream and the feeds. Those are passed through the
+// validation middleware untouched so flushing keeps working.
+func streamed(op object) bool {
+	success, _ := op["responses"].(object)["200"].(object)
+	content, _ := success["content"].(object)
+	for media := range content {
+		if isJSON(media) {
+			return false
+		}
+	}
+
+	return len(content) != 0
+}
+
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+
+	return r.body.Write(p)
+}
+
+// ValidationMiddleware checks every response against the document. In
+// ModeLog violations are only logged; in ModeStrict the response is replaced
+// with a 500 naming the violation, so contract drift fails the functional
+// tests instead of going unnoticed.
+func ValidationMiddleware(mode string) mux.MiddlewareFunc {
+	document := Document()
+	paths := document["paths"].(object)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			route := mux.CurrentRoute(request)
+			if route == nil {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			template, err := route.GetPathTemplate()
+			if err != nil {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			pathItem, _ := paths[template].(object)
+			if op, ok := pathItem[strings.ToLower(request.Method)].(object); ok && streamed(op) {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			rec := &recorder{header: writer.Header()}
+			next.ServeHTTP(rec, request)
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+
+			media := strings.TrimSpace(strings.Split(rec.header.Get("Content-Type"), ";")[0])
+			if media != "" && !isJSON(media) {
+				writer.WriteHeader(rec.status)
+				writer.Write(rec.body.Bytes())
+				return
+			}
+
+			err = ValidateResponse(document, request.Method, template, rec.status, rec.body.Bytes())
+			if err != nil {
+				log.Printf("openapi: %s: %v", request.URL.RequestURI(), err)
+
+				if mode == ModeStrict {
+					body, err := json.Marshal(models.Error{Message: fmt.Sprintf("contract violation: %v", err)})
+					if err != nil {
+						return
+					}
+
+					writer.WriteHeader(http.StatusInternalServerError)
+					writer.Write(body)
+
+					return
+				}
+			}
+
+			writer.WriteHeader(rec.status)
+			writer.Write(rec.body.Bytes())
+		})
+	}
+}
@@ -0,0 +1,316 @@
+package openapi_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"tp-db-forum/internal/app"
+	_handler "tp-db-forum/internal/app/delivery"
+	"tp-db-forum/internal/app/delivery/gql"
+	"tp-db-forum/internal/app/delivery/openapi"
+	"tp-db-forum/internal/app/events"
+	"tp-db-forum/internal/app/models"
+	_useCase "tp-db-forum/internal/app/usecase"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx"
+)
+
+const created = "2020-01-01T00:00:00.000Z"
+
+// fakeRepository serves one user, forum, thread and post, and nothing else.
+type fakeRepository struct {
+	app.Repository
+}
+
+var (
+	user   = models.User{Nickname: "alice", FullName: "Alice", About: "about", Email: "alice@example.com", Version: 1}
+	forum  = models.Forum{Slug: "forum", Title: "Forum", User: "alice", Posts: 1, Threads: 1, Version: 1}
+	thread = models.Thread{Id: 1, Author: "alice", Created: created, Forum: "forum", Message: "message", Slug: "thread", Title: "Thread", Votes: 1, Version: 1}
+)
+
+func post() models.Post {
+	post := models.Post{Id: 1, Author: "alice", Created: created, Forum: "forum", Message: "message", Thread: 1, Votes: 1, Version: 1}
+	post.Parent.Valid = true
+
+	return post
+}
+
+func (fakeRepository) SelectUserByNickname(nickname string) (models.User, error) {
+	if !strings.EqualFold(nickname, user.Nickname) {
+		return models.User{}, pgx.ErrNoRows
+	}
+
+	return user, nil
+}
+
+func (fakeRepository) SelectReputation(nickname string) (int64, error) {
+	return 3, nil
+}
+
+func (fakeRepository) SelectForumBySlug(slug string) (models.Forum, error) {
+	if !strings.EqualFold(slug, forum.Slug) {
+		return models.Forum{}, pgx.ErrNoRows
+	}
+
+	return forum, nil
+}
+
+func (fakeRepository) SelectThreadBySlug(slug string) (models.Thread, error) {
+	if !strings.EqualFold(slug, thread.Slug) {
+		return models.Thread{}, pgx.ErrNoRows
+	}
+
+	return thread, nil
+}
+
+func (fakeRepository) SelectThreadById(id int) (models.Thread, error) {
+	if id != thread.Id {
+		return models.Thread{}, pgx.ErrNoRows
+	}
+
+	return thread, nil
+}
+
+func (fakeRepository) UpdateThread(update models.Thread) (models.Thread, error) {
+	if update.Id != thread.Id || update.Version != 0 && update.Version != thread.Version {
+		return models.Thread{}, pgx.ErrNoRows
+	}
+
+	return thread, nil
+}
+
+func (fakeRepository) SelectThreadIdBySlug(slug string) (int, error) {
+	if !strings.EqualFold(slug, thread.Slug) {
+		return 0, pgx.ErrNoRows
+	}
+
+	return thread.Id, nil
+}
+
+func (fakeRepository) SelectPostById(id int) (models.Post, error) {
+	if id != 1 {
+		return models.Post{}, pgx.ErrNoRows
+	}
+
+	return post(), nil
+}
+
+func (fakeRepository) InsertVote(vote models.Vote) (models.Vote, error) {
+	return vote, nil
+}
+
+func (fakeRepository) InsertPostVote(vote models.Vote) (models.Vote, error) {
+	return vote, nil
+}
+
+func (fakeRepository) GetServiceStatus(estimate bool) (map[string]int, error) {
+	return map[string]int{"user": 1, "forum": 1, "thread": 1, "post": 1}, nil
+}
+
+func (fakeRepository) SelectPoolStats() models.PoolStats {
+	return models.PoolStats{}
+}
+
+func (fakeRepository) SelectMigrations() ([]models.Migration, error) {
+	return []models.Migration{{Version: 1, Name: "moderation", Applied: created}}, nil
+}
+
+func (fakeRepository) SelectUsersByForum(slugForum string, parameters models.QueryParameters) ([]models.User, error) {
+	return []models.User{user}, nil
+}
+
+func (fakeRepository) SelectThreadsByForum(slugForum string, parameters models.QueryParameters) ([]models.Thread, error) {
+	return []models.Thread{thread}, nil
+}
+
+func (fakeRepository) SelectPostsByThread(thread models.Thread, limit, since int, sort string, desc bool) ([]models.Post, error) {
+	return []models.Post{post()}, nil
+}
+
+func (fakeRepository) StreamPostsByThread(thread models.Thread, limit, since int, sort string, desc bool, each func(models.Post) error) error {
+	return each(post())
+}
+
+func (fakeRepository) StreamThreadsByForum(slugForum string, parameters models.QueryParameters, each func(models.Thread) error) error {
+	return each(thread)
+}
+
+func (fakeRepository) StreamUsersByForum(slugForum string, parameters models.QueryParameters, each func(models.User) error) error {
+	return each(user)
+}
+
+func (fakeRepository) SelectUsersByNicknames(nicknames []string) ([]models.User, error) {
+	return []models.User{user}, nil
+}
+
+func (fakeRepository) SelectThreadsByIdsOrSlugs(ids []int, slugs []string) ([]models.Thread, error) {
+	return []models.Thread{thread}, nil
+}
+
+func (fakeRepository) SelectPostsByIds(ids []int) ([]models.Post, error) {
+	return []models.Post{post()}, nil
+}
+
+func (fakeRepository) SelectLeaderboard(options models.LeaderboardOptions) (models.Leaderboard, error) {
+	return models.Leaderboard{
+		Forum:   options.Forum,
+		Window:  options.Window,
+		Entries: []models.LeaderboardEntry{{Nickname: "alice", Reputation: 3}},
+	}, nil
+}
+
+func newRouter(t *testing.T) *mux.Router {
+	router := mux.NewRouter()
+
+	useCase := _useCase.NewAppUseCase(fakeRepository{})
+	broker := events.NewBroker(10)
+	_handler.NewAppHandler(router, useCase, broker)
+	if err := gql.NewGraphQLHandler(router, useCase, broker); err != nil {
+		t.Fatal(err)
+	}
+	if err := openapi.NewOpenAPIHandler(router); err != nil {
+		t.Fatal(err)
+	}
+
+	return router
+}
+
+func TestRoutesDocumented(t *testing.T) {
+	for _, route := range openapi.Undocumented(newRouter(t), openapi.Document()) {
+		t.Errorf("%s is not documented", route)
+	}
+}
+
+func TestResponsesMatchDocument(t *testing.T) {
+	router := newRouter(t)
+	router.Use(openapi.ValidationMiddleware(openapi.ModeStrict))
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	for _, test := range []struct {
+		method string
+		path   string
+		accept string
+		body   string
+		status int
+	}{
+		{http.MethodGet, "/api/user/alice/profile", "", "", http.StatusOK},
+		{http.MethodGet, "/api/user/bob/profile", "", "", http.StatusNotFound},
+		{http.MethodPost, "/api/user/batch", "", `["alice"]`, http.StatusOK},
+		{http.MethodGet, "/api/user/leaderboard", "", "", http.StatusOK},
+		{http.MethodGet, "/api/forum/forum/details", "", "", http.StatusOK},
+		{http.MethodGet, "/api/forum/nope/details", "", "", http.StatusNotFound},
+		{http.MethodGet, "/api/forum/forum/threads?limit=1", "", "", http.StatusOK},
+		{http.MethodGet, "/api/forum/forum/threads?limit=1&envelope=true", "", "", http.StatusOK},
+		{http.MethodGet, "/api/forum/forum/users?limit=1", "", "", http.StatusOK},
+		{http.MethodGet, "/api/forum/forum/leaderboard?window=week", "", "", http.StatusOK},
+		{http.MethodGet, "/api/forum/forum/leaderboard?window=decade", "", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/thread/thread/details", "", "", http.StatusOK},
+		{http.MethodGet, "/api/thread/2/details", "", "", http.StatusNotFound},
+		{http.MethodPost, "/api/thread/thread/vote", "", `{"nickname":"alice","voice":1}`, http.StatusOK},
+		{http.MethodPost, "/api/thread/batch", "", `["thread"]`, http.StatusOK},
+		{http.MethodGet, "/api/thread/1/posts?limit=1&sort=tree", "", "", http.StatusOK},
+		{http.MethodGet, "/api/thread/1/posts?limit=1&sort=score_tree&envelope=true", "", "", http.StatusOK},
+		{http.MethodGet, "/api/thread/1/posts?cursor=bogus", "", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/thread/1/posts", "", "", http.StatusOK},
+		{http.MethodGet, "/api/thread/1/posts?limit=5000", "", "", http.StatusOK},
+		{http.MethodGet, "/api/thread/1/posts", "application/x-ndjson", "", http.StatusOK},
+		{http.MethodGet, "/api/thread/1/posts?cursor=" + models.PostCursor(post(), "flat", false, false).Encode(), "application/x-ndjson", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/forum/forum/threads", "text/csv", "", http.StatusOK},
+		{http.MethodGet, "/api/post/1/details?related=user,forum,thread", "", "", http.StatusOK},
+		{http.MethodGet, "/api/post/2/details", "", "", http.StatusNotFound},
+		{http.MethodPost, "/api/post/1/vote", "", `{"nickname":"alice","voice":-1}`, http.StatusOK},
+		{http.MethodPost, "/api/post/batch", "", `[1]`, http.StatusOK},
+		{http.MethodGet, "/api/service/status", "", "", http.StatusOK},
+		{http.MethodGet, "/api/service/status/extended", "", "", http.StatusOK},
+		{http.MethodGet, "/api/openapi.json", "", "", http.StatusOK},
+	} {
+		request, err := http.NewRequest(test.method, server.URL+test.path, strings.NewReader(test.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.accept != "" {
+			request.Header.Set("Accept", test.accept)
+		}
+
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if response.StatusCode != test.status {
+			t.Errorf("%s %s: status %d, want %d: %s", test.method, test.path, response.StatusCode, test.status, body)
+		}
+	}
+}
+
+func TestUnlimitedListingIsPaged(t *testing.T) {
+	router := newRouter(t)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/thread/1/posts", nil))
+
+	etag := recorder.Header().Get("ETag")
+	if recorder.Code != http.StatusOK || etag == "" {
+		t.Fatalf("listing without a limit: status %d, ETag %q", recorder.Code, etag)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/api/thread/1/posts", nil)
+	request.Header.Set("If-None-Match", etag)
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusNotModified {
+		t.Errorf("revalidated listing without a limit: status %d", recorder.Code)
+	}
+}
+
+func TestIfMatchList(t *testing.T) {
+	router := newRouter(t)
+
+	for _, test := range []struct {
+		ifMatch string
+		status  int
+	}{
+		{`"1"`, http.StatusOK},
+		{`"5", "1"`, http.StatusOK},
+		{`"5", "6"`, http.StatusPreconditionFailed},
+		{`*`, http.StatusOK},
+		{`W/"1"`, http.StatusPreconditionFailed},
+	} {
+		request := httptest.NewRequest(http.MethodPost, "/api/thread/1/details", strings.NewReader(`{"title":"Thread"}`))
+		request.Header.Set("If-Match", test.ifMatch)
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		if recorder.Code != test.status {
+			t.Errorf("If-Match %s: status %d, want %d: %s", test.ifMatch, recorder.Code, test.status, recorder.Body.String())
+		}
+	}
+}
+
+func TestValidationCatchesDrift(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/forum/{slug}/details", func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte(`{"slug":"forum","title":1}`))
+	}).Methods(http.MethodGet)
+	router.Use(openapi.ValidationMiddleware(openapi.ModeStrict))
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/forum/forum/details", nil))
+
+	if recorder.Code != http.StatusInternalServerError || !strings.Contains(recorder.Body.String(), "contract violation") {
+		t.Errorf("drifted response passed: %d %s", recorder.Code, recorder.Body.String())
+	}
+}
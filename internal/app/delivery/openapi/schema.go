@@ -0,0 +1,223 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"tp-db-forum/internal/app/models"
+)
+
+type object = map[string]interface{}
+
+var (
+	jsonNullIntType = reflect.TypeOf(models.JsonNullInt{})
+	rawMessageType  = reflect.TypeOf(json.RawMessage{})
+)
+
+func ref(name string) object {
+	return object{"$ref": "#/components/schemas/" + name}
+}
+
+func arrayOf(items object) object {
+	return object{"type": "array", "items": items}
+}
+
+func anyOf(schemas ...object) object {
+	list := make([]interface{}, 0, len(schemas))
+	for _, schema := range schemas {
+		list = append(list, schema)
+	}
+
+	return object{"anyOf": list}
+}
+
+// schemaOf describes the JSON encoding of a model type. Every field without
+// omitempty is listed as required, which is what the handlers produce when
+// they marshal the model.
+func schemaOf(t reflect.Type) object {
+	switch t {
+	case jsonNullIntType:
+		return object{"type": "integer", "format": "int64", "nullable": true}
+	case rawMessageType:
+		return object{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := schemaOf(t.Elem())
+		schema["nullable"] = true
+
+		return schema
+	case reflect.String:
+		return object{"type": "string"}
+	case reflect.Bool:
+		return object{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return object{"type": "integer", "format": "int32"}
+	case reflect.Int64:
+		return object{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return object{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return arrayOf(schemaOf(t.Elem()))
+	case reflect.Map:
+		return object{"type": "object", "additionalProperties": schemaOf(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	}
+
+	return object{}
+}
+
+func structSchema(t reflect.Type) object {
+	properties := object{}
+	var required []interface{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name, options := field.Name, ""
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			parts := strings.SplitN(tag, ",", 2)
+			if parts[0] == "-" {
+				continue
+			}
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			if len(parts) > 1 {
+				options = parts[1]
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		// Embedded structs are flattened, as encoding/json does.
+		if field.Anonymous && name == field.Name && field.Type.Kind() == reflect.Struct {
+			embedded := structSchema(field.Type)
+			for key, value := range embedded["properties"].(object) {
+				properties[key] = value
+			}
+			if names, ok := embedded["required"].([]interface{}); ok {
+				required = append(required, names...)
+			}
+			continue
+		}
+
+		properties[name] = schemaOf(field.Type)
+		if !strings.Contains(options, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	schema := object{"type": "object", "properties": properties}
+	if len(required) != 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+// input is the request body variant of a model: the same properties, but
+// only the listed ones are required and the server-assigned ones are
+// dropped.
+func input(t reflect.Type, drop []string, required ...string) object {
+	schema := structSchema(t)
+
+	properties := schema["properties"].(object)
+	for _, name := range drop {
+		delete(properties, name)
+	}
+
+	delete(schema, "required")
+	if len(required) != 0 {
+		list := make([]interface{}, 0, len(required))
+		for _, name := range required {
+			list = append(list, name)
+		}
+		schema["required"] = list
+	}
+
+	return schema
+}
+
+func components() object {
+	threadWithoutSlug := schemaOf(reflect.TypeOf(models.ThreadWithoutSlug{}))
+	threadWithoutSlug["additionalProperties"] = false
+
+	return object{
+		"Error":             schemaOf(reflect.TypeOf(models.Error{})),
+		"User":              schemaOf(reflect.TypeOf(models.User{})),
+		"Forum":             schemaOf(reflect.TypeOf(models.Forum{})),
+		"Thread":            schemaOf(reflect.TypeOf(models.Thread{})),
+		"ThreadWithoutSlug": threadWithoutSlug,
+		"Post":              schemaOf(reflect.TypeOf(models.Post{})),
+		"Vote":              schemaOf(reflect.TypeOf(models.Vote{})),
+		"Webhook":           schemaOf(reflect.TypeOf(models.Webhook{})),
+		"WebhookDelivery":   schemaOf(reflect.TypeOf(models.WebhookDelivery{})),
+
+		"UserInput":    input(reflect.TypeOf(models.User{}), []string{"nickname"}),
+		"ForumInput":   input(reflect.TypeOf(models.Forum{}), []string{"posts", "threads"}, "slug", "title", "user"),
+		"ThreadInput":  input(reflect.TypeOf(models.Thread{}), []string{"id", "forum", "votes"}, "author", "title", "message"),
+		"ThreadUpdate": input(reflect.TypeOf(models.Thread{}), []string{"id", "author", "created", "forum", "slug", "votes"}),
+		"PostInput":    input(reflect.TypeOf(models.Post{}), []string{"id", "created", "forum", "isEdited", "thread", "votes"}, "author", "message"),
+		"PostUpdate":   input(reflect.TypeOf(models.Post{}), []string{"id", "author", "created", "forum", "isEdited", "parent", "thread", "votes"}),
+		"WebhookInput": input(reflect.TypeOf(models.Webhook{}), []string{"id", "created"}, "url"),
+
+		"ThreadResult": anyOf(ref("Thread"), ref("ThreadWithoutSlug")),
+		"PostFull": object{
+			"type":     "object",
+			"required": []interface{}{"post"},
+			"properties": object{
+				"post":   ref("Post"),
+				"author": ref("User"),
+				"forum":  ref("Forum"),
+				"thread": ref("ThreadResult"),
+			},
+		},
+		"Status": object{
+			"type":     "object",
+			"required": []interface{}{"forum", "post", "thread", "user"},
+			"properties": object{
+				"forum":  object{"type": "integer", "format": "int64"},
+				"post":   object{"type": "integer", "format": "int64"},
+				"thread": object{"type": "integer", "format": "int64"},
+				"user":   object{"type": "integer", "format": "int64"},
+			},
+		},
+		"PageEnvelope":     schemaOf(reflect.TypeOf(models.PageEnvelope{})),
+		"ImportReport":     schemaOf(reflect.TypeOf(models.ImportReport{})),
+		"BulkReport":       schemaOf(reflect.TypeOf(models.BulkReport{})),
+		"CounterReport":    schemaOf(reflect.TypeOf(models.CounterReport{})),
+		"MembershipReport": schemaOf(reflect.TypeOf(models.MembershipReport{})),
+		"ServiceInfo":      schemaOf(reflect.TypeOf(models.ServiceInfo{})),
+		"UserProfile":      schemaOf(reflect.TypeOf(models.UserProfile{})),
+		"Leaderboard":      schemaOf(reflect.TypeOf(models.Leaderboard{})),
+		"ForumStats":       schemaOf(reflect.TypeOf(models.ForumStats{})),
+		"ArchiveRecord": object{
+			"type":     "object",
+			"required": []interface{}{"type", "data"},
+			"properties": object{
+				"type": object{"type": "string", "enum": []interface{}{"archive", "user", "forum", "thread", "post", "vote", "post_vote", "trailer"}},
+				"data": object{"type": "object"},
+			},
+		},
+		"GraphQLRequest": object{
+			"type":     "object",
+			"required": []interface{}{"query"},
+			"properties": object{
+				"query":         object{"type": "string"},
+				"operationName": object{"type": "string"},
+				"variables":     object{"type": "object", "nullable": true},
+			},
+		},
+		"GraphQLResult": object{
+			"type": "object",
+			"properties": object{
+				"data":   object{"type": "object", "nullable": true},
+				"errors": arrayOf(object{"type": "object"}),
+			},
+		},
+	}
+}
@@ -0,0 +1,706 @@
+package openapi
+
+// maxBatchKeys mirrors the limit of the batch handlers.
+const maxBatchKeys = 100
+
+const (
+	jsonMediaType   = "application/json"
+	pageMediaType   = "application/vnd.forum.page+json"
+	ndjsonMediaType = "application/x-ndjson"
+	csvMediaType    = "text/csv"
+)
+
+func pathParameter(name, description string) object {
+	return object{
+		"name":        name,
+		"in":          "path",
+		"required":    true,
+		"description": description,
+		"schema":      object{"type": "string"},
+	}
+}
+
+func queryParameter(name, description string, schema object) object {
+	return object{
+		"name":        name,
+		"in":          "query",
+		"description": description,
+		"schema":      schema,
+	}
+}
+
+var (
+	slugParameter     = pathParameter("slug", "Forum slug.")
+	slugOrIdParameter = pathParameter("slug_or_id", "Thread slug or numeric id.")
+	idParameter       = pathParameter("id", "Numeric id.")
+
+	limitParameter = queryParameter("limit", "Maximum number of items to return.",
+		object{"type": "integer", "format": "int32", "minimum": 1, "default": 100})
+	descParameter = queryParameter("desc", "Sort in descending order.",
+		object{"type": "boolean", "default": false})
+	cursorParameter = queryParameter("cursor", "Opaque cursor from X-Next-Cursor, X-Prev-Cursor or the page envelope.",
+		object{"type": "string"})
+	envelopeParameter = queryParameter("envelope", "Wrap the list in a page envelope.",
+		object{"type": "boolean", "default": false})
+	totalParameter = queryParameter("total", "Include the total number of items in the page envelope.",
+		object{"type": "string", "enum": []interface{}{"exact", "estimate"}})
+	estimateStatusParameter = queryParameter("estimate", "Take the counts from the planner statistics instead.",
+		object{"type": "boolean", "default": false})
+	windowParameter = queryParameter("window", "Count the votes cast in the last day, week, month or year only.",
+		object{"type": "string", "enum": []interface{}{"all", "day", "week", "month", "year"}, "default": "all"})
+	leaderboardLimitParameter = queryParameter("limit", "Number of users to list.",
+		object{"type": "integer", "format": "int32", "minimum": 1, "maximum": 100, "default": 10})
+	fieldsParameter = queryParameter("fields", "Comma separated fields to export with text/csv or application/x-ndjson; all of them by default.",
+		object{"type": "string"})
+)
+
+func jsonContent(schema object) object {
+	return object{jsonMediaType: object{"schema": schema}}
+}
+
+func response(description string, schema object) object {
+	if schema == nil {
+		return object{"description": description}
+	}
+
+	return object{"description": description, "content": jsonContent(schema)}
+}
+
+func errorResponse(description string) object {
+	return response(description, ref("Error"))
+}
+
+func body(schema object) object {
+	return object{"required": true, "content": jsonContent(schema)}
+}
+
+// list describes a listing handler built on writeList: a bare JSON array by
+// default, or a page envelope around it.
+func list(item object) object {
+	envelope := object{
+		"allOf": []interface{}{
+			ref("PageEnvelope"),
+			object{"type": "object", "properties": object{"items": arrayOf(item)}},
+		},
+	}
+
+	return object{
+		"description": "A page of items. Cursors are also sent in X-Next-Cursor, X-Prev-Cursor and Link.",
+		"content": object{
+			jsonMediaType: object{"schema": anyOf(arrayOf(item), envelope)},
+			pageMediaType: object{"schema": envelope},
+		},
+	}
+}
+
+// streamable adds the export forms of a listing the handler can stream.
+func streamable(listing object, item object) object {
+	content := listing["content"].(object)
+	content[ndjsonMediaType] = object{"schema": item}
+	content[csvMediaType] = object{"schema": object{"type": "string"}}
+	listing["description"] = listing["description"].(string) +
+		" With Accept: application/x-ndjson or text/csv the items are streamed one per line instead," +
+		" with the columns given by fields; combined with cursor they are answered with 400." +
+		" Without a limit, or with a bigger one, at most X-Result-Limit items are returned."
+
+	return listing
+}
+
+func feed(description string) object {
+	return object{
+		"summary":    description,
+		"parameters": []interface{}{},
+		"responses": object{
+			"200": object{
+				"description": "The feed.",
+				"content": object{
+					"application/atom+xml": object{"schema": object{"type": "string"}},
+					"application/rss+xml":  object{"schema": object{"type": "string"}},
+				},
+			},
+			"304": response("Not modified since If-None-Match or If-Modified-Since.", nil),
+			"404": errorResponse("Not found."),
+		},
+	}
+}
+
+func operation(summary string, parameters []interface{}, requestBody object, responses object) object {
+	result := object{
+		"summary":    summary,
+		"parameters": parameters,
+		"responses":  responses,
+	}
+	if requestBody != nil {
+		result["requestBody"] = requestBody
+	}
+
+	return result
+}
+
+// idempotent documents the Idempotency-Key handling of a creating operation.
+func idempotent(op object) object {
+	op["parameters"] = append(op["parameters"].([]interface{}), object{
+		"name":        "Idempotency-Key",
+		"in":          "header",
+		"description": "Replay the stored response of an earlier request with this key and the same body.",
+		"schema":      object{"type": "string", "maxLength": 255},
+	})
+
+	responses := op["responses"].(object)
+	responses["422"] = errorResponse("The Idempotency-Key was used for a different request.")
+	if _, ok := responses["400"]; !ok {
+		responses["400"] = errorResponse("The Idempotency-Key is too long.")
+	}
+
+	conflict := "A request with the same Idempotency-Key is still being processed."
+	if existing, ok := responses["409"].(object); ok {
+		schema := existing["content"].(object)[jsonMediaType].(object)["schema"].(object)
+		existing["description"] = existing["description"].(string) + " Or: " + conflict
+		existing["content"] = jsonContent(anyOf(schema, ref("Error")))
+	} else {
+		responses["409"] = errorResponse(conflict)
+	}
+
+	return op
+}
+
+// conditional documents the validators of a read operation.
+func conditional(op object) object {
+	op["parameters"] = append(op["parameters"].([]interface{}),
+		object{"name": "If-None-Match", "in": "header", "schema": object{"type": "string"}},
+		object{"name": "If-Modified-Since", "in": "header", "schema": object{"type": "string"}},
+	)
+	op["responses"].(object)["304"] = response("Not modified; the ETag header carries the current version.", nil)
+
+	return op
+}
+
+// versioned documents If-Match on an update operation.
+func versioned(op object) object {
+	op["parameters"] = append(op["parameters"].([]interface{}), object{
+		"name":        "If-Match",
+		"in":          "header",
+		"description": "Only update if the entity still has this ETag.",
+		"schema":      object{"type": "string"},
+	})
+	op["responses"].(object)["412"] = errorResponse("The entity changed since the version in If-Match.")
+
+	return op
+}
+
+func params(list ...object) []interface{} {
+	result := make([]interface{}, 0, len(list))
+	for _, item := range list {
+		result = append(result, item)
+	}
+
+	return result
+}
+
+func paths() object {
+	forumFeed := feed("Latest threads of a forum.")
+	forumFeed["parameters"] = params(slugParameter)
+	threadFeed := feed("Latest posts of a thread.")
+	threadFeed["parameters"] = params(slugOrIdParameter)
+
+	return object{
+		"/api/user/batch": object{
+			"post": operation("Get users by nickname.",
+				params(),
+				body(object{"type": "array", "maxItems": maxBatchKeys, "items": object{"type": "string"}}),
+				object{
+					"200": response("Found entities in request order and the keys nothing was found for.", object{
+						"type":     "object",
+						"required": []interface{}{"items", "missing"},
+						"properties": object{
+							"items":   arrayOf(ref("User")),
+							"missing": arrayOf(object{"type": "string"}),
+						},
+					}),
+					"400": errorResponse("Malformed body or too many keys."),
+					"500": errorResponse("Database error."),
+				}),
+		},
+		"/api/user/{nickname}/create": object{
+			"post": idempotent(operation("Create a user.",
+				params(pathParameter("nickname", "User nickname.")),
+				body(ref("UserInput")),
+				object{
+					"201": response("The created user.", ref("User")),
+					"409": response("Users that already have this nickname or email.", arrayOf(ref("User"))),
+				})),
+		},
+		"/api/user/leaderboard": object{
+			"get": operation("Users by the votes on their threads and posts in every forum. Results are cached for up to a minute.",
+				params(windowParameter, leaderboardLimitParameter),
+				nil,
+				object{
+					"200": response("The leaderboard.", ref("Leaderboard")),
+					"400": errorResponse("Invalid window or limit."),
+					"500": errorResponse("Database error."),
+				}),
+		},
+		"/api/user/{nickname}/profile": object{
+			"get": conditional(operation("Get a user with their reputation.",
+				params(pathParameter("nickname", "User nickname.")),
+				nil,
+				object{
+					"200": response("The user.", ref("UserProfile")),
+					"404": errorResponse("User not found."),
+				})),
+			"post": versioned(operation("Update a user.",
+				params(pathParameter("nickname", "User nickname.")),
+				body(ref("UserInput")),
+				object{
+					"200": response("The updated user.", ref("User")),
+					"404": errorResponse("User not found."),
+					"409": errorResponse("The email belongs to another user."),
+				})),
+		},
+
+		"/api/forum/create": object{
+			"post": idempotent(operation("Create a forum.",
+				params(),
+				body(ref("ForumInput")),
+				object{
+					"201": response("The created forum.", ref("Forum")),
+					"404": errorResponse("Owner not found."),
+					"409": response("The forum that already has this slug.", ref("Forum")),
+				})),
+		},
+		"/api/forum/{slug}/details": object{
+			"get": conditional(operation("Get a forum.",
+				params(slugParameter),
+				nil,
+				object{
+					"200": response("The forum.", ref("Forum")),
+					"404": errorResponse("Forum not found."),
+				})),
+		},
+		"/api/forum/{slug}/create": object{
+			"post": idempotent(operation("Create a thread in a forum.",
+				params(slugParameter),
+				body(ref("ThreadInput")),
+				object{
+					"201": response("The created thread; slug is left out when none was given.", ref("ThreadResult")),
+					"404": errorResponse("Forum or author not found."),
+					"409": response("The thread that already has this slug.", ref("Thread")),
+				})),
+		},
+		"/api/forum/{slug}/threads": object{
+			"get": conditional(operation("List the threads of a forum by creation time.",
+				params(slugParameter, limitParameter,
+					queryParameter("since", "Only threads created at or after (before with desc) this time.",
+						object{"type": "string", "format": "date-time"}),
+					descParameter, cursorParameter, envelopeParameter, totalParameter, fieldsParameter),
+				nil,
+				object{
+					"200": streamable(list(ref("ThreadResult")), ref("ThreadResult")),
+					"400": errorResponse("Invalid cursor, or a cursor with a streamed format."),
+					"404": errorResponse("Forum not found."),
+				})),
+		},
+		"/api/forum/{slug}/users": object{
+			"get": conditional(operation("List the users who posted in a forum by nickname.",
+				params(slugParameter, limitParameter,
+					queryParameter("since", "Only users with a nickname after (before with desc) this one.",
+						object{"type": "string"}),
+					descParameter, cursorParameter, envelopeParameter, totalParameter, fieldsParameter),
+				nil,
+				object{
+					"200": streamable(list(ref("User")), ref("User")),
+					"400": errorResponse("Invalid cursor, or a cursor with a streamed format."),
+					"404": errorResponse("Forum not found."),
+				})),
+		},
+		"/api/forum/{slug}/events": object{
+			"get": operation("Stream forum activity as server-sent events.",
+				params(slugParameter,
+					queryParameter("last_event_id", "Resume after this event; same as the Last-Event-ID header.",
+						object{"type": "string"}),
+					object{"name": "Last-Event-ID", "in": "header", "schema": object{"type": "string"}}),
+				nil,
+				object{
+					"200": object{
+						"description": "Event stream with thread, posts, vote and post_vote events.",
+						"content":     object{"text/event-stream": object{"schema": object{"type": "string"}}},
+					},
+					"404": errorResponse("Forum not found."),
+				}),
+		},
+		"/api/forum/{slug}/feed.atom": object{"get": forumFeed},
+		"/api/forum/{slug}/feed.rss":  object{"get": forumFeed},
+		"/api/forum/{slug}/export": object{
+			"get": operation("Export a forum with its threads, posts, thread and post votes and their users.",
+				params(slugParameter),
+				nil,
+				object{
+					"200": object{
+						"description": "The forum archive, one record per line, written as it is read." +
+							" It ends with a trailer record counting the others; an export that fails midway leaves it out.",
+						"content": object{ndjsonMediaType: object{"schema": ref("ArchiveRecord")}},
+					},
+					"404": errorResponse("Forum not found."),
+					"500": errorResponse("Database error."),
+				}),
+		},
+		"/api/forum/{slug}/stats": object{
+			"get": operation("Activity of a forum over time, its top posters, busiest threads and reply depths. "+
+				"Results are cached for up to a minute.",
+				params(slugParameter,
+					queryParameter("bucket", "Size of the activity buckets.",
+						object{"type": "string", "enum": []interface{}{"hour", "day", "week"}, "default": "day"}),
+					queryParameter("since", "Start of the window, rounded down to a bucket; 30 buckets before until by default.",
+						object{"type": "string", "format": "date-time"}),
+					queryParameter("until", "End of the window, rounded up to a bucket; the end of the current one by default.",
+						object{"type": "string", "format": "date-time"}),
+					queryParameter("limit", "Length of the top posters and busiest threads lists.",
+						object{"type": "integer", "format": "int32", "minimum": 1, "maximum": 100, "default": 10})),
+				nil,
+				object{
+					"200": response("The forum stats.", ref("ForumStats")),
+					"400": errorResponse("Invalid bucket, window or limit."),
+					"404": errorResponse("Forum not found."),
+					"500": errorResponse("Database error."),
+				}),
+		},
+		"/api/forum/{slug}/leaderboard": object{
+			"get": operation("Users of a forum by the votes on their threads and posts there. Results are cached for up to a minute.",
+				params(slugParameter, windowParameter, leaderboardLimitParameter),
+				nil,
+				object{
+					"200": response("The leaderboard.", ref("Leaderboard")),
+					"400": errorResponse("Invalid window or limit."),
+					"404": errorResponse("Forum not found."),
+					"500": errorResponse("Database error."),
+				}),
+		},
+		"/api/forum/import": object{
+			"post": operation("Import a forum archive in one transaction."+
+				" The request body limit applies, so large archives are better imported with forumctl.",
+				params(
+					queryParameter("slug", "Import the forum under this slug.", object{"type": "string"}),
+					queryParameter("users", "What to do with users that already exist.",
+						object{"type": "string", "enum": []interface{}{"skip", "overwrite", "fail"}, "default": "skip"}),
+					queryParameter("slugs", "What to do with forum and thread slugs that are taken.",
+						object{"type": "string", "enum": []interface{}{"fail", "rename"}, "default": "fail"}),
+					queryParameter("dry_run", "Validate the archive and report the conflicts without importing.",
+						object{"type": "boolean", "default": false})),
+				object{
+					"required": true,
+					"content":  object{ndjsonMediaType: object{"schema": ref("ArchiveRecord")}},
+				},
+				object{
+					"200": response("Dry run report.", ref("ImportReport")),
+					"201": response("Import report.", ref("ImportReport")),
+					"400": errorResponse("Malformed archive or options."),
+					"409": errorResponse("The archive conflicts with existing data."),
+					"500": errorResponse("Database error."),
+				}),
+		},
+
+		"/api/thread/batch": object{
+			"post": operation("Get threads by slug or id.",
+				params(),
+				body(object{"type": "array", "maxItems": maxBatchKeys, "items": object{"type": "string"}}),
+				object{
+					"200": response("Found entities in request order and the keys nothing was found for.", object{
+						"type":     "object",
+						"required": []interface{}{"items", "missing"},
+						"properties": object{
+							"items":   arrayOf(ref("ThreadResult")),
+							"missing": arrayOf(object{"type": "string"}),
+						},
+					}),
+					"400": errorResponse("Malformed body or too many keys."),
+					"500": errorResponse("Database error."),
+				}),
+		},
+		"/api/thread/{slug_or_id}/create": object{
+			"post": idempotent(operation("Create posts in a thread.",
+				params(slugOrIdParameter),
+				body(arrayOf(ref("PostInput"))),
+				object{
+					"201": response("The created posts.", arrayOf(ref("Post"))),
+					"404": errorResponse("Thread or author not found."),
+					"403": errorResponse("The thread is locked."),
+					"409": errorResponse("A parent post belongs to another thread."),
+				})),
+		},
+		"/api/thread/{slug_or_id}/vote": object{
+			"post": idempotent(operation("Vote for a thread.",
+				params(slugOrIdParameter),
+				body(ref("Vote")),
+				object{
+					"200": response("The thread with its new vote count.", ref("ThreadResult")),
+					"404": errorResponse("Thread or user not found."),
+				})),
+		},
+		"/api/thread/{slug_or_id}/details": object{
+			"get": conditional(operation("Get a thread.",
+				params(slugOrIdParameter),
+				nil,
+				object{
+					"200": response("The thread.", ref("ThreadResult")),
+					"404": errorResponse("Thread not found."),
+				})),
+			"post": versioned(operation("Update a thread.",
+				params(slugOrIdParameter),
+				body(ref("ThreadUpdate")),
+				object{
+					"200": response("The updated thread.", ref("ThreadResult")),
+					"404": errorResponse("Thread not found."),
+				})),
+		},
+		"/api/thread/{slug_or_id}/posts": object{
+			"get": conditional(operation("List the posts of a thread.",
+				params(slugOrIdParameter, limitParameter,
+					queryParameter("since", "Only posts after (before with desc) the post with this id.",
+						object{"type": "integer", "format": "int64"}),
+					queryParameter("sort", "Sort order. score_tree is tree with the root posts and the replies to each post ordered by votes, highest first.",
+						object{"type": "string", "enum": []interface{}{"flat", "tree", "parent_tree", "score_tree"}, "default": "flat"}),
+					descParameter, cursorParameter, envelopeParameter, totalParameter, fieldsParameter),
+				nil,
+				object{
+					"200": streamable(list(ref("Post")), ref("Post")),
+					"400": errorResponse("Invalid cursor, or a cursor with a streamed format."),
+					"404": errorResponse("Thread not found."),
+				})),
+		},
+		"/api/thread/{slug_or_id}/feed.atom": object{"get": threadFeed},
+		"/api/thread/{slug_or_id}/feed.rss":  object{"get": threadFeed},
+
+		"/api/post/batch": object{
+			"post": operation("Get posts by id.",
+				params(),
+				body(object{"type": "array", "maxItems": maxBatchKeys, "items": object{"type": "integer", "format": "int64"}}),
+				object{
+					"200": response("Found entities in request order and the keys nothing was found for.", object{
+						"type":     "object",
+						"required": []interface{}{"items", "missing"},
+						"properties": object{
+							"items":   arrayOf(ref("Post")),
+							"missing": arrayOf(object{"type": "integer", "format": "int64"}),
+						},
+					}),
+					"400": errorResponse("Malformed body or too many keys."),
+					"500": errorResponse("Database error."),
+				}),
+		},
+		"/api/post/{id}/details": object{
+			"get": conditional(operation("Get a post with related objects.",
+				params(idParameter,
+					object{
+						"name":        "related",
+						"in":          "query",
+						"description": "Related objects to include.",
+						"style":       "form",
+						"explode":     false,
+						"schema":      arrayOf(object{"type": "string", "enum": []interface{}{"user", "forum", "thread"}}),
+					}),
+				nil,
+				object{
+					"200": response("The post and the requested related objects.", ref("PostFull")),
+					"404": errorResponse("Post not found."),
+				})),
+			"post": versioned(operation("Update a post.",
+				params(idParameter),
+				body(ref("PostUpdate")),
+				object{
+					"200": response("The updated post.", ref("Post")),
+					"404": errorResponse("Post not found."),
+				})),
+		},
+		"/api/post/{id}/vote": object{
+			"post": idempotent(operation("Vote for a post. Voting again replaces the earlier vote.",
+				params(idParameter),
+				body(ref("Vote")),
+				object{
+					"200": response("The post with its new vote count.", ref("Post")),
+					"404": errorResponse("Post or user not found."),
+				})),
+		},
+
+		"/api/service/status": object{
+			"get": operation("Row counts of the main tables, as the triggers keep them.",
+				params(estimateStatusParameter),
+				nil,
+				object{
+					"200": response("Row counts.", ref("Status")),
+					"404": errorResponse("Database error."),
+				}),
+		},
+		"/api/service/status/extended": object{
+			"get": operation("Row counts along with the version, uptime, connection pool and schema migration of the instance.",
+				params(estimateStatusParameter),
+				nil,
+				object{
+					"200": response("Extended status.", ref("ServiceInfo")),
+					"500": errorResponse("Database error."),
+				}),
+		},
+		"/api/service/load": object{
+			"post": operation("Bulk load CSV files with COPY."+
+				" Thread and post ids of the files only link their rows, the loaded ones get new ids.",
+				params(),
+				object{
+					"required": true,
+					"content": object{"multipart/form-data": object{"schema": object{
+						"type": "object",
+						"properties": object{
+							"users":      object{"type": "string", "format": "binary", "description": "nickname, fullname, about, email"},
+							"forums":     object{"type": "string", "format": "binary", "description": "slug, title, user"},
+							"threads":    object{"type": "string", "format": "binary", "description": "id, slug, author, forum, title, message, created"},
+							"posts":      object{"type": "string", "format": "binary", "description": "id, parent, thread, author, message, created, isEdited"},
+							"votes":      object{"type": "string", "format": "binary", "description": "nickname, thread, voice"},
+							"post_votes": object{"type": "string", "format": "binary", "description": "nickname, post, voice"},
+						},
+					}}},
+				},
+				object{
+					"201": response("Loaded row counts.", ref("BulkReport")),
+					"400": errorResponse("Malformed files or rows failing the integrity checks."),
+					"409": errorResponse("A forum or thread slug is taken."),
+					"500": errorResponse("Database error."),
+				}),
+		},
+		"/api/service/clear": object{
+			"post": operation("Remove all data.",
+				params(),
+				nil,
+				object{
+					"200": response("Cleared.", nil),
+				}),
+		},
+
+		"/api/admin/webhooks": object{
+			"get": operation("List webhook subscriptions.",
+				params(),
+				nil,
+				object{
+					"200": response("The subscriptions.", arrayOf(ref("Webhook"))),
+					"500": errorResponse("Database error."),
+				}),
+			"post": operation("Subscribe a webhook.",
+				params(),
+				body(ref("WebhookInput")),
+				object{
+					"201": response("The subscription with its signing secret.", ref("Webhook")),
+					"400": errorResponse("Invalid url."),
+					"500": errorResponse("Database error."),
+				}),
+		},
+		"/api/admin/webhooks/dead": object{
+			"get": operation("List deliveries that ran out of attempts.",
+				params(limitParameter),
+				nil,
+				object{
+					"200": response("Dead deliveries.", arrayOf(ref("WebhookDelivery"))),
+					"500": errorResponse("Database error."),
+				}),
+		},
+		"/api/admin/webhooks/deliveries/{id}/replay": object{
+			"post": operation("Retry a dead delivery.",
+				params(idParameter),
+				nil,
+				object{
+					"202": response("Queued.", nil),
+					"404": errorResponse("Delivery not found."),
+				}),
+		},
+		"/api/admin/webhooks/{id}": object{
+			"delete": operation("Unsubscribe a webhook.",
+				params(idParameter),
+				nil,
+				object{
+					"204": response("Removed.", nil),
+					"404": errorResponse("Webhook not found."),
+				}),
+		},
+		"/api/admin/outbox/{id}/replay": object{
+			"post": operation("Deliver an outbox event again to every matching webhook.",
+				params(idParameter),
+				nil,
+				object{
+					"202": response("Number of queued deliveries.", object{
+						"type":       "object",
+						"required":   []interface{}{"deliveries"},
+						"properties": object{"deliveries": object{"type": "integer"}},
+					}),
+					"404": errorResponse("Event not found."),
+					"500": errorResponse("Database error."),
+				}),
+		},
+		"/api/admin/counters/reconcile": object{
+			"post": operation("Recount the counters the triggers maintain and list the drifted ones.",
+				params(queryParameter("fix", "Correct the drifted counters.", object{"type": "boolean", "default": false})),
+				nil,
+				object{
+					"200": response("The drifted counters.", ref("CounterReport")),
+					"500": errorResponse("Database error."),
+				}),
+		},
+		"/api/admin/forum-users/reconcile": object{
+			"post": operation("Compare the users of forums to the profiles and to who wrote there.",
+				params(
+					queryParameter("forum", "Slug of the forum to check; every forum when left out.", object{"type": "string"}),
+					queryParameter("fix", "Rebuild the rows that are off.", object{"type": "boolean", "default": false})),
+				nil,
+				object{
+					"200": response("The rows that are off.", ref("MembershipReport")),
+					"404": errorResponse("Forum not found."),
+					"500": errorResponse("Database error."),
+				}),
+		},
+
+		"/graphql": object{
+			"get": operation("Run a GraphQL query.",
+				params(
+					queryParameter("query", "GraphQL document.", object{"type": "string"}),
+					queryParameter("operationName", "Operation to run.", object{"type": "string"}),
+					queryParameter("variables", "JSON object with the variables.", object{"type": "string"})),
+				nil,
+				object{
+					"200": response("GraphQL result.", ref("GraphQLResult")),
+				}),
+			"post": operation("Run a GraphQL query or mutation.",
+				params(),
+				body(ref("GraphQLRequest")),
+				object{
+					"200": response("GraphQL result.", ref("GraphQLResult")),
+					"400": errorResponse("Invalid request body."),
+				}),
+		},
+
+		"/api/openapi.json": object{
+			"get": operation("This document.",
+				params(),
+				nil,
+				object{
+					"200": response("OpenAPI 3 document.", object{"type": "object"}),
+				}),
+		},
+
+		"/debug/vars": object{
+			"get": operation("Runtime and repository cache metrics.",
+				params(),
+				nil,
+				object{
+					"200": response("expvar variables.", object{"type": "object"}),
+				}),
+		},
+	}
+}
+
+// Document builds the OpenAPI 3 description of the REST API.
+func Document() map[string]interface{} {
+	return object{
+		"openapi": "3.0.3",
+		"info": object{
+			"title":   "tp-db-forum",
+			"version": "1.0.0",
+		},
+		"paths":      paths(),
+		"components": object{"schemas": components()},
+	}
+}
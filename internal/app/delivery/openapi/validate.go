@@ -0,0 +1,203 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// validator checks decoded JSON values against the subset of OpenAPI
+// schemas Document uses.
+type validator struct {
+	schemas object
+}
+
+func (v validator) resolve(schema object) object {
+	for {
+		target, ok := schema["$ref"].(string)
+		if !ok {
+			return schema
+		}
+
+		schema, _ = v.schemas[strings.TrimPrefix(target, "#/components/schemas/")].(object)
+	}
+}
+
+func (v validator) validate(schema object, value interface{}, path string) error {
+	schema = v.resolve(schema)
+
+	if value == nil {
+		if nullable, _ := schema["nullable"].(bool); nullable || len(schema) == 0 {
+			return nil
+		}
+		if _, ok := schema["anyOf"]; !ok {
+			return fmt.Errorf("%s: null is not allowed", path)
+		}
+	}
+
+	if schemas, ok := schema["anyOf"].([]interface{}); ok {
+		var errs []string
+		for _, item := range schemas {
+			err := v.validate(item.(object), value, path)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err.Error())
+		}
+
+		return fmt.Errorf("%s: matches none of the alternatives (%s)", path, strings.Join(errs, "; "))
+	}
+
+	if schemas, ok := schema["allOf"].([]interface{}); ok {
+		for _, item := range schemas {
+			if err := v.validate(item.(object), value, path); err != nil {
+				return err
+			}
+		}
+	}
+
+	if values, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, item := range values {
+			if reflect.DeepEqual(item, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", path, value, values)
+		}
+	}
+
+	kind, _ := schema["type"].(string)
+	switch kind {
+	case "":
+		return nil
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s: expected a string", path)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected a boolean", path)
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			return fmt.Errorf("%s: expected a number", path)
+		}
+	case "integer":
+		number, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s: expected an integer", path)
+		}
+		if _, err := number.Int64(); err != nil {
+			return fmt.Errorf("%s: %s is not an integer", path, number)
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected an array", path)
+		}
+
+		itemSchema, _ := schema["items"].(object)
+		for i, item := range items {
+			if err := v.validate(itemSchema, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "object":
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected an object", path)
+		}
+
+		return v.validateObject(schema, fields, path)
+	}
+
+	return nil
+}
+
+func (v validator) validateObject(schema object, fields map[string]interface{}, path string) error {
+	required, _ := schema["required"].([]interface{})
+	for _, name := range required {
+		if _, ok := fields[name.(string)]; !ok {
+			return fmt.Errorf("%s: missing property %q", path, name)
+		}
+	}
+
+	properties, _ := schema["properties"].(object)
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if property, ok := properties[name].(object); ok {
+			if err := v.validate(property, fields[name], path+"."+name); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				return fmt.Errorf("%s: unexpected property %q", path, name)
+			}
+		case object:
+			if err := v.validate(additional, fields[name], path+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateResponse checks a response of the operation at the route template
+// path against the document. Operations that don't describe a JSON body for
+// the status are only checked for the status itself.
+func ValidateResponse(document map[string]interface{}, method, path string, status int, body []byte) error {
+	pathItem, ok := document["paths"].(object)[path].(object)
+	if !ok {
+		return fmt.Errorf("%s is not documented", path)
+	}
+
+	op, ok := pathItem[strings.ToLower(method)].(object)
+	if !ok {
+		return fmt.Errorf("%s %s is not documented", method, path)
+	}
+
+	responses := op["responses"].(object)
+	resp, ok := responses[fmt.Sprint(status)].(object)
+	if !ok {
+		return fmt.Errorf("%s %s: status %d is not documented", method, path, status)
+	}
+
+	content, _ := resp["content"].(object)
+	media, ok := content[jsonMediaType].(object)
+	if !ok {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("%s %s: status %d: body is not JSON: %v", method, path, status, err)
+	}
+
+	v := validator{schemas: document["components"].(object)["schemas"].(object)}
+	if err := v.validate(media["schema"].(object), value, "body"); err != nil {
+		return fmt.Errorf("%s %s: status %d: %v", method, path, status, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package rpc
+
+import (
+	"tp-db-forum/internal/app/models"
+)
+
+func userToProto(user models.User) *User {
+	return &User{
+		Nickname: user.Nickname,
+		Fullname: user.FullName,
+		About:    user.About,
+		Email:    user.Email,
+	}
+}
+
+func userFromProto(user *User) models.User {
+	return models.User{
+		Nickname: user.GetNickname(),
+		FullName: user.GetFullname(),
+		About:    user.GetAbout(),
+		Email:    user.GetEmail(),
+	}
+}
+
+func forumToProto(forum models.Forum) *ForumInfo {
+	return &ForumInfo{
+		Slug:    forum.Slug,
+		Title:   forum.Title,
+		User:    forum.User,
+		Posts:   int64(forum.Posts),
+		Threads: int64(forum.Threads),
+	}
+}
+
+func threadToProto(thread models.Thread) *Thread {
+	result := &Thread{
+		Id:      int32(thread.Id),
+		Slug:    thread.Slug,
+		Forum:   thread.Forum,
+		Author:  thread.Author,
+		Title:   thread.Title,
+		Message: thread.Message,
+		Created: thread.Created,
+		Votes:   int32(thread.Votes),
+	}
+	if models.IsUUID(thread.Slug) {
+		result.Slug = ""
+	}
+
+	return result
+}
+
+func threadFromProto(thread *Thread) models.Thread {
+	return models.Thread{
+		Slug:    thread.GetSlug(),
+		Forum:   thread.GetForum(),
+		Author:  thread.GetAuthor(),
+		Title:   thread.GetTitle(),
+		Message: thread.GetMessage(),
+		Created: thread.GetCreated(),
+	}
+}
+
+func postToProto(post models.Post) *Post {
+	return &Post{
+		Id:       int64(post.Id),
+		Parent:   post.Parent.Int64,
+		Author:   post.Author,
+		Message:  post.Message,
+		Forum:    post.Forum,
+		Thread:   int32(post.Thread),
+		Created:  post.Created,
+		IsEdited: post.IsEdited,
+	}
+}
+
+func postFromProto(post *Post) models.Post {
+	result := models.Post{
+		Author:  post.GetAuthor(),
+		Message: post.GetMessage(),
+	}
+	if post.GetParent() != 0 {
+		result.Parent.Valid = true
+		result.Parent.Int64 = post.GetParent()
+	}
+
+	return result
+}
+
+func postsToProto(posts []models.Post) *Posts {
+	result := &Posts{Posts: make([]*Post, 0, len(posts))}
+	for _, post := range posts {
+		result.Posts = append(result.Posts, postToProto(post))
+	}
+
+	return result
+}
@@ -0,0 +1,705 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package rpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// ForumClient is the client API for Forum service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ForumClient interface {
+	CreateUser(ctx context.Context, in *User, opts ...grpc.CallOption) (*User, error)
+	GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*User, error)
+	UpdateUser(ctx context.Context, in *User, opts ...grpc.CallOption) (*User, error)
+	CreateForum(ctx context.Context, in *ForumInfo, opts ...grpc.CallOption) (*ForumInfo, error)
+	GetForum(ctx context.Context, in *GetForumRequest, opts ...grpc.CallOption) (*ForumInfo, error)
+	ListForumThreads(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*Threads, error)
+	ListForumUsers(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*Users, error)
+	CreateThread(ctx context.Context, in *Thread, opts ...grpc.CallOption) (*Thread, error)
+	GetThread(ctx context.Context, in *GetThreadRequest, opts ...grpc.CallOption) (*Thread, error)
+	UpdateThread(ctx context.Context, in *UpdateThreadRequest, opts ...grpc.CallOption) (*Thread, error)
+	Vote(ctx context.Context, in *VoteRequest, opts ...grpc.CallOption) (*Thread, error)
+	CreatePosts(ctx context.Context, in *CreatePostsRequest, opts ...grpc.CallOption) (*Posts, error)
+	// BulkCreatePosts accepts any number of batches on one stream and answers
+	// each of them with the created posts, in the order they were sent.
+	BulkCreatePosts(ctx context.Context, opts ...grpc.CallOption) (Forum_BulkCreatePostsClient, error)
+	GetPost(ctx context.Context, in *GetPostRequest, opts ...grpc.CallOption) (*Post, error)
+	UpdatePost(ctx context.Context, in *UpdatePostRequest, opts ...grpc.CallOption) (*Post, error)
+	ListThreadPosts(ctx context.Context, in *ListPostsRequest, opts ...grpc.CallOption) (Forum_ListThreadPostsClient, error)
+}
+
+type forumClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewForumClient(cc grpc.ClientConnInterface) ForumClient {
+	return &forumClient{cc}
+}
+
+func (c *forumClient) CreateUser(ctx context.Context, in *User, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := c.cc.Invoke(ctx, "/forum.Forum/CreateUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumClient) GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := c.cc.Invoke(ctx, "/forum.Forum/GetUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumClient) UpdateUser(ctx context.Context, in *User, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := c.cc.Invoke(ctx, "/forum.Forum/UpdateUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumClient) CreateForum(ctx context.Context, in *ForumInfo, opts ...grpc.CallOption) (*ForumInfo, error) {
+	out := new(ForumInfo)
+	err := c.cc.Invoke(ctx, "/forum.Forum/CreateForum", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumClient) GetForum(ctx context.Context, in *GetForumRequest, opts ...grpc.CallOption) (*ForumInfo, error) {
+	out := new(ForumInfo)
+	err := c.cc.Invoke(ctx, "/forum.Forum/GetForum", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumClient) ListForumThreads(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*Threads, error) {
+	out := new(Threads)
+	err := c.cc.Invoke(ctx, "/forum.Forum/ListForumThreads", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumClient) ListForumUsers(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*Users, error) {
+	out := new(Users)
+	err := c.cc.Invoke(ctx, "/forum.Forum/ListForumUsers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumClient) CreateThread(ctx context.Context, in *Thread, opts ...grpc.CallOption) (*Thread, error) {
+	out := new(Thread)
+	err := c.cc.Invoke(ctx, "/forum.Forum/CreateThread", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumClient) GetThread(ctx context.Context, in *GetThreadRequest, opts ...grpc.CallOption) (*Thread, error) {
+	out := new(Thread)
+	err := c.cc.Invoke(ctx, "/forum.Forum/GetThread", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumClient) UpdateThread(ctx context.Context, in *UpdateThreadRequest, opts ...grpc.CallOption) (*Thread, error) {
+	out := new(Thread)
+	err := c.cc.Invoke(ctx, "/forum.Forum/UpdateThread", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumClient) Vote(ctx context.Context, in *VoteRequest, opts ...grpc.CallOption) (*Thread, error) {
+	out := new(Thread)
+	err := c.cc.Invoke(ctx, "/forum.Forum/Vote", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumClient) CreatePosts(ctx context.Context, in *CreatePostsRequest, opts ...grpc.CallOption) (*Posts, error) {
+	out := new(Posts)
+	err := c.cc.Invoke(ctx, "/forum.Forum/CreatePosts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumClient) BulkCreatePosts(ctx context.Context, opts ...grpc.CallOption) (Forum_BulkCreatePostsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Forum_ServiceDesc.Streams[0], "/forum.Forum/BulkCreatePosts", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &forumBulkCreatePostsClient{stream}
+	return x, nil
+}
+
+type Forum_BulkCreatePostsClient interface {
+	Send(*CreatePostsRequest) error
+	Recv() (*Posts, error)
+	grpc.ClientStream
+}
+
+type forumBulkCreatePostsClient struct {
+	grpc.ClientStream
+}
+
+func (x *forumBulkCreatePostsClient) Send(m *CreatePostsRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *forumBulkCreatePostsClient) Recv() (*Posts, error) {
+	m := new(Posts)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *forumClient) GetPost(ctx context.Context, in *GetPostRequest, opts ...grpc.CallOption) (*Post, error) {
+	out := new(Post)
+	err := c.cc.Invoke(ctx, "/forum.Forum/GetPost", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumClient) UpdatePost(ctx context.Context, in *UpdatePostRequest, opts ...grpc.CallOption) (*Post, error) {
+	out := new(Post)
+	err := c.cc.Invoke(ctx, "/forum.Forum/UpdatePost", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumClient) ListThreadPosts(ctx context.Context, in *ListPostsRequest, opts ...grpc.CallOption) (Forum_ListThreadPostsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Forum_ServiceDesc.Streams[1], "/forum.Forum/ListThreadPosts", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &forumListThreadPostsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Forum_ListThreadPostsClient interface {
+	Recv() (*Post, error)
+	grpc.ClientStream
+}
+
+type forumListThreadPostsClient struct {
+	grpc.ClientStream
+}
+
+func (x *forumListThreadPostsClient) Recv() (*Post, error) {
+	m := new(Post)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ForumServer is the server API for Forum service.
+// All implementations must embed UnimplementedForumServer
+// for forward compatibility
+type ForumServer interface {
+	CreateUser(context.Context, *User) (*User, error)
+	GetUser(context.Context, *GetUserRequest) (*User, error)
+	UpdateUser(context.Context, *User) (*User, error)
+	CreateForum(context.Context, *ForumInfo) (*ForumInfo, error)
+	GetForum(context.Context, *GetForumRequest) (*ForumInfo, error)
+	ListForumThreads(context.Context, *ListRequest) (*Threads, error)
+	ListForumUsers(context.Context, *ListRequest) (*Users, error)
+	CreateThread(context.Context, *Thread) (*Thread, error)
+	GetThread(context.Context, *GetThreadRequest) (*Thread, error)
+	UpdateThread(context.Context, *UpdateThreadRequest) (*Thread, error)
+	Vote(context.Context, *VoteRequest) (*Thread, error)
+	CreatePosts(context.Context, *CreatePostsRequest) (*Posts, error)
+	// BulkCreatePosts accepts any number of batches on one stream and answers
+	// each of them with the created posts, in the order they were sent.
+	BulkCreatePosts(Forum_BulkCreatePostsServer) error
+	GetPost(context.Context, *GetPostRequest) (*Post, error)
+	UpdatePost(context.Context, *UpdatePostRequest) (*Post, error)
+	ListThreadPosts(*ListPostsRequest, Forum_ListThreadPostsServer) error
+	mustEmbedUnimplementedForumServer()
+}
+
+// UnimplementedForumServer must be embedded to have forward compatible implementations.
+type UnimplementedForumServer struct {
+}
+
+func (UnimplementedForumServer) CreateUser(context.Context, *User) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateUser not implemented")
+}
+func (UnimplementedForumServer) GetUser(context.Context, *GetUserRequest) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUser not implemented")
+}
+func (UnimplementedForumServer) UpdateUser(context.Context, *User) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateUser not implemented")
+}
+func (UnimplementedForumServer) CreateForum(context.Context, *ForumInfo) (*ForumInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateForum not implemented")
+}
+func (UnimplementedForumServer) GetForum(context.Context, *GetForumRequest) (*ForumInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetForum not implemented")
+}
+func (UnimplementedForumServer) ListForumThreads(context.Context, *ListRequest) (*Threads, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListForumThreads not implemented")
+}
+func (UnimplementedForumServer) ListForumUsers(context.Context, *ListRequest) (*Users, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListForumUsers not implemented")
+}
+func (UnimplementedForumServer) CreateThread(context.Context, *Thread) (*Thread, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateThread not implemented")
+}
+func (UnimplementedForumServer) GetThread(context.Context, *GetThreadRequest) (*Thread, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetThread not implemented")
+}
+func (UnimplementedForumServer) UpdateThread(context.Context, *UpdateThreadRequest) (*Thread, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateThread not implemented")
+}
+func (UnimplementedForumServer) Vote(context.Context, *VoteRequest) (*Thread, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Vote not implemented")
+}
+func (UnimplementedForumServer) CreatePosts(context.Context, *CreatePostsRequest) (*Posts, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreatePosts not implemented")
+}
+func (UnimplementedForumServer) BulkCreatePosts(Forum_BulkCreatePostsServer) error {
+	return status.Errorf(codes.Unimplemented, "method BulkCreatePosts not implemented")
+}
+func (UnimplementedForumServer) GetPost(context.Context, *GetPostRequest) (*Post, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPost not implemented")
+}
+func (UnimplementedForumServer) UpdatePost(context.Context, *UpdatePostRequest) (*Post, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdatePost not implemented")
+}
+func (UnimplementedForumServer) ListThreadPosts(*ListPostsRequest, Forum_ListThreadPostsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListThreadPosts not implemented")
+}
+func (UnimplementedForumServer) mustEmbedUnimplementedForumServer() {}
+
+// UnsafeForumServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ForumServer will
+// result in compilation errors.
+type UnsafeForumServer interface {
+	mustEmbedUnimplementedForumServer()
+}
+
+func RegisterForumServer(s grpc.ServiceRegistrar, srv ForumServer) {
+	s.RegisterService(&Forum_ServiceDesc, srv)
+}
+
+func _Forum_CreateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(User)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServer).CreateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/forum.Forum/CreateUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServer).CreateUser(ctx, req.(*User))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forum_GetUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServer).GetUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/forum.Forum/GetUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServer).GetUser(ctx, req.(*GetUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forum_UpdateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(User)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServer).UpdateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/forum.Forum/UpdateUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServer).UpdateUser(ctx, req.(*User))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forum_CreateForum_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ForumInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServer).CreateForum(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/forum.Forum/CreateForum",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServer).CreateForum(ctx, req.(*ForumInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forum_GetForum_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetForumRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServer).GetForum(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/forum.Forum/GetForum",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServer).GetForum(ctx, req.(*GetForumRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forum_ListForumThreads_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServer).ListForumThreads(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/forum.Forum/ListForumThreads",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServer).ListForumThreads(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forum_ListForumUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServer).ListForumUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/forum.Forum/ListForumUsers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServer).ListForumUsers(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forum_CreateThread_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Thread)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServer).CreateThread(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/forum.Forum/CreateThread",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServer).CreateThread(ctx, req.(*Thread))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forum_GetThread_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetThreadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServer).GetThread(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/forum.Forum/GetThread",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServer).GetThread(ctx, req.(*GetThreadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forum_UpdateThread_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateThreadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServer).UpdateThread(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/forum.Forum/UpdateThread",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServer).UpdateThread(ctx, req.(*UpdateThreadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forum_Vote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServer).Vote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/forum.Forum/Vote",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServer).Vote(ctx, req.(*VoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forum_CreatePosts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePostsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServer).CreatePosts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/forum.Forum/CreatePosts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServer).CreatePosts(ctx, req.(*CreatePostsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forum_BulkCreatePosts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ForumServer).BulkCreatePosts(&forumBulkCreatePostsServer{stream})
+}
+
+type Forum_BulkCreatePostsServer interface {
+	Send(*Posts) error
+	Recv() (*CreatePostsRequest, error)
+	grpc.ServerStream
+}
+
+type forumBulkCreatePostsServer struct {
+	grpc.ServerStream
+}
+
+func (x *forumBulkCreatePostsServer) Send(m *Posts) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *forumBulkCreatePostsServer) Recv() (*CreatePostsRequest, error) {
+	m := new(CreatePostsRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Forum_GetPost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServer).GetPost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/forum.Forum/GetPost",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServer).GetPost(ctx, req.(*GetPostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forum_UpdatePost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdatePostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServer).UpdatePost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/forum.Forum/UpdatePost",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServer).UpdatePost(ctx, req.(*UpdatePostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Forum_ListThreadPosts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListPostsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ForumServer).ListThreadPosts(m, &forumListThreadPostsServer{stream})
+}
+
+type Forum_ListThreadPostsServer interface {
+	Send(*Post) error
+	grpc.ServerStream
+}
+
+type forumListThreadPostsServer struct {
+	grpc.ServerStream
+}
+
+func (x *forumListThreadPostsServer) Send(m *Post) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Forum_ServiceDesc is the grpc.ServiceDesc for Forum service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Forum_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "forum.Forum",
+	HandlerType: (*ForumServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateUser",
+			Handler:    _Forum_CreateUser_Handler,
+		},
+		{
+			MethodName: "GetUser",
+			Handler:    _Forum_GetUser_Handler,
+		},
+		{
+			MethodName: "UpdateUser",
+			Handler:    _Forum_UpdateUser_Handler,
+		},
+		{
+			MethodName: "CreateForum",
+			Handler:    _Forum_CreateForum_Handler,
+		},
+		{
+			MethodName: "GetForum",
+			Handler:    _Forum_GetForum_Handler,
+		},
+		{
+			MethodName: "ListForumThreads",
+			Handler:    _Forum_ListForumThreads_Handler,
+		},
+		{
+			MethodName: "ListForumUsers",
+			Handler:    _Forum_ListForumUsers_Handler,
+		},
+		{
+			MethodName: "CreateThread",
+			Handler:    _Forum_CreateThread_Handler,
+		},
+		{
+			MethodName: "GetThread",
+			Handler:    _Forum_GetThread_Handler,
+		},
+		{
+			MethodName: "UpdateThread",
+			Handler:    _Forum_UpdateThread_Handler,
+		},
+		{
+			MethodName: "Vote",
+			Handler:    _Forum_Vote_Handler,
+		},
+		{
+			MethodName: "CreatePosts",
+			Handler:    _Forum_CreatePosts_Handler,
+		},
+		{
+			MethodName: "GetPost",
+			Handler:    _Forum_GetPost_Handler,
+		},
+		{
+			MethodName: "UpdatePost",
+			Handler:    _Forum_UpdatePost_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "BulkCreatePosts",
+			Handler:       _Forum_BulkCreatePosts_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ListThreadPosts",
+			Handler:       _Forum_ListThreadPosts_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "forum.proto",
+}
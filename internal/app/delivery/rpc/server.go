@@ -0,0 +1,362 @@
+package rpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative forum.proto
+
+import (
+	"context"
+	"github.com/jackc/pgx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"strconv"
+	"tp-db-forum/internal/app"
+	"tp-db-forum/internal/app/events"
+	"tp-db-forum/internal/app/models"
+)
+
+type AppServer struct {
+	UnimplementedForumServer
+
+	appUseCase app.UseCase
+	broker     *events.Broker
+}
+
+func NewAppServer(server *grpc.Server, appUseCase app.UseCase, broker *events.Broker) {
+	RegisterForumServer(server, &AppServer{
+		appUseCase: appUseCase,
+		broker:     broker,
+	})
+}
+
+// statusError maps the errors the use cases surface to the closest gRPC code.
+func statusError(err error, message string) error {
+	if err == pgx.ErrNoRows {
+		return status.Error(codes.NotFound, message)
+	}
+
+	if err == models.ErrThreadLocked {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	if pgErr, ok := err.(pgx.PgError); ok {
+		switch pgErr.Code {
+		case "23505":
+			return status.Error(codes.AlreadyExists, message)
+		case "23503":
+			return status.Error(codes.NotFound, message)
+		case "00409":
+			return status.Error(codes.FailedPrecondition, "parent post was created in another thread")
+		}
+	}
+
+	return status.Error(codes.Internal, err.Error())
+}
+
+func (s *AppServer) threadBySlugOrId(slugOrId string) (models.Thread, error) {
+	id, err := strconv.Atoi(slugOrId)
+	if err != nil {
+		return s.appUseCase.CheckThreadBySlug(slugOrId)
+	}
+
+	return s.appUseCase.CheckThreadById(id)
+}
+
+func (s *AppServer) CreateUser(ctx context.Context, request *User) (*User, error) {
+	user, err := s.appUseCase.CreateUser(userFromProto(request))
+	if err != nil {
+		return nil, statusError(err, "user already exists")
+	}
+
+	return userToProto(user), nil
+}
+
+func (s *AppServer) GetUser(ctx context.Context, request *GetUserRequest) (*User, error) {
+	user, err := s.appUseCase.CheckUserByNickname(request.GetNickname())
+	if err != nil {
+		return nil, statusError(err, "can't find user")
+	}
+
+	return userToProto(user), nil
+}
+
+func (s *AppServer) UpdateUser(ctx context.Context, request *User) (*User, error) {
+	user, err := s.appUseCase.EditUser(userFromProto(request))
+	if err != nil {
+		if pgErr, ok := err.(pgx.PgError); ok && pgErr.Code == "23505" {
+			return nil, status.Error(codes.AlreadyExists, "conflict email")
+		}
+
+		return nil, statusError(err, "can't find user")
+	}
+
+	return userToProto(user), nil
+}
+
+func (s *AppServer) CreateForum(ctx context.Context, request *ForumInfo) (*ForumInfo, error) {
+	user, err := s.appUseCase.CheckUserByNickname(request.GetUser())
+	if err != nil {
+		return nil, statusError(err, "can't find user")
+	}
+
+	forum, err := s.appUseCase.CreateForum(models.Forum{
+		Slug:  request.GetSlug(),
+		Title: request.GetTitle(),
+		User:  user.Nickname,
+	})
+	if err != nil {
+		return nil, statusError(err, "forum already exists")
+	}
+
+	return forumToProto(forum), nil
+}
+
+func (s *AppServer) GetForum(ctx context.Context, request *GetForumRequest) (*ForumInfo, error) {
+	forum, err := s.appUseCase.CheckForumBySlug(request.GetSlug())
+	if err != nil {
+		return nil, statusError(err, "can't find forum")
+	}
+
+	return forumToProto(forum), nil
+}
+
+func (s *AppServer) ListForumThreads(ctx context.Context, request *ListRequest) (*Threads, error) {
+	forum, err := s.appUseCase.CheckForumBySlug(request.GetForum())
+	if err != nil {
+		return nil, statusError(err, "can't find forum")
+	}
+
+	threads, err := s.appUseCase.CheckThreadsByForum(forum.Slug, listParameters(request))
+	if err != nil {
+		return nil, statusError(err, "can't find forum")
+	}
+
+	result := &Threads{Threads: make([]*Thread, 0, len(threads))}
+	for _, thread := range threads {
+		result.Threads = append(result.Threads, threadToProto(thread))
+	}
+
+	return result, nil
+}
+
+func (s *AppServer) ListForumUsers(ctx context.Context, request *ListRequest) (*Users, error) {
+	forum, err := s.appUseCase.CheckForumBySlug(request.GetForum())
+	if err != nil {
+		return nil, statusError(err, "can't find forum")
+	}
+
+	users, err := s.appUseCase.CheckUsersByForum(forum.Slug, listParameters(request))
+	if err != nil {
+		return nil, statusError(err, "can't find forum")
+	}
+
+	result := &Users{Users: make([]*User, 0, len(users))}
+	for _, user := range users {
+		result.Users = append(result.Users, userToProto(user))
+	}
+
+	return result, nil
+}
+
+func (s *AppServer) CreateThread(ctx context.Context, request *Thread) (*Thread, error) {
+	forum, err := s.appUseCase.CheckForumBySlug(request.GetForum())
+	if err != nil {
+		return nil, statusError(err, "can't find forum")
+	}
+
+	thread := threadFromProto(request)
+	thread.Forum = forum.Slug
+
+	thread, err = s.appUseCase.CreateForumThread(thread)
+	if err != nil {
+		return nil, statusError(err, "thread already exists")
+	}
+	thread.Forum = forum.Slug
+
+	s.broker.Publish(forum.Slug, "thread", thread)
+
+	return threadToProto(thread), nil
+}
+
+func (s *AppServer) GetThread(ctx context.Context, request *GetThreadRequest) (*Thread, error) {
+	thread, err := s.threadBySlugOrId(request.GetSlugOrId())
+	if err != nil {
+		return nil, statusError(err, "can't find thread")
+	}
+
+	return threadToProto(thread), nil
+}
+
+func (s *AppServer) UpdateThread(ctx context.Context, request *UpdateThreadRequest) (*Thread, error) {
+	thread, err := s.threadBySlugOrId(request.GetSlugOrId())
+	if err != nil {
+		return nil, statusError(err, "can't find thread")
+	}
+
+	thread, err = s.appUseCase.EditThread(models.Thread{
+		Id:      thread.Id,
+		Title:   request.GetTitle(),
+		Message: request.GetMessage(),
+	})
+	if err != nil {
+		return nil, statusError(err, "can't find thread")
+	}
+
+	return threadToProto(thread), nil
+}
+
+func (s *AppServer) Vote(ctx context.Context, request *VoteRequest) (*Thread, error) {
+	thread, err := s.threadBySlugOrId(request.GetThread())
+	if err != nil {
+		return nil, statusError(err, "can't find thread")
+	}
+
+	vote := models.Vote{
+		Nickname: request.GetNickname(),
+		Voice:    int(request.GetVoice()),
+		IdThread: thread.Id,
+	}
+
+	_, err = s.appUseCase.AddVote(vote)
+	if pgErr, ok := err.(pgx.PgError); ok && pgErr.Code == "23505" {
+		_, err = s.appUseCase.UpdateVote(vote)
+	}
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "can't find user")
+	}
+
+	thread, err = s.appUseCase.CheckThreadById(thread.Id)
+	if err != nil {
+		return nil, statusError(err, "can't find thread")
+	}
+
+	s.broker.Publish(thread.Forum, "vote", map[string]interface{}{
+		"thread":   thread.Id,
+		"nickname": vote.Nickname,
+		"voice":    vote.Voice,
+		"votes":    thread.Votes,
+	})
+
+	return threadToProto(thread), nil
+}
+
+func (s *AppServer) createPosts(request *CreatePostsRequest) (*Posts, error) {
+	thread, err := s.threadBySlugOrId(request.GetThread())
+	if err != nil {
+		return nil, statusError(err, "can't find thread")
+	}
+
+	if len(request.GetPosts()) == 0 {
+		return &Posts{}, nil
+	}
+
+	posts := make([]models.Post, 0, len(request.GetPosts()))
+	for _, post := range request.GetPosts() {
+		posts = append(posts, postFromProto(post))
+	}
+
+	result, err := s.appUseCase.CreatePosts(posts, thread.Id)
+	if err == nil && len(result) == 0 {
+		err = pgx.ErrNoRows
+	}
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			if _, err := s.appUseCase.CheckUserByNickname(posts[0].Author); err == pgx.ErrNoRows {
+				return nil, status.Error(codes.NotFound, "can't find user")
+			}
+
+			return nil, status.Error(codes.FailedPrecondition, "conflict")
+		}
+
+		return nil, statusError(err, "can't find user")
+	}
+
+	s.broker.Publish(result[0].Forum, "posts", result)
+
+	return postsToProto(result), nil
+}
+
+func (s *AppServer) CreatePosts(ctx context.Context, request *CreatePostsRequest) (*Posts, error) {
+	return s.createPosts(request)
+}
+
+func (s *AppServer) BulkCreatePosts(stream Forum_BulkCreatePostsServer) error {
+	for {
+		request, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		posts, err := s.createPosts(request)
+		if err != nil {
+			return err
+		}
+
+		if err := stream.Send(posts); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *AppServer) GetPost(ctx context.Context, request *GetPostRequest) (*Post, error) {
+	data, err := s.appUseCase.CheckPostById(int(request.GetId()), nil)
+	if err != nil {
+		return nil, statusError(err, "can't find post")
+	}
+
+	return postToProto(data["post"].(models.Post)), nil
+}
+
+func (s *AppServer) UpdatePost(ctx context.Context, request *UpdatePostRequest) (*Post, error) {
+	post, err := s.appUseCase.EditPost(int(request.GetId()), request.GetMessage(), 0)
+	if err != nil {
+		return nil, statusError(err, "can't find post")
+	}
+
+	return postToProto(post), nil
+}
+
+func (s *AppServer) ListThreadPosts(request *ListPostsRequest, stream Forum_ListThreadPostsServer) error {
+	thread, err := s.threadBySlugOrId(request.GetThread())
+	if err != nil {
+		return statusError(err, "can't find thread")
+	}
+
+	limit := int(request.GetLimit())
+	if limit <= 0 {
+		limit = 100
+	}
+
+	sort := request.GetSort()
+	if sort == "" {
+		sort = "flat"
+	}
+
+	sent := false
+	err = s.appUseCase.StreamPostsByThread(thread, limit, int(request.GetSince()), sort, request.GetDesc(), func(post models.Post) error {
+		sent = true
+
+		return stream.Send(postToProto(post))
+	})
+	if err != nil && !sent {
+		return statusError(err, "can't find thread")
+	}
+
+	return err
+}
+
+func listParameters(request *ListRequest) models.QueryParameters {
+	limit := int(request.GetLimit())
+	if limit <= 0 {
+		limit = 100
+	}
+
+	return models.QueryParameters{
+		Limit: limit,
+		Since: request.GetSince(),
+		Desc:  request.GetDesc(),
+	}
+}
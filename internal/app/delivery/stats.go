@@ -0,0 +1,120 @@
+package delivery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"tp-db-forum/internal/app/models"
+)
+
+func statsOptions(request *http.Request) (models.StatsOptions, error) {
+	query := request.URL.Query()
+	options := models.StatsOptions{Bucket: query.Get("bucket")}
+
+	var err error
+	if value := query.Get("limit"); value != "" {
+		if options.Limit, err = strconv.Atoi(value); err != nil || options.Limit < 1 {
+			return options, fmt.Errorf("%w: limit must be a positive integer", models.ErrInvalidStatsOptions)
+		}
+	}
+
+	for name, t := range map[string]*time.Time{"since": &options.Since, "until": &options.Until} {
+		if value := query.Get(name); value != "" {
+			if *t, err = time.Parse(time.RFC3339, value); err != nil {
+				return options, fmt.Errorf("%w: %s must be an RFC 3339 time", models.ErrInvalidStatsOptions, name)
+			}
+		}
+	}
+
+	return options, nil
+}
+
+func (h AppHandler) ForumStats(writer http.ResponseWriter, request *http.Request) {
+	slug := strings.TrimSuffix(strings.TrimPrefix(request.URL.Path, "/api/forum/"), "/stats")
+
+	options, err := statsOptions(request)
+	var stats models.ForumStats
+	if err == nil {
+		stats, err = h.appUseCase.CheckForumStats(slug, options)
+	}
+	if err != nil {
+		status, message := http.StatusInternalServerError, "can't load forum stats"
+		switch {
+		case errors.Is(err, models.ErrInvalidStatsOptions):
+			status, message = http.StatusBadRequest, err.Error()
+		case err == pgx.ErrNoRows:
+			status, message = http.StatusNotFound, "Can't find forum"
+		}
+
+		body, err := errorMarshal(message)
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(status)
+		writer.Write(body)
+
+		return
+	}
+
+	body, err := json.Marshal(stats)
+	if err != nil {
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+	writer.Write(body)
+}
+
+// Leaderboard serves both the leaderboard of a forum and the global one.
+func (h AppHandler) Leaderboard(writer http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	options := models.LeaderboardOptions{Window: query.Get("window")}
+	if strings.HasPrefix(request.URL.Path, "/api/forum/") {
+		options.Forum = strings.TrimSuffix(strings.TrimPrefix(request.URL.Path, "/api/forum/"), "/leaderboard")
+	}
+
+	var err error
+	if value := query.Get("limit"); value != "" {
+		if options.Limit, err = strconv.Atoi(value); err != nil || options.Limit < 1 {
+			err = fmt.Errorf("%w: limit must be a positive integer", models.ErrInvalidLeaderboardOptions)
+		}
+	}
+
+	var leaderboard models.Leaderboard
+	if err == nil {
+		leaderboard, err = h.appUseCase.CheckLeaderboard(options)
+	}
+	if err != nil {
+		status, message := http.StatusInternalServerError, "can't load leaderboard"
+		switch {
+		case errors.Is(err, models.ErrInvalidLeaderboardOptions):
+			status, message = http.StatusBadRequest, err.Error()
+		case err == pgx.ErrNoRows:
+			status, message = http.StatusNotFound, "Can't find forum"
+		}
+
+		body, err := errorMarshal(message)
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(status)
+		writer.Write(body)
+
+		return
+	}
+
+	body, err := json.Marshal(leaderboard)
+	if err != nil {
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+	writer.Write(body)
+}
@@ -0,0 +1,355 @@
+package delivery
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"tp-db-forum/internal/app/models"
+)
+
+const (
+	ndjsonMediaType = "application/x-ndjson"
+	csvMediaType    = "text/csv"
+
+	// maxListLimit caps listings asked for without a limit or with a bigger
+	// one; X-Result-Limit tells the client it was applied.
+	maxListLimit = 100000
+	// Listings that may be longer than streamThreshold are written as the
+	// rows are read instead of being marshalled at once.
+	streamThreshold  = 1000
+	streamFlushEvery = 100
+)
+
+type listFormat int
+
+const (
+	formatJSON listFormat = iota
+	formatNDJSON
+	formatCSV
+)
+
+func requestedFormat(request *http.Request) listFormat {
+	accept := request.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, csvMediaType):
+		return formatCSV
+	case strings.Contains(accept, ndjsonMediaType):
+		return formatNDJSON
+	}
+
+	return formatJSON
+}
+
+// shouldStream reports whether a listing is written with a listStream:
+// always for the export formats, and for JSON arrays when the client asked
+// for more than streamThreshold items. A listing without a limit is paged
+// like any other, so it keeps its Link header and ETag. Cursor pages and
+// envelopes need the whole page for their links, so they are only ever JSON.
+func shouldStream(request *http.Request, format listFormat, requested int, cursor *models.Cursor) bool {
+	if cursor != nil {
+		return false
+	}
+
+	return format != formatJSON || (!wantsEnvelope(request) && requested > streamThreshold)
+}
+
+// checkCursorFormat answers 400 to a cursor page asked for as NDJSON or CSV,
+// which can't carry the links to the next one.
+func checkCursorFormat(writer http.ResponseWriter, format listFormat, cursor *models.Cursor) bool {
+	if cursor == nil || format == formatJSON {
+		return true
+	}
+
+	body, err := errorMarshal("cursor pages are only served as JSON")
+	if err != nil {
+		return false
+	}
+
+	writer.WriteHeader(http.StatusBadRequest)
+	writer.Write(body)
+
+	return false
+}
+
+// listLimit clamps the limit query parameter to maxListLimit.
+func listLimit(writer http.ResponseWriter, limit int) int {
+	if limit > 0 && limit <= maxListLimit {
+		return limit
+	}
+
+	writer.Header().Set("X-Result-Limit", strconv.Itoa(maxListLimit))
+
+	return maxListLimit
+}
+
+// fieldIndexes maps the JSON names of a model's fields to their indexes.
+var fieldIndexes sync.Map
+
+func jsonFields(t reflect.Type) map[string]int {
+	if fields, ok := fieldIndexes.Load(t); ok {
+		return fields.(map[string]int)
+	}
+
+	fields := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+	fieldIndexes.Store(t, fields)
+
+	return fields
+}
+
+// listColumns reads the fields parameter: the comma separated JSON names
+// of the model's fields to export, in order. All of them, in declaration
+// order, when it's absent.
+func listColumns(request *http.Request, model interface{}) ([]string, error) {
+	t := reflect.TypeOf(model)
+	fields := jsonFields(t)
+
+	parameter := request.URL.Query().Get("fields")
+	if parameter == "" {
+		columns := make([]string, 0, len(fields))
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+			if _, ok := fields[name]; ok {
+				columns = append(columns, name)
+			}
+		}
+
+		return columns, nil
+	}
+
+	columns := strings.Split(parameter, ",")
+	for i, column := range columns {
+		columns[i] = strings.TrimSpace(column)
+		if _, ok := fields[columns[i]]; !ok {
+			return nil, fmt.Errorf("unknown field %q", columns[i])
+		}
+	}
+
+	return columns, nil
+}
+
+// cell formats a field for CSV. Timestamps are already strfmt.DateTime
+// strings, so they come out exactly as in the JSON.
+func cell(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case models.JsonNullInt:
+		if !v.Valid {
+			return ""
+		}
+
+		return strconv.FormatInt(v.Int64, 10)
+	}
+
+	return fmt.Sprint(value)
+}
+
+// startListStream sets up a listStream for the requested format, answering
+// 400 when the fields parameter names a field model doesn't have.
+func startListStream(writer http.ResponseWriter, request *http.Request, format listFormat, model interface{}) (*listStream, bool) {
+	var columns []string
+	if format == formatCSV || (format == formatNDJSON && request.URL.Query().Get("fields") != "") {
+		var err error
+		columns, err = listColumns(request, model)
+		if err != nil {
+			body, err := errorMarshal(err.Error())
+			if err != nil {
+				return nil, false
+			}
+
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.Write(body)
+
+			return nil, false
+		}
+	}
+
+	return newListStream(writer, format, columns), true
+}
+
+// listStream writes a listing item by item as a JSON array, NDJSON or CSV.
+// Nothing is sent before the first item, so the handler can still answer
+// with an error status when there is none.
+type listStream struct {
+	writer  http.ResponseWriter
+	flusher http.Flusher
+	format  listFormat
+	columns []string
+	csv     *csv.Writer
+	count   int
+}
+
+// newListStream starts a listing. columns selects the NDJSON and CSV
+// fields; nil means the full JSON representation.
+func newListStream(writer http.ResponseWriter, format listFormat, columns []string) *listStream {
+	flusher, _ := writer.(http.Flusher)
+
+	stream := &listStream{
+		writer:  writer,
+		flusher: flusher,
+		format:  format,
+		columns: columns,
+	}
+	if format == formatCSV {
+		stream.csv = csv.NewWriter(writer)
+	}
+
+	return stream
+}
+
+func (s *listStream) begin() error {
+	switch s.format {
+	case formatNDJSON:
+		s.writer.Header().Set("Content-Type", ndjsonMediaType)
+	case formatCSV:
+		s.writer.Header().Set("Content-Type", csvMediaType+"; charset=utf-8")
+	}
+
+	s.writer.WriteHeader(http.StatusOK)
+
+	switch s.format {
+	case formatJSON:
+		_, err := s.writer.Write([]byte("["))
+
+		return err
+	case formatCSV:
+		return s.csv.Write(s.columns)
+	}
+
+	return nil
+}
+
+// selected returns the columns of item, leaving out the ones its type
+// doesn't have, like the slug of a thread that has none.
+func (s *listStream) selected(item interface{}) ([]string, []interface{}) {
+	value := reflect.ValueOf(item)
+	fields := jsonFields(value.Type())
+
+	names := make([]string, 0, len(s.columns))
+	values := make([]interface{}, 0, len(s.columns))
+	for _, column := range s.columns {
+		if i, ok := fields[column]; ok {
+			names = append(names, column)
+			values = append(values, value.Field(i).Interface())
+		}
+	}
+
+	return names, values
+}
+
+func (s *listStream) encode(item interface{}) ([]byte, error) {
+	if s.columns == nil {
+		return json.Marshal(item)
+	}
+
+	names, values := s.selected(item)
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		value, err := json.Marshal(values[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if i != 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.Quote(name))
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+
+	return []byte(b.String()), nil
+}
+
+func (s *listStream) Write(item interface{}) error {
+	if s.count == 0 {
+		if err := s.begin(); err != nil {
+			return err
+		}
+	}
+
+	switch s.format {
+	case formatCSV:
+		value := reflect.ValueOf(item)
+		fields := jsonFields(value.Type())
+
+		record := make([]string, len(s.columns))
+		for i, column := range s.columns {
+			if index, ok := fields[column]; ok {
+				record[i] = cell(value.Field(index).Interface())
+			}
+		}
+
+		if err := s.csv.Write(record); err != nil {
+			return err
+		}
+	default:
+		body, err := s.encode(item)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case s.format == formatNDJSON:
+			body = append(body, '\n')
+		case s.count != 0:
+			body = append([]byte(","), body...)
+		}
+
+		if _, err := s.writer.Write(body); err != nil {
+			return err
+		}
+	}
+
+	s.count++
+	if s.count%streamFlushEvery == 0 {
+		s.flush()
+	}
+
+	return nil
+}
+
+func (s *listStream) flush() {
+	if s.csv != nil {
+		s.csv.Flush()
+	}
+
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+}
+
+// Close ends the listing, writing an empty one if no item was written.
+func (s *listStream) Close() {
+	if s.count == 0 {
+		if s.begin() != nil {
+			return
+		}
+	}
+
+	if s.format == formatJSON {
+		s.writer.Write([]byte("]"))
+	}
+
+	if s.csv != nil {
+		s.csv.Flush()
+	}
+}
@@ -0,0 +1,181 @@
+package delivery
+
+import (
+	"encoding/json"
+	"github.com/jackc/pgx"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"tp-db-forum/internal/app/models"
+)
+
+func (h AppHandler) CreateWebhook(writer http.ResponseWriter, request *http.Request) {
+	var webhook models.Webhook
+	err := json.NewDecoder(request.Body).Decode(&webhook)
+	if err != nil {
+		return
+	}
+
+	u, err := url.Parse(webhook.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		body, err := errorMarshal("url must be an absolute http(s) url")
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write(body)
+
+		return
+	}
+
+	result, err := h.appUseCase.CreateWebhook(webhook)
+	if err != nil {
+		body, err := errorMarshal("can't create webhook")
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(http.StatusInternalServerError)
+		writer.Write(body)
+
+		return
+	}
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+
+	writer.WriteHeader(http.StatusCreated)
+	writer.Write(body)
+}
+
+func (h AppHandler) Webhooks(writer http.ResponseWriter, request *http.Request) {
+	webhooks, err := h.appUseCase.CheckWebhooks()
+	if err != nil {
+		body, err := errorMarshal("can't load webhooks")
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(http.StatusInternalServerError)
+		writer.Write(body)
+
+		return
+	}
+
+	body, err := json.Marshal(webhooks)
+	if err != nil {
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+	writer.Write(body)
+}
+
+func (h AppHandler) DeleteWebhook(writer http.ResponseWriter, request *http.Request) {
+	id, err := strconv.Atoi(strings.TrimPrefix(request.URL.Path, "/api/admin/webhooks/"))
+	if err != nil {
+		return
+	}
+
+	err = h.appUseCase.RemoveWebhook(id)
+	if err != nil {
+		body, err := errorMarshal("can't find webhook")
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(http.StatusNotFound)
+		writer.Write(body)
+
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (h AppHandler) DeadDeliveries(writer http.ResponseWriter, request *http.Request) {
+	limit, err := strconv.Atoi(request.URL.Query().Get("limit"))
+	if err != nil {
+		limit = 100
+	}
+
+	deliveries, err := h.appUseCase.CheckDeadDeliveries(limit)
+	if err != nil {
+		body, err := errorMarshal("can't load deliveries")
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(http.StatusInternalServerError)
+		writer.Write(body)
+
+		return
+	}
+
+	body, err := json.Marshal(deliveries)
+	if err != nil {
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+	writer.Write(body)
+}
+
+func (h AppHandler) ReplayDelivery(writer http.ResponseWriter, request *http.Request) {
+	id, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(request.URL.Path, "/api/admin/webhooks/deliveries/"), "/replay"), 10, 64)
+	if err != nil {
+		return
+	}
+
+	err = h.appUseCase.ReplayDelivery(id)
+	if err != nil {
+		body, err := errorMarshal("can't find delivery")
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(http.StatusNotFound)
+		writer.Write(body)
+
+		return
+	}
+
+	writer.WriteHeader(http.StatusAccepted)
+}
+
+func (h AppHandler) ReplayEvent(writer http.ResponseWriter, request *http.Request) {
+	id, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(request.URL.Path, "/api/admin/outbox/"), "/replay"), 10, 64)
+	if err != nil {
+		return
+	}
+
+	deliveries, err := h.appUseCase.ReplayEvent(id)
+	if err != nil {
+		status, message := http.StatusInternalServerError, "can't replay event"
+		if err == pgx.ErrNoRows {
+			status, message = http.StatusNotFound, "can't find event"
+		}
+
+		body, err := errorMarshal(message)
+		if err != nil {
+			return
+		}
+
+		writer.WriteHeader(status)
+		writer.Write(body)
+
+		return
+	}
+
+	body, err := json.Marshal(map[string]int{"deliveries": deliveries})
+	if err != nil {
+		return
+	}
+
+	writer.WriteHeader(http.StatusAccepted)
+	writer.Write(body)
+}
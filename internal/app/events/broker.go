@@ -0,0 +1,157 @@
+package events
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Event struct {
+	Id    uint64
+	Type  string
+	Forum string
+	Data  []byte
+}
+
+// The log of a forum grows up to the broker's size before it wraps, and
+// is dropped once nobody listens and nothing was published for logIdle.
+type forumLog struct {
+	events      []Event
+	next        int
+	used        time.Time
+	subscribers map[chan Event]struct{}
+}
+
+const (
+	logIdle    = time.Hour
+	sweepEvery = time.Minute
+)
+
+// Broker keeps a bounded log of recent events per forum and fans new events
+// out to subscribers. Event ids start from the current unix time in
+// nanoseconds, so ids handed out before a restart stay smaller than new ones.
+type Broker struct {
+	mu      sync.Mutex
+	lastId  uint64
+	size    int
+	logs    map[string]*forumLog
+	sweptAt time.Time
+}
+
+func NewBroker(size int) *Broker {
+	return &Broker{
+		lastId:  uint64(time.Now().UnixNano()),
+		size:    size,
+		logs:    make(map[string]*forumLog),
+		sweptAt: time.Now(),
+	}
+}
+
+func (b *Broker) forumLog(forum string) *forumLog {
+	key := strings.ToLower(forum)
+	now := time.Now()
+
+	log, ok := b.logs[key]
+	if !ok {
+		b.sweep(now)
+
+		log = &forumLog{subscribers: make(map[chan Event]struct{})}
+		b.logs[key] = log
+	}
+	log.used = now
+
+	return log
+}
+
+// sweep drops the idle logs, at most once every sweepEvery.
+func (b *Broker) sweep(now time.Time) {
+	if now.Sub(b.sweptAt) < sweepEvery {
+		return
+	}
+	b.sweptAt = now
+
+	for key, log := range b.logs {
+		if len(log.subscribers) == 0 && now.Sub(log.used) > logIdle {
+			delete(b.logs, key)
+		}
+	}
+}
+
+func (l *forumLog) append(event Event, size int) {
+	if len(l.events) < size {
+		l.events = append(l.events, event)
+		return
+	}
+
+	l.events[l.next] = event
+	l.next = (l.next + 1) % size
+}
+
+func (b *Broker) Publish(forum, kind string, data interface{}) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastId++
+	event := Event{
+		Id:    b.lastId,
+		Type:  kind,
+		Forum: forum,
+		Data:  body,
+	}
+
+	log := b.forumLog(forum)
+	log.append(event, b.size)
+
+	for ch := range log.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// the subscriber can't keep up: drop it, it will reconnect
+			// with Last-Event-ID and catch up from the log
+			delete(log.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe returns the logged events of the forum newer than lastId and a
+// channel receiving the following ones. The channel is closed when the
+// subscriber falls behind; the returned function unsubscribes.
+func (b *Broker) Subscribe(forum string, lastId uint64) ([]Event, <-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	log := b.forumLog(forum)
+
+	var backlog []Event
+	if lastId != 0 {
+		for i := range log.events {
+			event := log.events[(log.next+i)%len(log.events)]
+			if event.Id > lastId {
+				backlog = append(backlog, event)
+			}
+		}
+	}
+
+	ch := make(chan Event, 64)
+	log.subscribers[ch] = struct{}{}
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := log.subscribers[ch]; ok {
+			delete(log.subscribers, ch)
+			close(ch)
+		}
+		log.used = time.Now()
+	}
+
+	return backlog, ch, unsubscribe
+}
@@ -0,0 +1,147 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+	"tp-db-forum/internal/app/models"
+)
+
+const (
+	KeyHeader      = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+
+	DefaultTTL = 24 * time.Hour
+	maxKeySize = 255
+)
+
+func fingerprint(request *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(request.Method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(request.URL.Path))
+	hash.Write([]byte{0})
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func writeError(writer http.ResponseWriter, status int, message string) {
+	body, err := json.Marshal(models.Error{Message: message})
+	if err != nil {
+		return
+	}
+
+	writer.WriteHeader(status)
+	writer.Write(body)
+}
+
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(p)
+
+	return r.ResponseWriter.Write(p)
+}
+
+// Middleware honours the Idempotency-Key header on the routes with the given
+// names. The first response for a key is stored for ttl; a retry with the
+// same key and body gets it replayed, a different request with the same key
+// gets 422 and a retry while the first request is still running gets 409.
+// Responses with a 5xx status aren't stored, so those can be retried.
+func Middleware(store Store, ttl time.Duration, routes ...string) mux.MiddlewareFunc {
+	names := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		names[route] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			key := request.Header.Get(KeyHeader)
+			route := mux.CurrentRoute(request)
+			if key == "" || route == nil || !names[route.GetName()] {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			if len(key) > maxKeySize {
+				writeError(writer, http.StatusBadRequest, "Idempotency-Key is too long")
+				return
+			}
+
+			body, err := ioutil.ReadAll(request.Body)
+			if err != nil {
+				writeError(writer, http.StatusBadRequest, "can't read request body")
+				return
+			}
+			request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+			requestFingerprint := fingerprint(request, body)
+
+			record, found, err := store.Begin(key, requestFingerprint, ttl)
+			if err != nil {
+				log.Printf("idempotency: %v", err)
+				writeError(writer, http.StatusInternalServerError, "can't check Idempotency-Key")
+				return
+			}
+
+			if found {
+				switch {
+				case record.Fingerprint != requestFingerprint:
+					writeError(writer, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+				case !record.Done:
+					writeError(writer, http.StatusConflict, "a request with this Idempotency-Key is still being processed")
+				default:
+					writer.Header().Set(ReplayedHeader, "true")
+					writer.WriteHeader(record.Status)
+					writer.Write(record.Body)
+				}
+
+				return
+			}
+
+			rec := &recorder{ResponseWriter: writer}
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					store.Release(key)
+					panic(recovered)
+				}
+
+				if rec.status == 0 {
+					rec.status = http.StatusOK
+				}
+
+				if rec.status >= http.StatusInternalServerError {
+					err = store.Release(key)
+				} else {
+					err = store.Complete(key, rec.status, rec.body.Bytes())
+				}
+				if err != nil {
+					log.Printf("idempotency: %v", err)
+				}
+			}()
+
+			next.ServeHTTP(rec, request)
+		})
+	}
+}
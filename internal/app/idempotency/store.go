@@ -0,0 +1,144 @@
+package idempotency
+
+import (
+	"github.com/jackc/pgx"
+	"sync"
+	"time"
+	"tp-db-forum/internal/app"
+	"tp-db-forum/internal/app/models"
+)
+
+// Store keeps Idempotency-Key records. Begin either reserves key for a new
+// request, or returns the record already stored under it.
+type Store interface {
+	Begin(key, fingerprint string, ttl time.Duration) (models.IdempotencyRecord, bool, error)
+	Complete(key string, status int, body []byte) error
+	Release(key string) error
+}
+
+type postgresStore struct {
+	repository app.Repository
+
+	mu        sync.Mutex
+	lastPrune time.Time
+}
+
+const pruneInterval = time.Minute
+
+func NewPostgresStore(repository app.Repository) Store {
+	return &postgresStore{
+		repository: repository,
+		lastPrune:  time.Now(),
+	}
+}
+
+func (s *postgresStore) Begin(key, fingerprint string, ttl time.Duration) (models.IdempotencyRecord, bool, error) {
+	s.prune()
+
+	for {
+		reserved, err := s.repository.InsertIdempotencyKey(key, fingerprint, ttl)
+		if err != nil {
+			return models.IdempotencyRecord{}, false, err
+		}
+		if reserved {
+			return models.IdempotencyRecord{Key: key, Fingerprint: fingerprint}, false, nil
+		}
+
+		record, err := s.repository.SelectIdempotencyKey(key)
+		if err == pgx.ErrNoRows {
+			// Released between the insert and the select; try again.
+			continue
+		}
+		if err != nil {
+			return models.IdempotencyRecord{}, false, err
+		}
+
+		return record, true, nil
+	}
+}
+
+func (s *postgresStore) Complete(key string, status int, body []byte) error {
+	return s.repository.CompleteIdempotencyKey(key, status, body)
+}
+
+func (s *postgresStore) Release(key string) error {
+	return s.repository.DeleteIdempotencyKey(key)
+}
+
+func (s *postgresStore) prune() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.lastPrune) < pruneInterval {
+		return
+	}
+	s.lastPrune = time.Now()
+
+	go s.repository.PruneIdempotencyKeys()
+}
+
+type memoryEntry struct {
+	record  models.IdempotencyRecord
+	expires time.Time
+}
+
+type memoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	lastPrune time.Time
+}
+
+// NewMemoryStore keeps the records in process, for a single instance or
+// when the table isn't wanted.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		entries:   make(map[string]*memoryEntry),
+		lastPrune: time.Now(),
+	}
+}
+
+func (s *memoryStore) Begin(key, fingerprint string, ttl time.Duration) (models.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastPrune) >= pruneInterval {
+		for k, entry := range s.entries {
+			if now.After(entry.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastPrune = now
+	}
+
+	if entry, ok := s.entries[key]; ok && now.Before(entry.expires) {
+		return entry.record, true, nil
+	}
+
+	record := models.IdempotencyRecord{Key: key, Fingerprint: fingerprint}
+	s.entries[key] = &memoryEntry{record: record, expires: now.Add(ttl)}
+
+	return record, false, nil
+}
+
+func (s *memoryStore) Complete(key string, status int, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok {
+		entry.record.Done = true
+		entry.record.Status = status
+		entry.record.Body = append([]byte(nil), body...)
+	}
+
+	return nil
+}
+
+func (s *memoryStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+
+	return nil
+}
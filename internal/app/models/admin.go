@@ -0,0 +1,54 @@
+package models
+
+import "errors"
+
+// Kinds of moderated content.
+const (
+	ModerationThread = "thread"
+	ModerationPost   = "post"
+)
+
+// Moderation is the moderation state of a thread or post. Deleted content
+// is replaced, not removed, so replies keep their place in the tree and a
+// restore brings the original back.
+type Moderation struct {
+	Locked  bool `json:"locked"`
+	Deleted bool `json:"deleted"`
+}
+
+var ErrThreadLocked = errors.New("thread is locked")
+
+// CounterDrift is a stored aggregate that doesn't match the rows it counts.
+type CounterDrift struct {
+	Table  string `json:"table"`
+	Key    string `json:"key"`
+	Column string `json:"column"`
+	Stored int64  `json:"stored"`
+	Actual int64  `json:"actual"`
+}
+
+type CounterReport struct {
+	Fixed  bool           `json:"fixed"`
+	Drifts []CounterDrift `json:"drifts"`
+}
+
+type Migration struct {
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+	Applied string `json:"applied,omitempty"`
+}
+
+// MembershipDrift is a users_forum row that's out of date with the profile
+// in users (stale), missing for someone who wrote in the forum (missing) or
+// left for someone who no longer has (extra).
+type MembershipDrift struct {
+	Forum    string `json:"forum"`
+	Nickname string `json:"nickname"`
+	Problem  string `json:"problem"`
+}
+
+type MembershipReport struct {
+	Forum  string            `json:"forum,omitempty"`
+	Fixed  bool              `json:"fixed"`
+	Drifts []MembershipDrift `json:"drifts"`
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/pgtype"
 )
@@ -16,6 +17,7 @@ type User struct {
 	Email    string `json:"email"`
 	FullName string `json:"fullname"`
 	Nickname string `json:"nickname"`
+	Version  int64  `json:"-"`
 }
 
 type Forum struct {
@@ -24,6 +26,7 @@ type Forum struct {
 	Slug    string `json:"slug"`
 	Posts   int    `json:"posts"`
 	Threads int    `json:"threads"`
+	Version int64  `json:"-"`
 }
 
 type Thread struct {
@@ -35,6 +38,7 @@ type Thread struct {
 	Message string `json:"message"`
 	Slug    string `json:"slug"`
 	Votes   int    `json:"votes"`
+	Version int64  `json:"-"`
 }
 
 type ThreadWithoutSlug struct {
@@ -45,6 +49,7 @@ type ThreadWithoutSlug struct {
 	Title   string `json:"title"`
 	Message string `json:"message"`
 	Votes   int    `json:"votes"`
+	Version int64  `json:"-"`
 }
 
 func ThreadToWithout(thread Thread) ThreadWithoutSlug {
@@ -56,6 +61,7 @@ func ThreadToWithout(thread Thread) ThreadWithoutSlug {
 		Title:   thread.Title,
 		Message: thread.Message,
 		Votes:   thread.Votes,
+		Version: thread.Version,
 	}
 }
 
@@ -69,6 +75,8 @@ type Post struct {
 	Parent   JsonNullInt 	  `json:"parent"`
 	Thread   int         	  `json:"thread"`
 	Path     pgtype.Int8Array `json:"-"`
+	Votes    int              `json:"votes"`
+	Version  int64            `json:"-"`
 }
 
 type JsonNullInt struct {
@@ -97,18 +105,24 @@ func (v *JsonNullInt) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// ErrVersionMismatch is returned by the conditional updates when the entity
+// changed since the version the client sent in If-Match.
+var ErrVersionMismatch = errors.New("version mismatch")
+
 var PostParentError = `insert or update on table "post" violates foreign key constraint "post_parent_fkey"`
 
 type Vote struct {
 	Nickname string `json:"nickname"`
 	Voice    int    `json:"voice"`
 	IdThread int    `json:"-"`
+	IdPost   int    `json:"-"`
 }
 
 type QueryParameters struct {
-	Limit int
-	Since string
-	Desc  bool
+	Limit  int
+	Since  string
+	Desc   bool
+	Cursor *Cursor
 }
 
 func IsUUID(value string) bool {
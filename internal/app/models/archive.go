@@ -0,0 +1,86 @@
+package models
+
+import "errors"
+
+// ArchiveVersion is the version of the forum archive format written by
+// export; import refuses archives with another one.
+const ArchiveVersion = 2
+
+// ArchiveInfo opens every archive.
+type ArchiveInfo struct {
+	Version  int    `json:"version"`
+	Forum    string `json:"forum"`
+	Exported string `json:"exported"`
+}
+
+// ArchiveTrailer closes every archive with the number of records of each
+// type, so that one cut short by a failed export isn't taken for a whole.
+type ArchiveTrailer struct {
+	Counts map[string]int `json:"counts"`
+}
+
+// ArchivePost is a post as archived: ids are the ones of the exporting
+// database, and path is kept to check the imported tree against it.
+type ArchivePost struct {
+	Id       int         `json:"id"`
+	Author   string      `json:"author"`
+	Created  string      `json:"created"`
+	Message  string      `json:"message"`
+	IsEdited bool        `json:"isEdited"`
+	Parent   JsonNullInt `json:"parent"`
+	Thread   int         `json:"thread"`
+	Path     []int64     `json:"path"`
+}
+
+type ArchiveVote struct {
+	Nickname string `json:"nickname"`
+	Voice    int    `json:"voice"`
+	Thread   int    `json:"thread"`
+}
+
+type ArchivePostVote struct {
+	Nickname string `json:"nickname"`
+	Voice    int    `json:"voice"`
+	Post     int    `json:"post"`
+}
+
+// Conflict policies of an import.
+const (
+	ConflictFail      = "fail"
+	ConflictSkip      = "skip"
+	ConflictOverwrite = "overwrite"
+	ConflictRename    = "rename"
+)
+
+type ImportOptions struct {
+	// Slug imports the forum under another slug.
+	Slug string
+	// Users is what to do with users that already exist: skip keeps them
+	// as they are, overwrite replaces their profile, fail aborts.
+	Users string
+	// Slugs is what to do with forum and thread slugs that are taken:
+	// rename appends a number, fail aborts.
+	Slugs  string
+	DryRun bool
+}
+
+type ImportReport struct {
+	DryRun       bool              `json:"dry_run"`
+	Forum        string            `json:"forum"`
+	UsersCreated int               `json:"users_created"`
+	UsersUpdated int               `json:"users_updated"`
+	UsersSkipped int               `json:"users_skipped"`
+	Threads      int               `json:"threads"`
+	Posts        int               `json:"posts"`
+	Votes        int               `json:"votes"`
+	PostVotes    int               `json:"post_votes"`
+	ThreadIds    map[int]int       `json:"thread_ids"`
+	Renamed      map[string]string `json:"renamed"`
+	Conflicts    []string          `json:"conflicts"`
+}
+
+var (
+	ErrInvalidArchive       = errors.New("invalid archive")
+	ErrArchiveConflict      = errors.New("archive conflicts with existing data")
+	ErrInvalidImportOptions = errors.New("invalid import options")
+)
@@ -0,0 +1,8 @@
+package models
+
+// BatchResult answers a batch read: the entities found, in the order their
+// keys were sent, and the keys nothing was found for.
+type BatchResult struct {
+	Items   []interface{} `json:"items"`
+	Missing interface{}   `json:"missing"`
+}
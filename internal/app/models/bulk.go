@@ -0,0 +1,39 @@
+package models
+
+import (
+	"errors"
+	"io"
+)
+
+// BulkSources are the CSV files of a bulk load, each with a header row and
+// these columns; any of them may be nil.
+//
+//	users:      nickname, fullname, about, email
+//	forums:     slug, title, user
+//	threads:    id, slug, author, forum, title, message, created
+//	posts:      id, parent, thread, author, message, created, isEdited
+//	votes:      nickname, thread, voice
+//	post_votes: nickname, post, voice
+//
+// Thread and post ids only link the rows of the files together; the loaded
+// rows get new ones. A post without a parent, or with parent 0, is a root.
+type BulkSources struct {
+	Users     io.Reader
+	Forums    io.Reader
+	Threads   io.Reader
+	Posts     io.Reader
+	Votes     io.Reader
+	PostVotes io.Reader
+}
+
+type BulkReport struct {
+	Users        int `json:"users"`
+	UsersSkipped int `json:"users_skipped"`
+	Forums       int `json:"forums"`
+	Threads      int `json:"threads"`
+	Posts        int `json:"posts"`
+	Votes        int `json:"votes"`
+	PostVotes    int `json:"post_votes"`
+}
+
+var ErrBulkIntegrity = errors.New("bulk load failed integrity checks")
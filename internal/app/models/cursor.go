@@ -0,0 +1,105 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the position of a list item in its sort order. Clients only see
+// it as an opaque token; Prev marks a token pointing backwards from the
+// first item of a page.
+type Cursor struct {
+	Sort     string  `json:"s"`
+	Desc     bool    `json:"d,omitempty"`
+	Prev     bool    `json:"p,omitempty"`
+	Created  string  `json:"c,omitempty"`
+	Id       int     `json:"i,omitempty"`
+	Nickname string  `json:"n,omitempty"`
+	Path     []int64 `json:"a,omitempty"`
+}
+
+type Page struct {
+	Next    string `json:"next,omitempty"`
+	Prev    string `json:"prev,omitempty"`
+	HasMore bool   `json:"has_more"`
+}
+
+func (c Cursor) Encode() string {
+	body, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString(body)
+}
+
+func DecodeCursor(token string) (Cursor, error) {
+	body, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	err = json.Unmarshal(body, &cursor)
+	if err != nil || cursor.Sort == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+func ThreadCursor(thread Thread, desc, prev bool) Cursor {
+	return Cursor{Sort: "created", Desc: desc, Prev: prev, Created: thread.Created, Id: thread.Id}
+}
+
+func UserCursor(user User, desc, prev bool) Cursor {
+	return Cursor{Sort: "nickname", Desc: desc, Prev: prev, Nickname: user.Nickname}
+}
+
+func PostCursor(post Post, sort string, desc, prev bool) Cursor {
+	cursor := Cursor{Sort: sort, Desc: desc, Prev: prev, Id: post.Id}
+	if sort == "tree" || sort == "parent_tree" {
+		for _, element := range post.Path.Elements {
+			cursor.Path = append(cursor.Path, element.Int)
+		}
+	}
+
+	return cursor
+}
+
+// NewPage builds the cursors of a page of count items. fromCursor tells
+// whether the page was requested with a cursor, prev whether that cursor
+// pointed backwards, and hasMore whether items remain beyond the page in the
+// requested direction.
+func NewPage(count int, fromCursor, prev, hasMore bool, first, last func(prev bool) Cursor) Page {
+	page := Page{HasMore: hasMore}
+	if count == 0 {
+		return page
+	}
+
+	moreAfter, moreBefore := hasMore, fromCursor
+	if prev {
+		moreAfter, moreBefore = true, hasMore
+	}
+
+	if moreAfter {
+		page.Next = last(false).Encode()
+	}
+	if moreBefore {
+		page.Prev = first(true).Encode()
+	}
+
+	return page
+}
+
+type PageEnvelope struct {
+	Items          interface{} `json:"items"`
+	HasMore        bool        `json:"has_more"`
+	Next           string      `json:"next,omitempty"`
+	Prev           string      `json:"prev,omitempty"`
+	Total          *int        `json:"total,omitempty"`
+	TotalEstimated bool        `json:"total_estimated,omitempty"`
+}
@@ -0,0 +1,11 @@
+package models
+
+// IdempotencyRecord is the state of an Idempotency-Key: reserved by the
+// first request carrying it, then holding that request's response.
+type IdempotencyRecord struct {
+	Key         string
+	Fingerprint string
+	Done        bool
+	Status      int
+	Body        []byte
+}
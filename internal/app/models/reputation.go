@@ -0,0 +1,82 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidLeaderboardOptions = errors.New("invalid leaderboard options")
+
+// UserProfile is a user with their reputation: the sum of the votes on
+// what they wrote, across every forum.
+type UserProfile struct {
+	User
+	Reputation int64 `json:"reputation"`
+}
+
+// Windows of a leaderboard, counting the votes cast in the last day, week,
+// month or year, or all of them.
+const (
+	WindowAll   = "all"
+	WindowDay   = "day"
+	WindowWeek  = "week"
+	WindowMonth = "month"
+	WindowYear  = "year"
+)
+
+type LeaderboardOptions struct {
+	Forum  string
+	Window string
+	Limit  int
+}
+
+// Normalize fills in the defaults and checks the window and limit.
+func (o LeaderboardOptions) Normalize() (LeaderboardOptions, error) {
+	if o.Window == "" {
+		o.Window = WindowAll
+	}
+	if _, ok := o.Since(time.Now()); !ok && o.Window != WindowAll {
+		return o, fmt.Errorf("%w: window must be all, day, week, month or year", ErrInvalidLeaderboardOptions)
+	}
+
+	if o.Limit == 0 {
+		o.Limit = DefaultStatsLimit
+	}
+	if o.Limit < 0 || o.Limit > MaxStatsLimit {
+		return o, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidLeaderboardOptions, MaxStatsLimit)
+	}
+
+	return o, nil
+}
+
+// Since returns the start of the window, with ok false for WindowAll or an
+// unknown window.
+func (o LeaderboardOptions) Since(now time.Time) (time.Time, bool) {
+	switch o.Window {
+	case WindowDay:
+		return now.AddDate(0, 0, -1), true
+	case WindowWeek:
+		return now.AddDate(0, 0, -7), true
+	case WindowMonth:
+		return now.AddDate(0, -1, 0), true
+	case WindowYear:
+		return now.AddDate(-1, 0, 0), true
+	}
+
+	return time.Time{}, false
+}
+
+// Leaderboard ranks users by reputation in a forum, or in all of them when
+// Forum is empty. Windowed ones count the votes by when they were first
+// cast, and leave out the ones cast before that was recorded.
+type Leaderboard struct {
+	Forum   string             `json:"forum,omitempty"`
+	Window  string             `json:"window"`
+	Entries []LeaderboardEntry `json:"entries"`
+}
+
+type LeaderboardEntry struct {
+	Nickname   string `json:"nickname"`
+	Reputation int64  `json:"reputation"`
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidStatsOptions = errors.New("invalid stats options")
+
+// Sizes of the buckets of ForumStats.Activity.
+const (
+	BucketHour = "hour"
+	BucketDay  = "day"
+	BucketWeek = "week"
+)
+
+const (
+	DefaultStatsBuckets = 30
+	MaxStatsBuckets     = 1000
+	DefaultStatsLimit   = 10
+	MaxStatsLimit       = 100
+)
+
+// StatsOptions select the window of ForumStats: content created from Since
+// up to, not including, Until, which are whole buckets apart.
+type StatsOptions struct {
+	Bucket string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
+
+// TruncateBucket returns the start of the bucket t is in. Weeks start on
+// Monday, as date_trunc has them.
+func TruncateBucket(t time.Time, bucket string) time.Time {
+	t = t.UTC()
+	switch bucket {
+	case BucketHour:
+		return t.Truncate(time.Hour)
+	case BucketWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	}
+
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// NextBucket returns the start of the bucket after the one starting at t.
+func NextBucket(t time.Time, bucket string) time.Time {
+	switch bucket {
+	case BucketHour:
+		return t.Add(time.Hour)
+	case BucketWeek:
+		return t.AddDate(0, 0, 7)
+	}
+
+	return t.AddDate(0, 0, 1)
+}
+
+// Normalize fills in the defaults, the DefaultStatsBuckets buckets up to
+// the end of the current one, and widens Since and Until to whole buckets.
+func (o StatsOptions) Normalize(now time.Time) (StatsOptions, error) {
+	if o.Bucket == "" {
+		o.Bucket = BucketDay
+	}
+	if o.Bucket != BucketHour && o.Bucket != BucketDay && o.Bucket != BucketWeek {
+		return o, fmt.Errorf("%w: bucket must be hour, day or week", ErrInvalidStatsOptions)
+	}
+
+	if o.Limit == 0 {
+		o.Limit = DefaultStatsLimit
+	}
+	if o.Limit < 0 || o.Limit > MaxStatsLimit {
+		return o, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidStatsOptions, MaxStatsLimit)
+	}
+
+	if o.Until.IsZero() {
+		o.Until = now
+	}
+	if until := TruncateBucket(o.Until, o.Bucket); !until.Equal(o.Until) {
+		o.Until = NextBucket(until, o.Bucket)
+	}
+
+	if o.Since.IsZero() {
+		o.Since = o.Until
+		for i := 0; i < DefaultStatsBuckets; i++ {
+			o.Since = TruncateBucket(o.Since.Add(-time.Nanosecond), o.Bucket)
+		}
+	}
+	o.Since = TruncateBucket(o.Since, o.Bucket)
+
+	if !o.Since.Before(o.Until) {
+		return o, fmt.Errorf("%w: since must be before until", ErrInvalidStatsOptions)
+	}
+
+	buckets := 0
+	for start := o.Since; start.Before(o.Until); start = NextBucket(start, o.Bucket) {
+		if buckets++; buckets > MaxStatsBuckets {
+			return o, fmt.Errorf("%w: more than %d buckets", ErrInvalidStatsOptions, MaxStatsBuckets)
+		}
+	}
+
+	return o, nil
+}
+
+// ForumStats is the activity of a forum in the window of its options.
+// Active users wrote a thread or post or voted in the bucket; votes count
+// from when they were cast, so ones from before that was recorded are left
+// out. Depth 0 is a post that replies to the thread itself.
+type ForumStats struct {
+	Forum          string           `json:"forum"`
+	Bucket         string           `json:"bucket"`
+	Since          string           `json:"since"`
+	Until          string           `json:"until"`
+	Activity       []ActivityBucket `json:"activity"`
+	TopPosters     []TopPoster      `json:"top_posters"`
+	BusiestThreads []BusyThread     `json:"busiest_threads"`
+	Depths         []DepthCount     `json:"depths"`
+	Generated      string           `json:"generated"`
+}
+
+type ActivityBucket struct {
+	Start   string `json:"start"`
+	Posts   int64  `json:"posts"`
+	Threads int64  `json:"threads"`
+	Users   int64  `json:"users"`
+	Votes   int64  `json:"votes"`
+}
+
+type TopPoster struct {
+	Nickname string `json:"nickname"`
+	Posts    int64  `json:"posts"`
+}
+
+type BusyThread struct {
+	Id    int    `json:"id"`
+	Slug  string `json:"slug,omitempty"`
+	Title string `json:"title"`
+	Posts int64  `json:"posts"`
+}
+
+type DepthCount struct {
+	Depth int   `json:"depth"`
+	Posts int64 `json:"posts"`
+}
@@ -0,0 +1,20 @@
+package models
+
+type PoolStats struct {
+	MaxConnections       int `json:"max_connections"`
+	CurrentConnections   int `json:"current_connections"`
+	AvailableConnections int `json:"available_connections"`
+}
+
+// ServiceInfo is the service status along with the state of the instance
+// answering: its version, how long it has been up, its connection pool and
+// the migration the database is at. Uptime is in seconds.
+type ServiceInfo struct {
+	Status    map[string]int `json:"status"`
+	Estimated bool           `json:"estimated"`
+	Version   string         `json:"version"`
+	Started   string         `json:"started"`
+	Uptime    int64          `json:"uptime"`
+	Migration int            `json:"migration"`
+	Pool      PoolStats      `json:"pool"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "encoding/json"
+
+type Webhook struct {
+	Id      int      `json:"id"`
+	Url     string   `json:"url"`
+	Secret  string   `json:"secret,omitempty"`
+	Topics  []string `json:"topics"`
+	Created string   `json:"created"`
+}
+
+type OutboxEvent struct {
+	Id      int64           `json:"id"`
+	Topic   string          `json:"topic"`
+	Created string          `json:"created"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type WebhookDelivery struct {
+	Id          int64       `json:"id"`
+	Webhook     int         `json:"webhook"`
+	Url         string      `json:"url"`
+	Secret      string      `json:"-"`
+	Status      string      `json:"status"`
+	Attempts    int         `json:"attempts"`
+	NextAttempt string      `json:"next_attempt"`
+	LastError   string      `json:"last_error"`
+	Event       OutboxEvent `json:"event"`
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
@@ -28,10 +28,10 @@ func (p *postgresAppRepository) InsertUser(user models.User) error {
 }
 
 func (p *postgresAppRepository) SelectUserByNickname(nickname string) (models.User, error) {
-	row := p.Conn.QueryRow(`SELECT nickname, fullname, about, email FROM users WHERE nickname=$1 LIMIT 1;`, nickname)
+	row := p.Conn.QueryRow(`SELECT nickname, fullname, about, email, xmin::text::bigint FROM users WHERE nickname=$1 LIMIT 1;`, nickname)
 
 	var user models.User
-	err := row.Scan(&user.Nickname, &user.FullName, &user.About, &user.Email)
+	err := row.Scan(&user.Nickname, &user.FullName, &user.About, &user.Email, &user.Version)
 	if err != nil {
 		return models.User{}, err
 	}
@@ -78,12 +78,14 @@ func (p *postgresAppRepository) UpdateUser(user models.User) (models.User, error
 	err := p.Conn.QueryRow(
 		`UPDATE users SET email=COALESCE(NULLIF($1, ''), email), 
 							  about=COALESCE(NULLIF($2, ''), about), 
-							  fullname=COALESCE(NULLIF($3, ''), fullname) WHERE nickname=$4 RETURNING *`,
+							  fullname=COALESCE(NULLIF($3, ''), fullname)
+							  WHERE nickname=$4 AND ($5 = 0 OR xmin = $5::text::xid) RETURNING *, xmin::text::bigint`,
 		user.Email,
 		user.About,
 		user.FullName,
 		user.Nickname,
-	).Scan(&newUser.Nickname, &newUser.FullName, &newUser.About, &newUser.Email)
+		user.Version,
+	).Scan(&newUser.Nickname, &newUser.FullName, &newUser.About, &newUser.Email, &newUser.Version)
 
 	return newUser, err
 }
@@ -103,13 +105,14 @@ func (p *postgresAppRepository) InsertForum(forum models.Forum) (models.Forum, e
 func (p *postgresAppRepository) SelectForumBySlug(slug string) (models.Forum, error) {
 	var forum models.Forum
 	err := p.Conn.QueryRow(
-		`SELECT * FROM forum WHERE slug=$1 LIMIT 1;`,
+		`SELECT *, xmin::text::bigint FROM forum WHERE slug=$1 LIMIT 1;`,
 		slug).Scan(
 		&forum.Slug,
 		&forum.Title,
 		&forum.User,
 		&forum.Posts,
 		&forum.Threads,
+		&forum.Version,
 	)
 
 	return forum, err
@@ -152,11 +155,11 @@ func (p *postgresAppRepository) InsertThread(thread models.Thread) (models.Threa
 }
 
 func (p *postgresAppRepository) SelectThreadBySlug(slug string) (models.Thread, error) {
-	row := p.Conn.QueryRow(`SELECT * FROM thread WHERE slug=$1 LIMIT 1;`, slug)
+	row := p.Conn.QueryRow(`SELECT *, xmin::text::bigint FROM thread WHERE slug=$1 LIMIT 1;`, slug)
 
 	var thread models.Thread
 	var created time.Time
-	err := row.Scan(&thread.Id, &thread.Author, &created, &thread.Forum, &thread.Message, &thread.Slug, &thread.Title, &thread.Votes)
+	err := row.Scan(&thread.Id, &thread.Author, &created, &thread.Forum, &thread.Message, &thread.Slug, &thread.Title, &thread.Votes, &thread.Version)
 
 	thread.Created = strfmt.DateTime(created.UTC()).String()
 
@@ -164,18 +167,18 @@ func (p *postgresAppRepository) SelectThreadBySlug(slug string) (models.Thread,
 }
 
 func (p *postgresAppRepository) SelectThreadById(id int) (models.Thread, error) {
-	row := p.Conn.QueryRow(`SELECT * FROM thread WHERE id=$1 LIMIT 1;`, id)
+	row := p.Conn.QueryRow(`SELECT *, xmin::text::bigint FROM thread WHERE id=$1 LIMIT 1;`, id)
 
 	var thread models.Thread
 	var created time.Time
-	err := row.Scan(&thread.Id, &thread.Author, &created, &thread.Forum, &thread.Message, &thread.Slug, &thread.Title, &thread.Votes)
+	err := row.Scan(&thread.Id, &thread.Author, &created, &thread.Forum, &thread.Message, &thread.Slug, &thread.Title, &thread.Votes, &thread.Version)
 
 	thread.Created = strfmt.DateTime(created.UTC()).String()
 
 	return thread, err
 }
 
-func (p *postgresAppRepository) selectForumSlugById(id int) (string, error) {
+func (p *postgresAppRepository) SelectForumSlugByThreadId(id int) (string, error) {
 	query := `SELECT forum FROM thread WHERE id=$1`
 
 	var slug string
@@ -183,18 +186,13 @@ func (p *postgresAppRepository) selectForumSlugById(id int) (string, error) {
 	return slug, err
 }
 
-func (p *postgresAppRepository) InsertPosts(posts []models.Post, thread int) ([]models.Post, error) {
+func (p *postgresAppRepository) InsertPosts(posts []models.Post, thread int, forum string) ([]models.Post, error) {
 	resultPosts := make([]models.Post, 0, 0)
 
 	if len(posts) == 0 {
 		return resultPosts, nil
 	}
 
-	forum, err := p.selectForumSlugById(thread)
-	if err != nil {
-		return nil, err
-	}
-
 	insert := `INSERT INTO post(author, created, forum, message, parent, thread) VALUES `
 	var values []interface{}
 	timeCreated := time.Now()
@@ -214,7 +212,7 @@ func (p *postgresAppRepository) InsertPosts(posts []models.Post, thread int) ([]
 
 	rows, err := p.Conn.Query(insert, values...)
 	if err != nil {
-		return nil, err
+		return nil, postsError(err)
 	}
 
 	defer rows.Close()
@@ -234,6 +232,7 @@ func (p *postgresAppRepository) InsertPosts(posts []models.Post, thread int) ([]
 			&currentPost.Parent,
 			&currentPost.Thread,
 			&currentPost.Path,
+			&currentPost.Votes,
 		)
 		if err != nil {
 			return nil, err
@@ -246,20 +245,34 @@ func (p *postgresAppRepository) InsertPosts(posts []models.Post, thread int) ([]
 		}
 		resultPosts = append(resultPosts, currentPost)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, postsError(err)
+	}
 
 	return resultPosts, nil
 }
 
+// postsError turns the error updatePath raises for a locked thread into
+// models.ErrThreadLocked.
+func postsError(err error) error {
+	if pgErr, ok := err.(pgx.PgError); ok && pgErr.Code == "00423" {
+		return models.ErrThreadLocked
+	}
+
+	return err
+}
+
 func (p *postgresAppRepository) UpdateThread(thread models.Thread) (models.Thread, error) {
-	query := `UPDATE thread SET title=COALESCE(NULLIF($1, ''), title), message=COALESCE(NULLIF($2, ''), message) WHERE %s RETURNING *`
+	query := `UPDATE thread SET title=COALESCE(NULLIF($1, ''), title), message=COALESCE(NULLIF($2, ''), message)
+			  WHERE %s AND ($4 = 0 OR xmin = $4::text::xid) RETURNING *, xmin::text::bigint`
 
 	var row *pgx.Row
 	if thread.Slug == "" {
 		query = fmt.Sprintf(query, `id=$3`)
-		row = p.Conn.QueryRow(query, thread.Title, thread.Message, thread.Id)
+		row = p.Conn.QueryRow(query, thread.Title, thread.Message, thread.Id, thread.Version)
 	} else {
 		query = fmt.Sprintf(query, `slug=$3`)
-		row = p.Conn.QueryRow(query, thread.Title, thread.Message, thread.Slug)
+		row = p.Conn.QueryRow(query, thread.Title, thread.Message, thread.Slug, thread.Version)
 	}
 
 	var newThread models.Thread
@@ -273,6 +286,7 @@ func (p *postgresAppRepository) UpdateThread(thread models.Thread) (models.Threa
 		&newThread.Slug,
 		&newThread.Title,
 		&newThread.Votes,
+		&newThread.Version,
 	)
 
 	if err != nil {
@@ -306,7 +320,66 @@ func (p *postgresAppRepository) UpdateVote(vote models.Vote) (models.Vote, error
 	return vote, err
 }
 
-func (p *postgresAppRepository) GetServiceStatus() (map[string]int, error) {
+func (p *postgresAppRepository) InsertPostVote(vote models.Vote) (models.Vote, error) {
+	_, err := p.Conn.Exec(
+		`INSERT INTO post_votes(nickname, voice, id_post) VALUES ($1, $2, $3)`,
+		vote.Nickname,
+		vote.Voice,
+		vote.IdPost,
+	)
+
+	return vote, err
+}
+
+func (p *postgresAppRepository) UpdatePostVote(vote models.Vote) (models.Vote, error) {
+	_, err := p.Conn.Exec(
+		`UPDATE post_votes SET voice=$1 WHERE id_post=$2 AND nickname=$3`,
+		vote.Voice,
+		vote.IdPost,
+		vote.Nickname,
+	)
+
+	return vote, err
+}
+
+// GetServiceStatus reads the row counts table_count keeps, or the planner's
+// estimates with estimate set. Before the table_count migration it counts
+// the rows instead.
+func (p *postgresAppRepository) GetServiceStatus(estimate bool) (map[string]int, error) {
+	query := `SELECT name, COALESCE(sum(n), 0) FROM table_count GROUP BY name`
+	if estimate {
+		query = `SELECT relname::text, GREATEST(reltuples, 0)::bigint FROM pg_class
+		WHERE oid IN ('forum'::regclass, 'post'::regclass, 'thread'::regclass, 'users'::regclass)`
+	}
+
+	info, err := p.Conn.Query(query)
+	if pgErr, ok := err.(pgx.PgError); ok && pgErr.Code == "42P01" {
+		return p.countRows()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	defer info.Close()
+
+	status := map[string]int{"forum": 0, "post": 0, "thread": 0, "user": 0}
+	for info.Next() {
+		var name string
+		var count int
+		if err := info.Scan(&name, &count); err != nil {
+			return nil, err
+		}
+
+		if name == "users" {
+			name = "user"
+		}
+		status[name] = count
+	}
+
+	return status, info.Err()
+}
+
+func (p *postgresAppRepository) countRows() (map[string]int, error) {
 	info, err := p.Conn.Query(
 		`SELECT * FROM (SELECT COUNT(*) FROM forum) as forumCount,
 		(SELECT COUNT(*) FROM post) as postCount,
@@ -338,13 +411,34 @@ func (p *postgresAppRepository) GetServiceStatus() (map[string]int, error) {
 	return nil, errors.New("have not information")
 }
 
+func (p *postgresAppRepository) SelectPoolStats() models.PoolStats {
+	stat := p.Conn.Stat()
+
+	return models.PoolStats{
+		MaxConnections:       stat.MaxConnections,
+		CurrentConnections:   stat.CurrentConnections,
+		AvailableConnections: stat.AvailableConnections,
+	}
+}
+
 func (p *postgresAppRepository) ClearDatabase() error {
-	_, err := p.Conn.Exec(`TRUNCATE users, thread, forum, post, votes, users_forum;`)
+	_, err := p.Conn.Exec(`TRUNCATE users, thread, forum, post, votes, post_votes, users_forum, outbox, webhook_delivery, idempotency_key, thread_lock, moderated, table_count, reputation;`)
 
 	return err
 }
 
 func (p *postgresAppRepository) SelectUsersByForum(slugForum string, parameters models.QueryParameters) ([]models.User, error) {
+	var data []models.User
+	err := p.StreamUsersByForum(slugForum, parameters, func(user models.User) error {
+		data = append(data, user)
+
+		return nil
+	})
+
+	return data, err
+}
+
+func (p *postgresAppRepository) StreamUsersByForum(slugForum string, parameters models.QueryParameters, each func(models.User) error) error {
 	var query string
 	if parameters.Desc {
 		if parameters.Since != "" {
@@ -379,11 +473,10 @@ func (p *postgresAppRepository) SelectUsersByForum(slugForum string, parameters
 			parameters.Since,
 		)
 	}
-	var data []models.User
 	row, err := p.Conn.Query(query, slugForum, parameters.Limit)
 
 	if err != nil {
-		return data, nil
+		return nil
 	}
 
 	defer row.Close()
@@ -395,51 +488,66 @@ func (p *postgresAppRepository) SelectUsersByForum(slugForum string, parameters
 		err = row.Scan(&u.About, &u.Email, &u.FullName, &u.Nickname)
 
 		if err != nil {
-			return data, err
+			return err
 		}
 
-		data = append(data, u)
+		if err := each(u); err != nil {
+			return err
+		}
 	}
 
-	return data, err
+	return row.Err()
 }
 
 func (p *postgresAppRepository) SelectThreadsByForum(slugForum string, parameters models.QueryParameters) ([]models.Thread, error) {
+	var threads []models.Thread
+	err := p.StreamThreadsByForum(slugForum, parameters, func(thread models.Thread) error {
+		threads = append(threads, thread)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return threads, nil
+}
+
+func (p *postgresAppRepository) StreamThreadsByForum(slugForum string, parameters models.QueryParameters, each func(models.Thread) error) error {
 	var rows *pgx.Rows
 	var err error
 	if parameters.Since != "" {
 		if parameters.Desc {
 			rows, err = p.Conn.Query(
 				`SELECT * FROM thread WHERE forum=$1 AND created <= $2 
-				ORDER BY created DESC LIMIT NULLIF($3, 0)`,
+				ORDER BY created DESC, id DESC LIMIT NULLIF($3, 0)`,
 				slugForum, parameters.Since, parameters.Limit)
 		} else {
 			rows, err = p.Conn.Query(
 				`SELECT * FROM thread WHERE forum=$1 AND created >= $2 
-				ORDER BY created ASC LIMIT NULLIF($3, 0)`,
+				ORDER BY created ASC, id ASC LIMIT NULLIF($3, 0)`,
 				slugForum, parameters.Since, parameters.Limit)
 		}
 	} else {
 		if parameters.Desc {
 			rows, err = p.Conn.Query(
 				`SELECT * FROM thread WHERE forum=$1
-				ORDER BY created DESC LIMIT NULLIF($2, 0)`,
+				ORDER BY created DESC, id DESC LIMIT NULLIF($2, 0)`,
 				slugForum, parameters.Limit)
 		} else {
 			rows, err = p.Conn.Query(
 				`SELECT * FROM thread WHERE forum=$1
-				ORDER BY created ASC LIMIT NULLIF($2, 0)`,
+				ORDER BY created ASC, id ASC LIMIT NULLIF($2, 0)`,
 				slugForum, parameters.Limit)
 		}
 	}
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	defer rows.Close()
 
-	var threads []models.Thread
 	for rows.Next() {
 		var thread models.Thread
 		var created time.Time
@@ -455,15 +563,17 @@ func (p *postgresAppRepository) SelectThreadsByForum(slugForum string, parameter
 			&thread.Votes,
 		)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		thread.Created = strfmt.DateTime(created.UTC()).String()
 
-		threads = append(threads, thread)
+		if err := each(thread); err != nil {
+			return err
+		}
 	}
 
-	return threads, err
+	return rows.Err()
 }
 
 func (p *postgresAppRepository) SelectPostById(id int) (models.Post, error) {
@@ -471,7 +581,7 @@ func (p *postgresAppRepository) SelectPostById(id int) (models.Post, error) {
 	var created time.Time
 
 	err := p.Conn.QueryRow(
-		`SELECT * FROM post WHERE id=$1 LIMIT 1;`,
+		`SELECT *, xmin::text::bigint FROM post WHERE id=$1 LIMIT 1;`,
 		id).Scan(
 		&post.Id,
 		&post.Author,
@@ -482,6 +592,8 @@ func (p *postgresAppRepository) SelectPostById(id int) (models.Post, error) {
 		&post.Parent,
 		&post.Thread,
 		&post.Path,
+		&post.Votes,
+		&post.Version,
 	)
 	if err != nil {
 		return models.Post{}, err
@@ -492,15 +604,16 @@ func (p *postgresAppRepository) SelectPostById(id int) (models.Post, error) {
 	return post, nil
 }
 
-func (p *postgresAppRepository) UpdatePost(id int, message string) (models.Post, error) {
+func (p *postgresAppRepository) UpdatePost(id int, message string, version int64) (models.Post, error) {
 	var post models.Post
 	var created time.Time
 	err := p.Conn.QueryRow(
 		`UPDATE post SET message=COALESCE(NULLIF($1, ''), message),
 							 isEdited = CASE WHEN $1 = '' OR message = $1 THEN isEdited ELSE true END
-							 WHERE id=$2 RETURNING *`,
+							 WHERE id=$2 AND ($3 = 0 OR xmin = $3::text::xid) RETURNING *, xmin::text::bigint`,
 		message,
 		id,
+		version,
 	).Scan(
 		&post.Id,
 		&post.Author,
@@ -511,6 +624,8 @@ func (p *postgresAppRepository) UpdatePost(id int, message string) (models.Post,
 		&post.Parent,
 		&post.Thread,
 		&post.Path,
+		&post.Votes,
+		&post.Version,
 	)
 
 	post.Created = strfmt.DateTime(created.UTC()).String()
@@ -528,11 +643,28 @@ func (p *postgresAppRepository) selectThreadIdBySlug(slug string) (int, error) {
 }
 
 func (p *postgresAppRepository) SelectPostsByThread(thread models.Thread, limit, since int, sort string, desc bool) ([]models.Post, error) {
+	var posts []models.Post
+	err := p.StreamPostsByThread(thread, limit, since, sort, desc, func(post models.Post) error {
+		posts = append(posts, post)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// StreamPostsByThread calls each for every post of the listing as it is
+// read, so the listing is never held in memory as a whole. It stops at the
+// first error each returns.
+func (p *postgresAppRepository) StreamPostsByThread(thread models.Thread, limit, since int, sort string, desc bool, each func(models.Post) error) error {
 	var threadId int
 	if thread.Id == 0 {
 		thr, err := p.SelectThreadIdBySlug(thread.Slug)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		threadId = thr
@@ -540,47 +672,26 @@ func (p *postgresAppRepository) SelectPostsByThread(thread models.Thread, limit,
 		threadId = thread.Id
 	}
 
+	var rows *pgx.Rows
+	var err error
 	switch sort {
 	case "flat":
-		posts, err := p.selectPostsByThreadFlat(threadId, limit, since, desc)
-
-		return posts, err
+		rows, err = p.queryPostsByThreadFlat(threadId, limit, since, desc)
 	case "tree":
-		posts, err := p.selectPostsByThreadTree(threadId, limit, since, desc)
-
-		return posts, err
+		rows, err = p.queryPostsByThreadTree(threadId, limit, since, desc)
 	case "parent_tree":
-		posts, err := p.selectPostsByThreadParentTree(threadId, limit, since, desc)
-
-		return posts, err
+		rows, err = p.queryPostsByThreadParentTree(threadId, limit, since, desc)
+	case "score_tree":
+		rows, err = p.queryPostsByThreadScoreTree(threadId, limit, since, desc)
 	default:
-		return nil, errors.New("u gay")
-	}
-}
-
-func (p *postgresAppRepository) selectPostsByThreadFlat(id, limit, since int, desc bool) ([]models.Post, error) {
-	var rows *pgx.Rows
-	var err error
-	if since == 0 {
-		if desc {
-			rows, err = p.Conn.Query(`SELECT * FROM post WHERE thread=$1 ORDER BY id DESC LIMIT NULLIF($2, 0)`, id, limit)
-		} else {
-			rows, err = p.Conn.Query(`SELECT * FROM post WHERE thread=$1 ORDER BY id ASC LIMIT NULLIF($2, 0)`, id, limit)
-		}
-	} else {
-		if desc {
-			rows, err = p.Conn.Query(`SELECT * FROM post WHERE thread=$1 AND id < $2 ORDER BY id DESC LIMIT NULLIF($3, 0)`, id, since, limit)
-		} else {
-			rows, err = p.Conn.Query(`SELECT * FROM post WHERE thread=$1 AND id > $2 ORDER BY id ASC LIMIT NULLIF($3, 0)`, id, since, limit)
-		}
+		return errors.New("u gay")
 	}
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	defer rows.Close()
 
-	var posts []models.Post
 	for rows.Next() {
 		var post models.Post
 		var created time.Time
@@ -595,20 +706,43 @@ func (p *postgresAppRepository) selectPostsByThreadFlat(id, limit, since int, de
 			&post.Parent,
 			&post.Thread,
 			&post.Path,
+			&post.Votes,
 		)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		post.Created = strfmt.DateTime(created.UTC()).String()
 
-		posts = append(posts, post)
+		if err := each(post); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (p *postgresAppRepository) queryPostsByThreadFlat(id, limit, since int, desc bool) (*pgx.Rows, error) {
+	var rows *pgx.Rows
+	var err error
+	if since == 0 {
+		if desc {
+			rows, err = p.Conn.Query(`SELECT * FROM post WHERE thread=$1 ORDER BY id DESC LIMIT NULLIF($2, 0)`, id, limit)
+		} else {
+			rows, err = p.Conn.Query(`SELECT * FROM post WHERE thread=$1 ORDER BY id ASC LIMIT NULLIF($2, 0)`, id, limit)
+		}
+	} else {
+		if desc {
+			rows, err = p.Conn.Query(`SELECT * FROM post WHERE thread=$1 AND id < $2 ORDER BY id DESC LIMIT NULLIF($3, 0)`, id, since, limit)
+		} else {
+			rows, err = p.Conn.Query(`SELECT * FROM post WHERE thread=$1 AND id > $2 ORDER BY id ASC LIMIT NULLIF($3, 0)`, id, since, limit)
+		}
 	}
 
-	return posts, err
+	return rows, err
 }
 
-func (p *postgresAppRepository) selectPostsByThreadTree(id, limit, since int, desc bool) ([]models.Post, error) {
+func (p *postgresAppRepository) queryPostsByThreadTree(id, limit, since int, desc bool) (*pgx.Rows, error) {
 	var rows *pgx.Rows
 	var err error
 
@@ -643,41 +777,11 @@ func (p *postgresAppRepository) selectPostsByThreadTree(id, limit, since int, de
 			)
 		}
 	}
-	if err != nil {
-		return nil, err
-	}
-
-	defer rows.Close()
-
-	var posts []models.Post
-	for rows.Next() {
-		var post models.Post
-		var created time.Time
 
-		err = rows.Scan(
-			&post.Id,
-			&post.Author,
-			&created,
-			&post.Forum,
-			&post.Message,
-			&post.IsEdited,
-			&post.Parent,
-			&post.Thread,
-			&post.Path,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		post.Created = strfmt.DateTime(created.UTC()).String()
-
-		posts = append(posts, post)
-	}
-
-	return posts, nil
+	return rows, err
 }
 
-func (p *postgresAppRepository) selectPostsByThreadParentTree(id, limit, since int, desc bool) ([]models.Post, error) {
+func (p *postgresAppRepository) queryPostsByThreadParentTree(id, limit, since int, desc bool) (*pgx.Rows, error) {
 	var rows *pgx.Rows
 	var err error
 
@@ -714,38 +818,50 @@ func (p *postgresAppRepository) selectPostsByThreadParentTree(id, limit, since i
 		}
 	}
 
-	if err != nil {
-		return nil, err
-	}
-
-	defer rows.Close()
-
-	var posts []models.Post
-	for rows.Next() {
-		var post models.Post
-		var created time.Time
-
-		err = rows.Scan(
-			&post.Id,
-			&post.Author,
-			&created,
-			&post.Forum,
-			&post.Message,
-			&post.IsEdited,
-			&post.Parent,
-			&post.Thread,
-			&post.Path,
-		)
-		if err != nil {
-			return nil, err
-		}
+	return rows, err
+}
 
-		post.Created = strfmt.DateTime(created.UTC()).String()
+// selectScoreTree walks the thread depth first like tree does, but orders
+// the root posts and the replies to each post by their votes, highest
+// first. key holds the votes, negated, and the id of every post on the
+// path, so a post sorts right before its replies. It takes the thread, the
+// post to list after or 0, and the limit; the comparison and the direction
+// are filled in.
+//
+// The key of the post to list after comes from its path. Only the roots a
+// page can reach are walked, one more than the limit counting the root of
+// that post, and of those only the branches that sort after it, so a page
+// costs the size of the subtrees it lists from rather than of the thread.
+const selectScoreTree = `WITH RECURSIVE since AS (
+		SELECT array_agg(k.value ORDER BY a.n, k.n) AS bound FROM post s
+		CROSS JOIN unnest(s.path) WITH ORDINALITY AS a(id, n)
+		JOIN post ON post.id = a.id
+		CROSS JOIN unnest(ARRAY[-post.votes::bigint, post.id]) WITH ORDINALITY AS k(value, n)
+		WHERE s.id = $2
+	), roots AS (
+		SELECT post.id, ARRAY[-post.votes::bigint, post.id] AS key FROM post, since
+		WHERE post.thread = $1 AND post.parent IS NULL
+		AND ($2 = 0 OR ARRAY[-post.votes::bigint, post.id] %[1]s= since.bound[1:2])
+		ORDER BY key %[2]s LIMIT NULLIF($3, 0) + 1
+	), tree AS (
+		SELECT post.*, roots.key FROM roots JOIN post ON post.id = roots.id
+		UNION ALL
+		SELECT post.*, tree.key || ARRAY[-post.votes::bigint, post.id] FROM tree
+		JOIN post ON post.parent = tree.id
+		CROSS JOIN since
+		WHERE post.thread = $1
+		AND ($2 = 0 OR tree.key %[1]s since.bound OR tree.key = since.bound[1:array_length(tree.key, 1)])
+	)
+	SELECT id, author, created, forum, message, isEdited, parent, thread, path, votes FROM tree, since
+	WHERE $2 = 0 OR tree.key %[1]s since.bound
+	ORDER BY tree.key %[2]s LIMIT NULLIF($3, 0)`
 
-		posts = append(posts, post)
+func (p *postgresAppRepository) queryPostsByThreadScoreTree(id, limit, since int, desc bool) (*pgx.Rows, error) {
+	if desc {
+		return p.Conn.Query(fmt.Sprintf(selectScoreTree, "<", "DESC"), id, since, limit)
 	}
 
-	return posts, nil
+	return p.Conn.Query(fmt.Sprintf(selectScoreTree, ">", "ASC"), id, since, limit)
 }
 
 func (p *postgresAppRepository) SelectThreadByForum(forum string) (models.Thread, error) {
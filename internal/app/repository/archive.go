@@ -0,0 +1,499 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-openapi/strfmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/pgtype"
+	"io"
+	"strconv"
+	"time"
+	"tp-db-forum/internal/app/models"
+)
+
+// ExportForum calls each with the users, the forum, threads, posts, thread
+// votes and post votes of a forum, in the order of an archive, all read from
+// one snapshot.
+func (p *postgresAppRepository) ExportForum(slug string, each func(item interface{}) error) error {
+	tx, err := p.Conn.BeginEx(context.Background(), &pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var forum models.Forum
+	err = tx.QueryRow(`SELECT slug, title, "user", posts, threads FROM forum WHERE slug=$1`, slug).Scan(
+		&forum.Slug,
+		&forum.Title,
+		&forum.User,
+		&forum.Posts,
+		&forum.Threads,
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(
+		`SELECT nickname, fullname, COALESCE(about, ''), COALESCE(email, '') FROM users
+		WHERE nickname IN (
+			SELECT "user" FROM forum WHERE slug = $1
+			UNION SELECT author FROM thread WHERE forum = $1
+			UNION SELECT author FROM post WHERE forum = $1
+			UNION SELECT v.nickname FROM votes v JOIN thread t ON t.id = v.id_thread WHERE t.forum = $1
+			UNION SELECT v.nickname FROM post_votes v JOIN post p ON p.id = v.id_post WHERE p.forum = $1
+		) ORDER BY nickname`,
+		forum.Slug,
+	)
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.Nickname, &user.FullName, &user.About, &user.Email); err != nil {
+			rows.Close()
+			return err
+		}
+
+		if err := each(user); err != nil {
+			rows.Close()
+			return err
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if err := each(forum); err != nil {
+		return err
+	}
+
+	rows, err = tx.Query(
+		`SELECT id, author, created, forum, message, COALESCE(slug, ''), title, votes FROM thread
+		WHERE forum = $1 ORDER BY id`,
+		forum.Slug,
+	)
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		var thread models.Thread
+		var created time.Time
+		err := rows.Scan(&thread.Id, &thread.Author, &created, &thread.Forum, &thread.Message, &thread.Slug, &thread.Title, &thread.Votes)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		thread.Created = strfmt.DateTime(created.UTC()).String()
+
+		if err := each(thread); err != nil {
+			rows.Close()
+			return err
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = tx.Query(
+		`SELECT id, author, created, message, isEdited, parent, thread, path FROM post
+		WHERE forum = $1 ORDER BY id`,
+		forum.Slug,
+	)
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		var post models.ArchivePost
+		var created time.Time
+		var path pgtype.Int8Array
+		err := rows.Scan(&post.Id, &post.Author, &created, &post.Message, &post.IsEdited, &post.Parent, &post.Thread, &path)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		post.Created = strfmt.DateTime(created.UTC()).String()
+		if post.Parent.Int64 == 0 {
+			post.Parent.Valid = false
+		}
+		for _, element := range path.Elements {
+			post.Path = append(post.Path, element.Int)
+		}
+
+		if err := each(post); err != nil {
+			rows.Close()
+			return err
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = tx.Query(
+		`SELECT v.nickname, v.voice, v.id_thread FROM votes v JOIN thread t ON t.id = v.id_thread
+		WHERE t.forum = $1 ORDER BY v.id_thread, v.nickname`,
+		forum.Slug,
+	)
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		var vote models.ArchiveVote
+		if err := rows.Scan(&vote.Nickname, &vote.Voice, &vote.Thread); err != nil {
+			rows.Close()
+			return err
+		}
+
+		if err := each(vote); err != nil {
+			rows.Close()
+			return err
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = tx.Query(
+		`SELECT v.nickname, v.voice, v.id_post FROM post_votes v JOIN post p ON p.id = v.id_post
+		WHERE p.forum = $1 ORDER BY v.id_post, v.nickname`,
+		forum.Slug,
+	)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var vote models.ArchivePostVote
+		if err := rows.Scan(&vote.Nickname, &vote.Voice, &vote.Post); err != nil {
+			return err
+		}
+
+		if err := each(vote); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// freeSlug returns slug, or slug with the lowest numeric suffix that isn't
+// taken in table.
+func freeSlug(tx *pgx.Tx, table, slug string) (string, error) {
+	candidate := slug
+	for i := 2; ; i++ {
+		var taken bool
+		err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM `+table+` WHERE slug = $1)`, candidate).Scan(&taken)
+		if err != nil {
+			return "", err
+		}
+
+		if !taken {
+			return candidate, nil
+		}
+
+		candidate = slug + "-" + strconv.Itoa(i)
+	}
+}
+
+type importer struct {
+	tx      *pgx.Tx
+	options models.ImportOptions
+	report  models.ImportReport
+	forum   string
+	posts   map[int]int64
+}
+
+// conflict records a conflict. With the fail policy it ends the import,
+// unless this is a dry run, which goes on to find the other ones.
+func (i *importer) conflict(policy, format string, args ...interface{}) error {
+	message := fmt.Sprintf(format, args...)
+	i.report.Conflicts = append(i.report.Conflicts, message)
+
+	if policy == models.ConflictFail && !i.options.DryRun {
+		return fmt.Errorf("%w: %s", models.ErrArchiveConflict, message)
+	}
+
+	return nil
+}
+
+func (i *importer) user(user models.User) error {
+	var exists bool
+	err := i.tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM users WHERE nickname = $1)`, user.Nickname).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		var owner string
+		err := i.tx.QueryRow(`SELECT nickname FROM users WHERE email = $1`, user.Email).Scan(&owner)
+		if err == nil {
+			return fmt.Errorf("%w: email of user %s belongs to %s", models.ErrArchiveConflict, user.Nickname, owner)
+		}
+		if err != pgx.ErrNoRows {
+			return err
+		}
+
+		_, err = i.tx.Exec(
+			`INSERT INTO users(nickname, fullname, about, email) VALUES ($1, $2, $3, NULLIF($4, ''))`,
+			user.Nickname, user.FullName, user.About, user.Email,
+		)
+		if err != nil {
+			return err
+		}
+
+		i.report.UsersCreated++
+
+		return nil
+	}
+
+	switch i.options.Users {
+	case models.ConflictOverwrite:
+		_, err := i.tx.Exec(
+			`UPDATE users SET fullname = $2, about = $3, email = NULLIF($4, '') WHERE nickname = $1`,
+			user.Nickname, user.FullName, user.About, user.Email,
+		)
+		if err != nil {
+			return err
+		}
+
+		i.report.UsersUpdated++
+	default:
+		i.report.UsersSkipped++
+		if err := i.conflict(i.options.Users, "user %s already exists", user.Nickname); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// slug finds the slug to import a forum or thread under, following the
+// slugs policy.
+func (i *importer) slug(table, slug string) (string, error) {
+	free, err := freeSlug(i.tx, table, slug)
+	if err != nil || free == slug {
+		return free, err
+	}
+
+	if err := i.conflict(i.options.Slugs, "%s slug %s is taken", table, slug); err != nil {
+		return "", err
+	}
+
+	i.report.Renamed[slug] = free
+
+	return free, nil
+}
+
+func (i *importer) forumRecord(forum models.Forum) error {
+	if i.forum != "" {
+		return fmt.Errorf("%w: more than one forum", models.ErrInvalidArchive)
+	}
+
+	slug := forum.Slug
+	if i.options.Slug != "" {
+		slug = i.options.Slug
+	}
+
+	slug, err := i.slug("forum", slug)
+	if err != nil {
+		return err
+	}
+
+	_, err = i.tx.Exec(`INSERT INTO forum(slug, title, "user") VALUES ($1, $2, $3)`, slug, forum.Title, forum.User)
+	if err != nil {
+		return err
+	}
+
+	i.forum = slug
+	i.report.Forum = slug
+
+	return nil
+}
+
+func (i *importer) thread(thread models.Thread) error {
+	// Threads created without a slug get a random one, which is simply
+	// drawn again rather than renamed.
+	slug := thread.Slug
+	var err error
+	if models.IsUUID(slug) {
+		slug = uuid.New().String()
+	} else if slug != "" {
+		slug, err = i.slug("thread", slug)
+		if err != nil {
+			return err
+		}
+	}
+
+	var id int
+	err = i.tx.QueryRow(
+		`INSERT INTO thread(slug, author, created, message, title, forum) VALUES (NULLIF($1, ''), $2, $3, $4, $5, $6) RETURNING id`,
+		slug, thread.Author, thread.Created, thread.Message, thread.Title, i.forum,
+	).Scan(&id)
+	if err != nil {
+		return err
+	}
+
+	i.report.ThreadIds[thread.Id] = id
+	i.report.Threads++
+
+	return nil
+}
+
+func (i *importer) post(post models.ArchivePost) error {
+	thread, ok := i.report.ThreadIds[post.Thread]
+	if !ok {
+		return fmt.Errorf("%w: post %d: thread %d is not in the archive", models.ErrInvalidArchive, post.Id, post.Thread)
+	}
+
+	var parent *int64
+	if post.Parent.Valid && post.Parent.Int64 != 0 {
+		id, ok := i.posts[int(post.Parent.Int64)]
+		if !ok {
+			return fmt.Errorf("%w: post %d: parent %d is not before it in the archive", models.ErrInvalidArchive, post.Id, post.Parent.Int64)
+		}
+		parent = &id
+	}
+
+	var id int64
+	var depth int
+	err := i.tx.QueryRow(
+		`INSERT INTO post(author, created, forum, message, isEdited, parent, thread)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, cardinality(path)`,
+		post.Author, post.Created, i.forum, post.Message, post.IsEdited, parent, thread,
+	).Scan(&id, &depth)
+	if err != nil {
+		return err
+	}
+
+	if len(post.Path) != 0 && depth != len(post.Path) {
+		return fmt.Errorf("%w: post %d: imported at depth %d instead of %d", models.ErrInvalidArchive, post.Id, depth, len(post.Path))
+	}
+
+	i.posts[post.Id] = id
+	i.report.Posts++
+
+	return nil
+}
+
+func (i *importer) vote(vote models.ArchiveVote) error {
+	thread, ok := i.report.ThreadIds[vote.Thread]
+	if !ok {
+		return fmt.Errorf("%w: vote of %s: thread %d is not in the archive", models.ErrInvalidArchive, vote.Nickname, vote.Thread)
+	}
+
+	_, err := i.tx.Exec(`INSERT INTO votes(nickname, voice, id_thread) VALUES ($1, $2, $3)`, vote.Nickname, vote.Voice, thread)
+	if err != nil {
+		return err
+	}
+
+	i.report.Votes++
+
+	return nil
+}
+
+// postVote goes through post_votes like a vote made on the forum, so the
+// triggers count it into the post's votes and its author's reputation.
+func (i *importer) postVote(vote models.ArchivePostVote) error {
+	post, ok := i.posts[vote.Post]
+	if !ok {
+		return fmt.Errorf("%w: vote of %s: post %d is not in the archive", models.ErrInvalidArchive, vote.Nickname, vote.Post)
+	}
+
+	_, err := i.tx.Exec(`INSERT INTO post_votes(nickname, voice, id_post) VALUES ($1, $2, $3)`, vote.Nickname, vote.Voice, post)
+	if err != nil {
+		return err
+	}
+
+	i.report.PostVotes++
+
+	return nil
+}
+
+// ImportForum recreates an archived forum from the items next returns until
+// io.EOF, in one transaction. Threads and posts get new ids; the report maps
+// the thread ones. A dry run rolls back at the end, so its report shows what
+// an import would do, conflicts included.
+func (p *postgresAppRepository) ImportForum(next func() (interface{}, error), options models.ImportOptions) (models.ImportReport, error) {
+	tx, err := p.Conn.Begin()
+	if err != nil {
+		return models.ImportReport{}, err
+	}
+
+	defer tx.Rollback()
+
+	i := &importer{
+		tx:      tx,
+		options: options,
+		report: models.ImportReport{
+			DryRun:    options.DryRun,
+			ThreadIds: map[int]int{},
+			Renamed:   map[string]string{},
+			Conflicts: []string{},
+		},
+		posts: map[int]int64{},
+	}
+
+	for {
+		item, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return i.report, err
+		}
+
+		if _, ok := item.(models.User); !ok {
+			if _, ok := item.(models.Forum); !ok && i.forum == "" {
+				return i.report, fmt.Errorf("%w: %T before the forum", models.ErrInvalidArchive, item)
+			}
+		}
+
+		switch item := item.(type) {
+		case models.User:
+			if i.forum != "" {
+				return i.report, fmt.Errorf("%w: user %s after the forum", models.ErrInvalidArchive, item.Nickname)
+			}
+			err = i.user(item)
+		case models.Forum:
+			err = i.forumRecord(item)
+		case models.Thread:
+			err = i.thread(item)
+		case models.ArchivePost:
+			err = i.post(item)
+		case models.ArchiveVote:
+			err = i.vote(item)
+		case models.ArchivePostVote:
+			err = i.postVote(item)
+		}
+		if err != nil {
+			return i.report, err
+		}
+	}
+
+	if i.forum == "" {
+		return i.report, fmt.Errorf("%w: no forum", models.ErrInvalidArchive)
+	}
+
+	if options.DryRun {
+		return i.report, nil
+	}
+
+	return i.report, tx.Commit()
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"github.com/jackc/pgx/pgtype"
+	"tp-db-forum/internal/app/models"
+)
+
+func (p *postgresAppRepository) SelectUsersByNicknames(nicknames []string) ([]models.User, error) {
+	var keys pgtype.TextArray
+	err := keys.Set(nicknames)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.Conn.Query(`SELECT nickname, fullname, about, email FROM users WHERE nickname = ANY($1::citext[])`, &keys)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var user models.User
+
+		err = rows.Scan(&user.Nickname, &user.FullName, &user.About, &user.Email)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (p *postgresAppRepository) SelectForumsBySlugs(slugs []string) ([]models.Forum, error) {
+	var keys pgtype.TextArray
+	err := keys.Set(slugs)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.Conn.Query(`SELECT slug, title, "user", posts, threads FROM forum WHERE slug = ANY($1::citext[])`, &keys)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var forums []models.Forum
+	for rows.Next() {
+		var forum models.Forum
+
+		err = rows.Scan(&forum.Slug, &forum.Title, &forum.User, &forum.Posts, &forum.Threads)
+		if err != nil {
+			return nil, err
+		}
+
+		forums = append(forums, forum)
+	}
+
+	return forums, rows.Err()
+}
+
+func (p *postgresAppRepository) SelectThreadsByIds(ids []int) ([]models.Thread, error) {
+	var keys pgtype.Int4Array
+	err := keys.Set(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.Conn.Query(`SELECT * FROM thread WHERE id = ANY($1)`, &keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanThreads(rows)
+}
+
+func (p *postgresAppRepository) SelectThreadsByIdsOrSlugs(ids []int, slugs []string) ([]models.Thread, error) {
+	var idKeys pgtype.Int4Array
+	err := idKeys.Set(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var slugKeys pgtype.TextArray
+	err = slugKeys.Set(slugs)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.Conn.Query(`SELECT * FROM thread WHERE id = ANY($1) OR slug = ANY($2::citext[])`, &idKeys, &slugKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanThreads(rows)
+}
+
+func (p *postgresAppRepository) SelectPostsByIds(ids []int) ([]models.Post, error) {
+	// Int8Array takes only slices of 64-bit integers.
+	wide := make([]int64, len(ids))
+	for i, id := range ids {
+		wide[i] = int64(id)
+	}
+
+	var keys pgtype.Int8Array
+	err := keys.Set(wide)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.Conn.Query(`SELECT * FROM post WHERE id = ANY($1)`, &keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanPosts(rows)
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+	"time"
+	"tp-db-forum/internal/app/models"
+)
+
+func TestSelectPostsByIds(t *testing.T) {
+	p, done := testRepository(t)
+	defer done()
+
+	nickname := fmt.Sprintf("batch%d", time.Now().UnixNano())
+	if err := p.InsertUser(models.User{Nickname: nickname, FullName: "Batch", Email: nickname + "@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	forum, err := p.InsertForum(models.Forum{Slug: nickname, Title: "batch", User: nickname})
+	if err != nil {
+		t.Fatal(err)
+	}
+	thread, err := p.InsertThread(models.Thread{Slug: nickname, Author: nickname, Forum: forum.Slug, Message: "m", Title: "t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	posts, err := p.InsertPosts([]models.Post{{Author: nickname, Message: "a"}, {Author: nickname, Message: "b"}}, thread.Id, forum.Slug)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := p.SelectPostsByIds([]int{posts[0].Id, posts[1].Id, -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 {
+		t.Errorf("found %d posts, want 2", len(found))
+	}
+
+	threads, err := p.SelectThreadsByIds([]int{thread.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(threads) != 1 || threads[0].Id != thread.Id {
+		t.Errorf("found threads %+v, want %d", threads, thread.Id)
+	}
+}
@@ -0,0 +1,347 @@
+package repository
+
+import (
+	"fmt"
+	"github.com/jackc/pgx"
+	"io"
+	"tp-db-forum/internal/app/models"
+)
+
+// bulkCheck is an integrity rule of a bulk load: query selects one text
+// column naming the rows that break it.
+type bulkCheck struct {
+	problem string
+	query   string
+}
+
+var (
+	forumChecks = []bulkCheck{
+		{"forums listed twice", `SELECT slug::text FROM load_forum GROUP BY slug HAVING count(*) > 1`},
+		{"forums of unknown users", `SELECT l.slug::text FROM load_forum l
+			LEFT JOIN users u ON u.nickname = l."user" WHERE u.nickname IS NULL`},
+	}
+	threadChecks = []bulkCheck{
+		{"threads without an id", `SELECT COALESCE(title, '') FROM load_thread WHERE id IS NULL`},
+		{"threads listed twice", `SELECT id::text FROM load_thread GROUP BY id HAVING count(*) > 1`},
+		{"threads of unknown users", `SELECT l.id::text FROM load_thread l
+			LEFT JOIN users u ON u.nickname = l.author WHERE u.nickname IS NULL`},
+		{"threads in unknown forums", `SELECT l.id::text FROM load_thread l
+			LEFT JOIN forum f ON f.slug = l.forum WHERE f.slug IS NULL`},
+	}
+	postChecks = []bulkCheck{
+		{"posts without an id", `SELECT COALESCE(author::text, '') FROM load_post WHERE id IS NULL`},
+		{"posts listed twice", `SELECT id::text FROM load_post GROUP BY id HAVING count(*) > 1`},
+		{"posts of unknown users", `SELECT l.id::text FROM load_post l
+			LEFT JOIN users u ON u.nickname = l.author WHERE u.nickname IS NULL`},
+		{"posts in unknown threads", `SELECT l.id::text FROM load_post l
+			LEFT JOIN load_thread t ON t.id = l.thread WHERE t.id IS NULL`},
+		{"posts with unknown parents", `SELECT l.id::text FROM load_post l
+			LEFT JOIN load_post p ON p.id = l.parent WHERE l.parent <> 0 AND p.id IS NULL`},
+		{"posts with a parent in another thread", `SELECT l.id::text FROM load_post l
+			JOIN load_post p ON p.id = l.parent WHERE p.thread <> l.thread`},
+	}
+	pathChecks = []bulkCheck{
+		{"posts in a cycle of parents", `SELECT l.id::text FROM load_post l
+			LEFT JOIN post_paths p ON p.id = l.id WHERE p.id IS NULL`},
+	}
+	voteChecks = []bulkCheck{
+		{"votes of unknown users", `SELECT l.thread::text FROM load_votes l
+			LEFT JOIN users u ON u.nickname = l.nickname WHERE u.nickname IS NULL`},
+		{"votes for unknown threads", `SELECT l.thread::text FROM load_votes l
+			LEFT JOIN load_thread t ON t.id = l.thread WHERE t.id IS NULL`},
+		{"votes listed twice", `SELECT nickname || ' ' || thread FROM load_votes GROUP BY nickname, thread HAVING count(*) > 1`},
+		{"votes with a voice other than 1 or -1", `SELECT l.thread::text FROM load_votes l
+			WHERE voice IS NULL OR voice NOT IN (1, -1)`},
+	}
+	postVoteChecks = []bulkCheck{
+		{"post votes of unknown users", `SELECT l.post::text FROM load_post_votes l
+			LEFT JOIN users u ON u.nickname = l.nickname WHERE u.nickname IS NULL`},
+		{"votes for unknown posts", `SELECT l.post::text FROM load_post_votes l
+			LEFT JOIN load_post p ON p.id = l.post WHERE p.id IS NULL`},
+		{"post votes listed twice", `SELECT nickname || ' ' || post FROM load_post_votes GROUP BY nickname, post HAVING count(*) > 1`},
+		{"post votes with a voice other than 1 or -1", `SELECT l.post::text FROM load_post_votes l
+			WHERE voice IS NULL OR voice NOT IN (1, -1)`},
+	}
+)
+
+func checkBulk(tx *pgx.Tx, checks []bulkCheck) error {
+	for _, check := range checks {
+		var count int
+		var example string
+		err := tx.QueryRow(`SELECT count(*), COALESCE(min(c.x), '') FROM (`+check.query+`) c(x)`).Scan(&count, &example)
+		if err != nil {
+			return err
+		}
+
+		if count != 0 {
+			return fmt.Errorf("%w: %d %s, like %s", models.ErrBulkIntegrity, count, check.problem, example)
+		}
+	}
+
+	return nil
+}
+
+// BulkLoad copies the CSV files of sources into staging tables and moves
+// them into the forum in one transaction. It sets forum.bulk for the
+// transaction, which the insert triggers of users, forum, thread, post,
+// votes and post_votes are guarded by, and does their work once for all
+// rows instead: post paths, the forum, thread and post counters,
+// table_count, reputation and users_forum. The outbox is left out, loads don't make events. The tables
+// aren't locked, so the forum keeps serving and its own writes meanwhile go
+// through the triggers as usual.
+func (p *postgresAppRepository) BulkLoad(sources models.BulkSources) (models.BulkReport, error) {
+	var report models.BulkReport
+
+	tx, err := p.Conn.Begin()
+	if err != nil {
+		return report, err
+	}
+
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SET LOCAL forum.bulk = 'on'`); err != nil {
+		return report, err
+	}
+
+	staging := []struct {
+		table   string
+		columns string
+		source  io.Reader
+	}{
+		{"load_users", "nickname CITEXT, fullname TEXT, about TEXT, email CITEXT", sources.Users},
+		{"load_forum", `slug CITEXT, title TEXT, "user" CITEXT`, sources.Forums},
+		{"load_thread", "id INT, slug CITEXT, author CITEXT, forum CITEXT, title TEXT, message TEXT, created TIMESTAMP WITH TIME ZONE", sources.Threads},
+		{"load_post", "id BIGINT, parent BIGINT, thread INT, author CITEXT, message TEXT, created TIMESTAMP WITH TIME ZONE, isEdited BOOLEAN", sources.Posts},
+		{"load_votes", "nickname CITEXT, thread INT, voice INT", sources.Votes},
+		{"load_post_votes", "nickname CITEXT, post BIGINT, voice INT", sources.PostVotes},
+	}
+	for _, load := range staging {
+		if _, err := tx.Exec(`CREATE TEMP TABLE ` + load.table + ` (` + load.columns + `) ON COMMIT DROP`); err != nil {
+			return report, err
+		}
+
+		if load.source != nil {
+			_, err := tx.CopyFromReader(load.source, `COPY `+load.table+` FROM STDIN WITH (FORMAT csv, HEADER true)`)
+			if err != nil {
+				return report, err
+			}
+		}
+
+		if _, err := tx.Exec(`ANALYZE ` + load.table); err != nil {
+			return report, err
+		}
+	}
+
+	var users int
+	if err := tx.QueryRow(`SELECT count(*) FROM load_users`).Scan(&users); err != nil {
+		return report, err
+	}
+
+	tag, err := tx.Exec(
+		`INSERT INTO users (nickname, fullname, about, email)
+		SELECT nickname, COALESCE(fullname, ''), about, email FROM load_users
+		ON CONFLICT DO NOTHING`,
+	)
+	if err != nil {
+		return report, err
+	}
+	report.Users = int(tag.RowsAffected())
+	report.UsersSkipped = users - report.Users
+
+	if err := checkBulk(tx, forumChecks); err != nil {
+		return report, err
+	}
+
+	tag, err = tx.Exec(
+		`INSERT INTO forum (slug, title, "user")
+		SELECT l.slug, l.title, u.nickname FROM load_forum l JOIN users u ON u.nickname = l."user"`,
+	)
+	if err != nil {
+		return report, err
+	}
+	report.Forums = int(tag.RowsAffected())
+
+	if err := checkBulk(tx, threadChecks); err != nil {
+		return report, err
+	}
+
+	// The ids of the files only link their rows; every thread and post gets
+	// a new one from the sequences.
+	_, err = tx.Exec(
+		`CREATE TEMP TABLE thread_ids ON COMMIT DROP AS
+		SELECT id AS old, nextval(pg_get_serial_sequence('thread', 'id'))::INT AS new FROM load_thread ORDER BY id;
+		CREATE UNIQUE INDEX ON thread_ids (old);
+		ANALYZE thread_ids`,
+	)
+	if err != nil {
+		return report, err
+	}
+
+	tag, err = tx.Exec(
+		`INSERT INTO thread (id, author, created, forum, message, slug, title)
+		SELECT m.new, u.nickname, COALESCE(l.created, NOW()), f.slug, l.message,
+			COALESCE(NULLIF(l.slug, ''), md5(random()::text || l.id)::uuid::text), l.title
+		FROM load_thread l
+		JOIN thread_ids m ON m.old = l.id
+		JOIN users u ON u.nickname = l.author
+		JOIN forum f ON f.slug = l.forum
+		ORDER BY m.new`,
+	)
+	if err != nil {
+		return report, err
+	}
+	report.Threads = int(tag.RowsAffected())
+
+	if err := checkBulk(tx, postChecks); err != nil {
+		return report, err
+	}
+
+	_, err = tx.Exec(
+		`CREATE TEMP TABLE post_ids ON COMMIT DROP AS
+		SELECT id AS old, nextval(pg_get_serial_sequence('post', 'id')) AS new FROM load_post ORDER BY id;
+		CREATE UNIQUE INDEX ON post_ids (old);
+		CREATE INDEX ON load_post (parent);
+		ANALYZE post_ids`,
+	)
+	if err != nil {
+		return report, err
+	}
+
+	// Paths are built from the roots down, a level of the trees per step.
+	// Posts in a cycle are never reached, which the check after catches.
+	_, err = tx.Exec(
+		`CREATE TEMP TABLE post_paths ON COMMIT DROP AS
+		WITH RECURSIVE tree (id, path) AS (
+			SELECT l.id, ARRAY[m.new] FROM load_post l JOIN post_ids m ON m.old = l.id
+			WHERE COALESCE(l.parent, 0) = 0
+			UNION ALL
+			SELECT l.id, tree.path || m.new FROM tree
+			JOIN load_post l ON l.parent = tree.id
+			JOIN post_ids m ON m.old = l.id
+		)
+		SELECT id, path FROM tree;
+		CREATE UNIQUE INDEX ON post_paths (id);
+		ANALYZE post_paths`,
+	)
+	if err != nil {
+		return report, err
+	}
+
+	if err := checkBulk(tx, pathChecks); err != nil {
+		return report, err
+	}
+
+	tag, err = tx.Exec(
+		`INSERT INTO post (id, author, created, forum, message, isEdited, parent, thread, path)
+		SELECT m.new, u.nickname, COALESCE(l.created, NOW()), t.forum, l.message,
+			COALESCE(l.isEdited, FALSE), pm.new, t.id, pp.path
+		FROM load_post l
+		JOIN post_ids m ON m.old = l.id
+		JOIN post_paths pp ON pp.id = l.id
+		JOIN thread_ids tm ON tm.old = l.thread
+		JOIN thread t ON t.id = tm.new
+		JOIN users u ON u.nickname = l.author
+		LEFT JOIN post_ids pm ON pm.old = l.parent
+		ORDER BY m.new`,
+	)
+	if err != nil {
+		return report, err
+	}
+	report.Posts = int(tag.RowsAffected())
+
+	if err := checkBulk(tx, voteChecks); err != nil {
+		return report, err
+	}
+
+	tag, err = tx.Exec(
+		`INSERT INTO votes (nickname, voice, id_thread)
+		SELECT u.nickname, l.voice, m.new FROM load_votes l
+		JOIN thread_ids m ON m.old = l.thread
+		JOIN users u ON u.nickname = l.nickname`,
+	)
+	if err != nil {
+		return report, err
+	}
+	report.Votes = int(tag.RowsAffected())
+
+	if err := checkBulk(tx, postVoteChecks); err != nil {
+		return report, err
+	}
+
+	tag, err = tx.Exec(
+		`INSERT INTO post_votes (nickname, voice, id_post)
+		SELECT u.nickname, l.voice, m.new FROM load_post_votes l
+		JOIN post_ids m ON m.old = l.post
+		JOIN users u ON u.nickname = l.nickname`,
+	)
+	if err != nil {
+		return report, err
+	}
+	report.PostVotes = int(tag.RowsAffected())
+
+	_, err = tx.Exec(
+		`UPDATE thread t SET votes = v.votes FROM (
+			SELECT id_thread, sum(voice) AS votes FROM votes JOIN thread_ids m ON m.new = id_thread GROUP BY id_thread
+		) v WHERE t.id = v.id_thread;
+		UPDATE post p SET votes = v.votes FROM (
+			SELECT id_post, sum(voice) AS votes FROM post_votes JOIN post_ids m ON m.new = id_post GROUP BY id_post
+		) v WHERE p.id = v.id_post;
+		UPDATE forum f SET threads = f.threads + c.n FROM (
+			SELECT forum, count(*) AS n FROM thread JOIN thread_ids m ON m.new = id GROUP BY forum
+		) c WHERE f.slug = c.forum;
+		UPDATE forum f SET posts = f.posts + c.n FROM (
+			SELECT forum, count(*) AS n FROM post JOIN post_ids m ON m.new = id GROUP BY forum
+		) c WHERE f.slug = c.forum`,
+	)
+	if err != nil {
+		return report, err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO users_forum (nickname, fullname, about, email, slug)
+		SELECT u.nickname, u.fullname, u.about, u.email, a.forum FROM (
+			SELECT author, forum FROM thread JOIN thread_ids m ON m.new = id
+			UNION SELECT author, forum FROM post JOIN post_ids m ON m.new = id
+		) a JOIN users u ON u.nickname = a.author
+		ON CONFLICT DO NOTHING`,
+	)
+	if err != nil {
+		return report, err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO reputation (nickname, forum, score)
+		SELECT author, forum, sum(voice) FROM (
+			SELECT t.author, t.forum, v.voice FROM votes v
+			JOIN thread_ids m ON m.new = v.id_thread
+			JOIN thread t ON t.id = v.id_thread
+			UNION ALL
+			SELECT p.author, p.forum, v.voice FROM post_votes v
+			JOIN post_ids m ON m.new = v.id_post
+			JOIN post p ON p.id = v.id_post
+		) v GROUP BY author, forum
+		ON CONFLICT (nickname, forum) DO UPDATE SET score = reputation.score + EXCLUDED.score`,
+	)
+	if err != nil {
+		return report, err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO table_count (name, shard, n) VALUES ('users', 0, $1), ('forum', 0, $2), ('thread', 0, $3), ('post', 0, $4)
+		ON CONFLICT (name, shard) DO UPDATE SET n = table_count.n + EXCLUDED.n`,
+		report.Users, report.Forums, report.Threads, report.Posts,
+	)
+	if err != nil {
+		return report, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return report, err
+	}
+
+	// Fresh statistics keep the planner from treating the tables as small;
+	// the load itself is done, so a failure here isn't one of the load.
+	p.Conn.Exec(`ANALYZE users, forum, thread, post, votes, post_votes, users_forum`)
+
+	return report, nil
+}
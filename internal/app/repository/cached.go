@@ -0,0 +1,327 @@
+package repository
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"tp-db-forum/internal/app"
+	"tp-db-forum/internal/app/cache"
+	"tp-db-forum/internal/app/models"
+)
+
+type CacheConfig struct {
+	Size     int
+	TTL      time.Duration
+	StatsTTL time.Duration
+}
+
+// The TTL bounds how stale an entry can get when another instance writes to
+// the same database; writes through this instance invalidate right away.
+var DefaultCacheConfig = CacheConfig{
+	Size:     10000,
+	TTL:      30 * time.Second,
+	StatsTTL: time.Minute,
+}
+
+// CachedAppRepository serves users, forums and threads from memory. Every
+// method that changes one of them, directly or through the counter
+// triggers, drops the affected entry:
+//
+//	forum.threads  InsertThread
+//	forum.posts    InsertPosts
+//	thread.votes   InsertVote, UpdateVote
+//
+// The entry is dropped before the write and, until the write is done,
+// readers don't cache what they read, so a row read before the commit
+// isn't cached after the invalidation (see cache.LRU.SetSince).
+//
+// Thread id to slug and thread id to forum mappings never change, so those
+// entries are only evicted by size. Forum stats and leaderboards aren't
+// invalidated at all; they are as stale as StatsTTL at most.
+type CachedAppRepository struct {
+	app.Repository
+
+	users        *cache.LRU
+	forums       *cache.LRU
+	threads      *cache.LRU
+	threadIds    *cache.LRU
+	threadForums *cache.LRU
+	stats        *cache.LRU
+}
+
+func NewCachedAppRepository(repository app.Repository, config CacheConfig) *CachedAppRepository {
+	return &CachedAppRepository{
+		Repository:   repository,
+		users:        cache.NewLRU(config.Size, config.TTL),
+		forums:       cache.NewLRU(config.Size, config.TTL),
+		threads:      cache.NewLRU(config.Size, config.TTL),
+		threadIds:    cache.NewLRU(config.Size, 0),
+		threadForums: cache.NewLRU(config.Size, 0),
+		stats:        cache.NewLRU(config.Size, config.StatsTTL),
+	}
+}
+
+func (c *CachedAppRepository) Stats() map[string]cache.Stats {
+	return map[string]cache.Stats{
+		"users":         c.users.Stats(),
+		"forums":        c.forums.Stats(),
+		"threads":       c.threads.Stats(),
+		"thread_ids":    c.threadIds.Stats(),
+		"thread_forums": c.threadForums.Stats(),
+		"forum_stats":   c.stats.Stats(),
+	}
+}
+
+func (c *CachedAppRepository) SelectUserByNickname(nickname string) (models.User, error) {
+	key := strings.ToLower(nickname)
+	if user, ok := c.users.Get(key); ok {
+		return user.(models.User), nil
+	}
+
+	generation := c.users.Generation()
+	user, err := c.Repository.SelectUserByNickname(nickname)
+	if err != nil {
+		return user, err
+	}
+
+	c.users.SetSince(key, user, generation)
+
+	return user, nil
+}
+
+func (c *CachedAppRepository) UpdateUser(user models.User) (models.User, error) {
+	key := strings.ToLower(user.Nickname)
+	done := c.users.Invalidate(key)
+
+	newUser, err := c.Repository.UpdateUser(user)
+	done()
+	if err != nil {
+		return newUser, err
+	}
+
+	c.users.SetSince(key, newUser, c.users.Generation())
+
+	return newUser, nil
+}
+
+func (c *CachedAppRepository) SelectForumBySlug(slug string) (models.Forum, error) {
+	key := strings.ToLower(slug)
+	if forum, ok := c.forums.Get(key); ok {
+		return forum.(models.Forum), nil
+	}
+
+	generation := c.forums.Generation()
+	forum, err := c.Repository.SelectForumBySlug(slug)
+	if err != nil {
+		return forum, err
+	}
+
+	c.forums.SetSince(key, forum, generation)
+
+	return forum, nil
+}
+
+func (c *CachedAppRepository) InsertThread(thread models.Thread) (models.Thread, error) {
+	defer c.forums.Invalidate(strings.ToLower(thread.Forum))()
+
+	return c.Repository.InsertThread(thread)
+}
+
+func (c *CachedAppRepository) SelectThreadById(id int) (models.Thread, error) {
+	key := strconv.Itoa(id)
+	if thread, ok := c.threads.Get(key); ok {
+		return thread.(models.Thread), nil
+	}
+
+	generation := c.threads.Generation()
+	thread, err := c.Repository.SelectThreadById(id)
+	if err != nil {
+		return thread, err
+	}
+
+	c.setThread(thread, generation)
+
+	return thread, nil
+}
+
+func (c *CachedAppRepository) SelectThreadBySlug(slug string) (models.Thread, error) {
+	if id, ok := c.threadIds.Get(strings.ToLower(slug)); ok {
+		return c.SelectThreadById(id.(int))
+	}
+
+	generation := c.threads.Generation()
+	thread, err := c.Repository.SelectThreadBySlug(slug)
+	if err != nil {
+		return thread, err
+	}
+
+	c.setThread(thread, generation)
+
+	return thread, nil
+}
+
+func (c *CachedAppRepository) SelectThreadIdBySlug(slug string) (int, error) {
+	key := strings.ToLower(slug)
+	if id, ok := c.threadIds.Get(key); ok {
+		return id.(int), nil
+	}
+
+	id, err := c.Repository.SelectThreadIdBySlug(slug)
+	if err != nil {
+		return id, err
+	}
+
+	c.threadIds.Set(key, id)
+
+	return id, nil
+}
+
+func (c *CachedAppRepository) SelectForumSlugByThreadId(id int) (string, error) {
+	key := strconv.Itoa(id)
+	if forum, ok := c.threadForums.Get(key); ok {
+		return forum.(string), nil
+	}
+
+	forum, err := c.Repository.SelectForumSlugByThreadId(id)
+	if err != nil {
+		return forum, err
+	}
+
+	c.threadForums.Set(key, forum)
+
+	return forum, nil
+}
+
+func (c *CachedAppRepository) UpdateThread(thread models.Thread) (models.Thread, error) {
+	done := func() {}
+	if thread.Slug == "" {
+		done = c.threads.Invalidate(strconv.Itoa(thread.Id))
+	} else if id, ok := c.threadIds.Get(strings.ToLower(thread.Slug)); ok {
+		done = c.threads.Invalidate(strconv.Itoa(id.(int)))
+	}
+
+	newThread, err := c.Repository.UpdateThread(thread)
+	done()
+	if err != nil {
+		return newThread, err
+	}
+
+	// A slug that wasn't cached left the id unknown before the write; a
+	// reader that started meanwhile won't cache the thread after this.
+	c.threads.Invalidate(strconv.Itoa(newThread.Id))()
+	c.setThread(newThread, c.threads.Generation())
+
+	return newThread, nil
+}
+
+func (c *CachedAppRepository) InsertPosts(posts []models.Post, thread int, forum string) ([]models.Post, error) {
+	defer c.forums.Invalidate(strings.ToLower(forum))()
+
+	return c.Repository.InsertPosts(posts, thread, forum)
+}
+
+func (c *CachedAppRepository) InsertVote(vote models.Vote) (models.Vote, error) {
+	defer c.threads.Invalidate(strconv.Itoa(vote.IdThread))()
+
+	return c.Repository.InsertVote(vote)
+}
+
+func (c *CachedAppRepository) UpdateVote(vote models.Vote) (models.Vote, error) {
+	defer c.threads.Invalidate(strconv.Itoa(vote.IdThread))()
+
+	return c.Repository.UpdateVote(vote)
+}
+
+func (c *CachedAppRepository) ClearDatabase() error {
+	defer c.Purge()
+
+	return c.Repository.ClearDatabase()
+}
+
+func (c *CachedAppRepository) ImportForum(next func() (interface{}, error), options models.ImportOptions) (models.ImportReport, error) {
+	defer c.Purge()
+
+	return c.Repository.ImportForum(next, options)
+}
+
+func (c *CachedAppRepository) BulkLoad(sources models.BulkSources) (models.BulkReport, error) {
+	defer c.Purge()
+
+	return c.Repository.BulkLoad(sources)
+}
+
+func (c *CachedAppRepository) ModerateThread(id int, deleted bool) error {
+	defer c.Purge()
+
+	return c.Repository.ModerateThread(id, deleted)
+}
+
+func (c *CachedAppRepository) RenameUser(nickname, newNickname string) (models.User, error) {
+	defer c.Purge()
+
+	return c.Repository.RenameUser(nickname, newNickname)
+}
+
+func (c *CachedAppRepository) ReconcileCounters(fix bool) (models.CounterReport, error) {
+	if fix {
+		defer c.Purge()
+	}
+
+	return c.Repository.ReconcileCounters(fix)
+}
+
+func (c *CachedAppRepository) SelectForumStats(slug string, options models.StatsOptions) (models.ForumStats, error) {
+	key := strings.Join([]string{
+		strings.ToLower(slug),
+		options.Bucket,
+		strconv.FormatInt(options.Since.Unix(), 10),
+		strconv.FormatInt(options.Until.Unix(), 10),
+		strconv.Itoa(options.Limit),
+	}, " ")
+	if stats, ok := c.stats.Get(key); ok {
+		return stats.(models.ForumStats), nil
+	}
+
+	stats, err := c.Repository.SelectForumStats(slug, options)
+	if err != nil {
+		return stats, err
+	}
+
+	c.stats.Set(key, stats)
+
+	return stats, nil
+}
+
+// SelectLeaderboard shares the forum stats entries and their TTL.
+func (c *CachedAppRepository) SelectLeaderboard(options models.LeaderboardOptions) (models.Leaderboard, error) {
+	key := strings.Join([]string{"leaderboard", strings.ToLower(options.Forum), options.Window, strconv.Itoa(options.Limit)}, " ")
+	if leaderboard, ok := c.stats.Get(key); ok {
+		return leaderboard.(models.Leaderboard), nil
+	}
+
+	leaderboard, err := c.Repository.SelectLeaderboard(options)
+	if err != nil {
+		return leaderboard, err
+	}
+
+	c.stats.Set(key, leaderboard)
+
+	return leaderboard, nil
+}
+
+// Purge drops every entry, for writes that bypass the methods above.
+func (c *CachedAppRepository) Purge() {
+	c.users.Purge()
+	c.forums.Purge()
+	c.threads.Purge()
+	c.threadIds.Purge()
+	c.threadForums.Purge()
+	c.stats.Purge()
+}
+
+func (c *CachedAppRepository) setThread(thread models.Thread, generation uint64) {
+	c.threads.SetSince(strconv.Itoa(thread.Id), thread, generation)
+	if !models.IsUUID(thread.Slug) {
+		c.threadIds.Set(strings.ToLower(thread.Slug), thread.Id)
+	}
+}
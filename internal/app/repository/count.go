@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"tp-db-forum/internal/app/models"
+)
+
+// estimateRows returns the planner's row estimate for query, which is cheap
+// compared to running COUNT(*) over a large table.
+func (p *postgresAppRepository) estimateRows(query string, args ...interface{}) (int, error) {
+	var plan string
+	err := p.Conn.QueryRow(`EXPLAIN (FORMAT JSON) `+query, args...).Scan(&plan)
+	if err != nil {
+		return 0, err
+	}
+
+	var result []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	err = json.Unmarshal([]byte(plan), &result)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(result) == 0 {
+		return 0, errors.New("empty plan")
+	}
+
+	return int(result[0].Plan.Rows), nil
+}
+
+func (p *postgresAppRepository) CountThreadsByForum(slugForum string, estimate bool) (int, error) {
+	var count int
+	if estimate {
+		err := p.Conn.QueryRow(`SELECT threads FROM forum WHERE slug=$1`, slugForum).Scan(&count)
+
+		return count, err
+	}
+
+	err := p.Conn.QueryRow(`SELECT COUNT(*) FROM thread WHERE forum=$1`, slugForum).Scan(&count)
+
+	return count, err
+}
+
+func (p *postgresAppRepository) CountUsersByForum(slugForum string, estimate bool) (int, error) {
+	if estimate {
+		return p.estimateRows(`SELECT 1 FROM users_forum WHERE slug=$1`, slugForum)
+	}
+
+	var count int
+	err := p.Conn.QueryRow(`SELECT COUNT(*) FROM users_forum WHERE slug=$1`, slugForum).Scan(&count)
+
+	return count, err
+}
+
+func (p *postgresAppRepository) CountPostsByThread(thread models.Thread, estimate bool) (int, error) {
+	threadId := thread.Id
+	if threadId == 0 {
+		id, err := p.SelectThreadIdBySlug(thread.Slug)
+		if err != nil {
+			return 0, err
+		}
+
+		threadId = id
+	}
+
+	if estimate {
+		return p.estimateRows(`SELECT 1 FROM post WHERE thread=$1`, threadId)
+	}
+
+	var count int
+	err := p.Conn.QueryRow(`SELECT COUNT(*) FROM post WHERE thread=$1`, threadId).Scan(&count)
+
+	return count, err
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"github.com/jackc/pgx"
+	"tp-db-forum/internal/app/models"
+)
+
+// counterChecks recompute the aggregates the triggers maintain. Each query
+// selects the key, stored and actual value of the rows that drifted, and
+// whatever else the fix needs, under the name drift for the fix to update
+// from.
+var counterChecks = []struct {
+	table  string
+	column string
+	query  string
+	fix    string
+}{
+	{
+		"forum", "posts",
+		`SELECT f.slug::text AS key, f.posts AS stored, COALESCE(c.n, 0) AS actual FROM forum f
+		LEFT JOIN (SELECT forum, count(*) AS n FROM post GROUP BY forum) c ON c.forum = f.slug
+		WHERE f.posts IS DISTINCT FROM COALESCE(c.n, 0)`,
+		`UPDATE forum SET posts = drift.actual FROM drift WHERE forum.slug = drift.key`,
+	},
+	{
+		"forum", "threads",
+		`SELECT f.slug::text AS key, f.threads AS stored, COALESCE(c.n, 0) AS actual FROM forum f
+		LEFT JOIN (SELECT forum, count(*) AS n FROM thread GROUP BY forum) c ON c.forum = f.slug
+		WHERE f.threads IS DISTINCT FROM COALESCE(c.n, 0)`,
+		`UPDATE forum SET threads = drift.actual FROM drift WHERE forum.slug = drift.key`,
+	},
+	{
+		"thread", "votes",
+		`SELECT t.id::text AS key, t.votes::bigint AS stored, COALESCE(v.n, 0) AS actual FROM thread t
+		LEFT JOIN (SELECT id_thread, sum(voice) AS n FROM votes GROUP BY id_thread) v ON v.id_thread = t.id
+		WHERE t.votes IS DISTINCT FROM COALESCE(v.n, 0)`,
+		`UPDATE thread SET votes = drift.actual FROM drift WHERE thread.id = drift.key::int`,
+	},
+	{
+		"post", "votes",
+		`SELECT p.id::text AS key, p.votes::bigint AS stored, COALESCE(v.n, 0) AS actual FROM post p
+		LEFT JOIN (SELECT id_post, sum(voice) AS n FROM post_votes GROUP BY id_post) v ON v.id_post = p.id
+		WHERE p.votes <> COALESCE(v.n, 0)`,
+		`UPDATE post SET votes = drift.actual FROM drift WHERE post.id = drift.key::bigint`,
+	},
+	{
+		"table_count", "n",
+		`SELECT c.name AS key, COALESCE(s.n, 0) AS stored, c.n AS actual FROM (VALUES
+			('users', (SELECT count(*) FROM users)),
+			('forum', (SELECT count(*) FROM forum)),
+			('thread', (SELECT count(*) FROM thread)),
+			('post', (SELECT count(*) FROM post))
+		) c (name, n)
+		LEFT JOIN (SELECT name, sum(n)::bigint AS n FROM table_count GROUP BY name) s ON s.name = c.name
+		WHERE COALESCE(s.n, 0) <> c.n`,
+		`INSERT INTO table_count (name, shard, n) SELECT key, 0, actual - stored FROM drift
+		ON CONFLICT (name, shard) DO UPDATE SET n = table_count.n + EXCLUDED.n`,
+	},
+	{
+		"reputation", "score",
+		`SELECT c.nickname::text || ' ' || c.forum::text AS key, c.nickname, c.forum, c.stored, c.actual FROM (
+			SELECT COALESCE(r.nickname, a.author) AS nickname, COALESCE(r.forum, a.forum) AS forum,
+				COALESCE(r.score, 0) AS stored, COALESCE(a.n, 0) AS actual
+			FROM reputation r
+			FULL JOIN (
+				SELECT author, forum, sum(voice) AS n FROM (
+					SELECT t.author, t.forum, v.voice FROM votes v JOIN thread t ON t.id = v.id_thread
+					UNION ALL
+					SELECT p.author, p.forum, v.voice FROM post_votes v JOIN post p ON p.id = v.id_post
+				) v GROUP BY author, forum
+			) a ON a.author = r.nickname AND a.forum = r.forum
+		) c
+		WHERE c.stored <> c.actual`,
+		`INSERT INTO reputation (nickname, forum, score) SELECT nickname, forum, actual FROM drift
+		ON CONFLICT (nickname, forum) DO UPDATE SET score = EXCLUDED.score`,
+	},
+}
+
+// ReconcileCounters compares forum.posts, forum.threads, thread.votes,
+// post.votes, table_count and reputation to the rows they count. A check
+// reads one snapshot; a fix locks out writes to the counted tables while it
+// runs, so no trigger moves a counter between its count and its update.
+func (p *postgresAppRepository) ReconcileCounters(fix bool) (models.CounterReport, error) {
+	report := models.CounterReport{Fixed: fix, Drifts: []models.CounterDrift{}}
+
+	options := &pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}
+	if fix {
+		options = &pgx.TxOptions{IsoLevel: pgx.ReadCommitted}
+	}
+
+	tx, err := p.Conn.BeginEx(context.Background(), options)
+	if err != nil {
+		return report, err
+	}
+
+	defer tx.Rollback()
+
+	if fix {
+		if _, err := tx.Exec(`LOCK TABLE users, forum, post, thread, votes, post_votes IN SHARE MODE`); err != nil {
+			return report, err
+		}
+	}
+
+	for _, check := range counterChecks {
+		query := `SELECT key, stored, actual FROM (` + check.query + `) drift`
+		if fix {
+			query = `WITH drift AS (` + check.query + `), fixed AS (` + check.fix + `) SELECT key, stored, actual FROM drift`
+		}
+
+		rows, err := tx.Query(query)
+		if err != nil {
+			return report, err
+		}
+
+		for rows.Next() {
+			drift := models.CounterDrift{Table: check.table, Column: check.column}
+			if err := rows.Scan(&drift.Key, &drift.Stored, &drift.Actual); err != nil {
+				rows.Close()
+				return report, err
+			}
+
+			report.Drifts = append(report.Drifts, drift)
+		}
+
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return report, err
+		}
+	}
+
+	return report, tx.Commit()
+}
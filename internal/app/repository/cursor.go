@@ -0,0 +1,321 @@
+package repository
+
+import (
+	"fmt"
+	"github.com/go-openapi/strfmt"
+	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/pgtype"
+	"time"
+	"tp-db-forum/internal/app/models"
+)
+
+// keysetOrder returns the comparison operator and sort direction for reading
+// the items after a cursor position, in storage order.
+func keysetOrder(cursor models.Cursor) (string, string) {
+	if cursor.Desc != cursor.Prev {
+		return "<", "DESC"
+	}
+
+	return ">", "ASC"
+}
+
+// fetchLimit asks for one extra row to find out whether there is a next page.
+func fetchLimit(limit int) int {
+	if limit <= 0 {
+		return 0
+	}
+
+	return limit + 1
+}
+
+func scanThreads(rows *pgx.Rows) ([]models.Thread, error) {
+	defer rows.Close()
+
+	var threads []models.Thread
+	for rows.Next() {
+		var thread models.Thread
+		var created time.Time
+
+		err := rows.Scan(
+			&thread.Id,
+			&thread.Author,
+			&created,
+			&thread.Forum,
+			&thread.Message,
+			&thread.Slug,
+			&thread.Title,
+			&thread.Votes,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		thread.Created = strfmt.DateTime(created.UTC()).String()
+
+		threads = append(threads, thread)
+	}
+
+	return threads, rows.Err()
+}
+
+func scanPosts(rows *pgx.Rows) ([]models.Post, error) {
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		var post models.Post
+		var created time.Time
+
+		err := rows.Scan(
+			&post.Id,
+			&post.Author,
+			&created,
+			&post.Forum,
+			&post.Message,
+			&post.IsEdited,
+			&post.Parent,
+			&post.Thread,
+			&post.Path,
+			&post.Votes,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		post.Created = strfmt.DateTime(created.UTC()).String()
+
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
+
+func (p *postgresAppRepository) SelectThreadsByForumCursor(slugForum string, limit int, cursor models.Cursor) ([]models.Thread, bool, error) {
+	op, order := keysetOrder(cursor)
+
+	rows, err := p.Conn.Query(
+		fmt.Sprintf(
+			`SELECT * FROM thread WHERE forum=$1
+			AND (created, id) %s (COALESCE((SELECT created FROM thread WHERE id=$3), $2::timestamptz), $3)
+			ORDER BY created %s, id %s LIMIT NULLIF($4, 0)`,
+			op, order, order,
+		),
+		slugForum, cursor.Created, cursor.Id, fetchLimit(limit),
+	)
+	if err != nil {
+		return nil, false, err
+	}
+
+	threads, err := scanThreads(rows)
+	if err != nil {
+		return nil, false, err
+	}
+
+	hasMore := limit > 0 && len(threads) > limit
+	if hasMore {
+		threads = threads[:limit]
+	}
+
+	if cursor.Prev {
+		for i, j := 0, len(threads)-1; i < j; i, j = i+1, j-1 {
+			threads[i], threads[j] = threads[j], threads[i]
+		}
+	}
+
+	return threads, hasMore, nil
+}
+
+func (p *postgresAppRepository) SelectUsersByForumCursor(slugForum string, limit int, cursor models.Cursor) ([]models.User, bool, error) {
+	op, order := keysetOrder(cursor)
+
+	rows, err := p.Conn.Query(
+		fmt.Sprintf(
+			`SELECT about, email, fullname, nickname FROM users_forum
+			WHERE slug=$1 AND nickname %s $2
+			ORDER BY nickname %s LIMIT NULLIF($3, 0)`,
+			op, order,
+		),
+		slugForum, cursor.Nickname, fetchLimit(limit),
+	)
+	if err != nil {
+		return nil, false, err
+	}
+
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var user models.User
+
+		err = rows.Scan(&user.About, &user.Email, &user.FullName, &user.Nickname)
+		if err != nil {
+			return nil, false, err
+		}
+
+		users = append(users, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	hasMore := limit > 0 && len(users) > limit
+	if hasMore {
+		users = users[:limit]
+	}
+
+	if cursor.Prev {
+		for i, j := 0, len(users)-1; i < j; i, j = i+1, j-1 {
+			users[i], users[j] = users[j], users[i]
+		}
+	}
+
+	return users, hasMore, nil
+}
+
+func (p *postgresAppRepository) SelectPostsByThreadCursor(thread models.Thread, limit int, cursor models.Cursor) ([]models.Post, bool, error) {
+	threadId := thread.Id
+	if threadId == 0 {
+		id, err := p.SelectThreadIdBySlug(thread.Slug)
+		if err != nil {
+			return nil, false, err
+		}
+
+		threadId = id
+	}
+
+	op, order := keysetOrder(cursor)
+
+	var posts []models.Post
+	var hasMore bool
+	switch cursor.Sort {
+	case "flat":
+		rows, err := p.Conn.Query(
+			fmt.Sprintf(
+				`SELECT * FROM post WHERE thread=$1 AND id %s $2 ORDER BY id %s LIMIT NULLIF($3, 0)`,
+				op, order,
+			),
+			threadId, cursor.Id, fetchLimit(limit),
+		)
+		if err != nil {
+			return nil, false, err
+		}
+
+		posts, err = scanPosts(rows)
+		if err != nil {
+			return nil, false, err
+		}
+	case "tree":
+		var path pgtype.Int8Array
+		err := path.Set(cursor.Path)
+		if err != nil {
+			return nil, false, models.ErrInvalidCursor
+		}
+
+		rows, err := p.Conn.Query(
+			fmt.Sprintf(
+				`SELECT * FROM post WHERE thread=$1 AND path %s $2 ORDER BY path %s, id %s LIMIT NULLIF($3, 0)`,
+				op, order, order,
+			),
+			threadId, &path, fetchLimit(limit),
+		)
+		if err != nil {
+			return nil, false, err
+		}
+
+		posts, err = scanPosts(rows)
+		if err != nil {
+			return nil, false, err
+		}
+	case "parent_tree":
+		if len(cursor.Path) == 0 {
+			return nil, false, models.ErrInvalidCursor
+		}
+
+		rows, err := p.Conn.Query(
+			fmt.Sprintf(
+				`SELECT id FROM post WHERE thread=$1 AND parent IS NULL AND id %s $2
+				ORDER BY id %s LIMIT NULLIF($3, 0)`,
+				op, order,
+			),
+			threadId, cursor.Path[0], fetchLimit(limit),
+		)
+		if err != nil {
+			return nil, false, err
+		}
+
+		var roots []int64
+		for rows.Next() {
+			var root int64
+			err = rows.Scan(&root)
+			if err != nil {
+				rows.Close()
+				return nil, false, err
+			}
+
+			roots = append(roots, root)
+		}
+		rows.Close()
+
+		if err = rows.Err(); err != nil {
+			return nil, false, err
+		}
+
+		hasMore = limit > 0 && len(roots) > limit
+		if hasMore {
+			roots = roots[:limit]
+		}
+
+		if len(roots) == 0 {
+			return nil, false, nil
+		}
+
+		var ids pgtype.Int8Array
+		err = ids.Set(roots)
+		if err != nil {
+			return nil, false, err
+		}
+
+		rootOrder := "ASC"
+		if cursor.Desc {
+			rootOrder = "DESC"
+		}
+
+		rows, err = p.Conn.Query(
+			fmt.Sprintf(`SELECT * FROM post WHERE path[1] = ANY($1) ORDER BY path[1] %s, path, id`, rootOrder),
+			&ids,
+		)
+		if err != nil {
+			return nil, false, err
+		}
+
+		posts, err = scanPosts(rows)
+
+		return posts, hasMore, err
+	case "score_tree":
+		rows, err := p.Conn.Query(fmt.Sprintf(selectScoreTree, op, order), threadId, cursor.Id, fetchLimit(limit))
+		if err != nil {
+			return nil, false, err
+		}
+
+		posts, err = scanPosts(rows)
+		if err != nil {
+			return nil, false, err
+		}
+	default:
+		return nil, false, models.ErrInvalidCursor
+	}
+
+	hasMore = limit > 0 && len(posts) > limit
+	if hasMore {
+		posts = posts[:limit]
+	}
+
+	if cursor.Prev {
+		for i, j := 0, len(posts)-1; i < j; i, j = i+1, j-1 {
+			posts[i], posts[j] = posts[j], posts[i]
+		}
+	}
+
+	return posts, hasMore, nil
+}
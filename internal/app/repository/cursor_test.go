@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+	"tp-db-forum/internal/app/models"
+
+	"github.com/jackc/pgx"
+)
+
+// testRepository connects to the database in TEST_DATABASE_URL, which has to
+// be set up from init.sql; the tests that need one are skipped without it.
+func testRepository(t *testing.T) (*postgresAppRepository, func()) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	config, err := pgx.ParseConnectionString(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.PreferSimpleProtocol = true
+
+	pool, err := pgx.NewConnPool(pgx.ConnPoolConfig{ConnConfig: config, MaxConnections: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &postgresAppRepository{Conn: pool}, pool.Close
+}
+
+func TestThreadsByForumCursorTies(t *testing.T) {
+	p, done := testRepository(t)
+	defer done()
+
+	suffix := time.Now().UnixNano()
+	nickname := fmt.Sprintf("tie%d", suffix)
+	if err := p.InsertUser(models.User{Nickname: nickname, FullName: "Tie", Email: nickname + "@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	forum, err := p.InsertForum(models.Forum{Slug: nickname, Title: "ties", User: nickname})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const count = 7
+	created := "2020-01-01T00:00:00.000Z"
+	for i := 0; i < count; i++ {
+		_, err := p.InsertThread(models.Thread{
+			Slug:    fmt.Sprintf("%s-%d", nickname, i),
+			Author:  nickname,
+			Created: created,
+			Forum:   forum.Slug,
+			Message: "m",
+			Title:   "t",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, desc := range []bool{false, true} {
+		const limit = 2
+
+		threads, err := p.SelectThreadsByForum(forum.Slug, models.QueryParameters{Limit: limit, Desc: desc})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		seen := map[int]int{}
+		for len(threads) != 0 {
+			for _, thread := range threads {
+				seen[thread.Id]++
+			}
+
+			cursor := models.ThreadCursor(threads[len(threads)-1], desc, false)
+			threads, _, err = p.SelectThreadsByForumCursor(forum.Slug, limit, cursor)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if len(seen) != count {
+			t.Errorf("desc=%t: paged through %d of %d threads", desc, len(seen), count)
+		}
+		for id, n := range seen {
+			if n != 1 {
+				t.Errorf("desc=%t: thread %d listed %d times", desc, id, n)
+			}
+		}
+	}
+}
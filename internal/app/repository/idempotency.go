@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"github.com/jackc/pgx/pgtype"
+	"time"
+	"tp-db-forum/internal/app/models"
+)
+
+// InsertIdempotencyKey reserves key and reports whether it did. An expired
+// key is taken over as if it didn't exist.
+func (p *postgresAppRepository) InsertIdempotencyKey(key, fingerprint string, ttl time.Duration) (bool, error) {
+	tag, err := p.Conn.Exec(
+		`INSERT INTO idempotency_key (key, fingerprint, expires)
+		VALUES ($1, $2, NOW() + $3 * INTERVAL '1 millisecond')
+		ON CONFLICT (key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint, status = NULL, body = NULL, created = NOW(), expires = EXCLUDED.expires
+		WHERE idempotency_key.expires < NOW()`,
+		key,
+		fingerprint,
+		ttl.Milliseconds(),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+func (p *postgresAppRepository) SelectIdempotencyKey(key string) (models.IdempotencyRecord, error) {
+	var record models.IdempotencyRecord
+	var status pgtype.Int4
+
+	err := p.Conn.QueryRow(
+		`SELECT key, fingerprint, status, body FROM idempotency_key WHERE key = $1`,
+		key,
+	).Scan(&record.Key, &record.Fingerprint, &status, &record.Body)
+	if err != nil {
+		return models.IdempotencyRecord{}, err
+	}
+
+	if status.Status == pgtype.Present {
+		record.Done = true
+		record.Status = int(status.Int)
+	}
+
+	return record, nil
+}
+
+func (p *postgresAppRepository) CompleteIdempotencyKey(key string, status int, body []byte) error {
+	_, err := p.Conn.Exec(`UPDATE idempotency_key SET status = $2, body = $3 WHERE key = $1`, key, status, body)
+
+	return err
+}
+
+func (p *postgresAppRepository) DeleteIdempotencyKey(key string) error {
+	_, err := p.Conn.Exec(`DELETE FROM idempotency_key WHERE key = $1`, key)
+
+	return err
+}
+
+func (p *postgresAppRepository) PruneIdempotencyKeys() error {
+	_, err := p.Conn.Exec(`DELETE FROM idempotency_key WHERE expires < NOW()`)
+
+	return err
+}
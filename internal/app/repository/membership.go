@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"github.com/jackc/pgx"
+	"tp-db-forum/internal/app/models"
+)
+
+// membershipChecks compare users_forum to what it is built from: the
+// profiles in users and the authors of threads and posts. Each query
+// selects the forum and nickname of the rows that are off, limited to the
+// forum in $1 unless it's empty, under the name drift for the fix.
+var membershipChecks = []struct {
+	problem string
+	query   string
+	fix     string
+}{
+	{
+		"stale",
+		`SELECT uf.slug AS forum, uf.nickname FROM users_forum uf
+		JOIN users u ON u.nickname = uf.nickname
+		WHERE ($1 = '' OR uf.slug = $1)
+			AND (uf.fullname, uf.about, uf.email) IS DISTINCT FROM (u.fullname, u.about, u.email)`,
+		`UPDATE users_forum uf SET fullname = u.fullname, about = u.about, email = u.email
+		FROM drift JOIN users u ON u.nickname = drift.nickname
+		WHERE uf.slug = drift.forum AND uf.nickname = drift.nickname`,
+	},
+	{
+		"missing",
+		`SELECT a.forum, a.author AS nickname FROM (
+			SELECT forum, author FROM thread WHERE $1 = '' OR forum = $1
+			UNION
+			SELECT forum, author FROM post WHERE $1 = '' OR forum = $1
+		) a
+		LEFT JOIN users_forum uf ON uf.slug = a.forum AND uf.nickname = a.author
+		WHERE uf.nickname IS NULL`,
+		`INSERT INTO users_forum (nickname, fullname, about, email, slug)
+		SELECT u.nickname, u.fullname, u.about, u.email, drift.forum FROM drift
+		JOIN users u ON u.nickname = drift.nickname
+		ON CONFLICT DO NOTHING`,
+	},
+	{
+		"extra",
+		`SELECT uf.slug AS forum, uf.nickname FROM users_forum uf
+		WHERE ($1 = '' OR uf.slug = $1)
+			AND NOT EXISTS (SELECT 1 FROM thread t WHERE t.forum = uf.slug AND t.author = uf.nickname)
+			AND NOT EXISTS (SELECT 1 FROM post p WHERE p.forum = uf.slug AND p.author = uf.nickname)`,
+		`DELETE FROM users_forum uf USING drift
+		WHERE uf.slug = drift.forum AND uf.nickname = drift.nickname`,
+	},
+}
+
+// ReconcileForumUsers compares users_forum of the forum with slug, or of
+// every forum when slug is empty, to users and to who wrote there. A fix
+// rebuilds the rows that are off, holding back writes to users, thread and
+// post meanwhile, as ReconcileCounters does.
+func (p *postgresAppRepository) ReconcileForumUsers(slug string, fix bool) (models.MembershipReport, error) {
+	report := models.MembershipReport{Fixed: fix, Drifts: []models.MembershipDrift{}}
+
+	options := &pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}
+	if fix {
+		options = &pgx.TxOptions{IsoLevel: pgx.ReadCommitted}
+	}
+
+	tx, err := p.Conn.BeginEx(context.Background(), options)
+	if err != nil {
+		return report, err
+	}
+
+	defer tx.Rollback()
+
+	if fix {
+		if _, err := tx.Exec(`LOCK TABLE users, thread, post IN SHARE MODE`); err != nil {
+			return report, err
+		}
+	}
+
+	if slug != "" {
+		if err := tx.QueryRow(`SELECT slug::text FROM forum WHERE slug=$1`, slug).Scan(&report.Forum); err != nil {
+			return report, err
+		}
+		slug = report.Forum
+	}
+
+	for _, check := range membershipChecks {
+		query := `SELECT forum::text, nickname::text FROM (` + check.query + `) drift`
+		if fix {
+			query = `WITH drift AS (` + check.query + `), fixed AS (` + check.fix + `) SELECT forum::text, nickname::text FROM drift`
+		}
+
+		rows, err := tx.Query(query, slug)
+		if err != nil {
+			return report, err
+		}
+
+		for rows.Next() {
+			drift := models.MembershipDrift{Problem: check.problem}
+			if err := rows.Scan(&drift.Forum, &drift.Nickname); err != nil {
+				rows.Close()
+				return report, err
+			}
+
+			report.Drifts = append(report.Drifts, drift)
+		}
+
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return report, err
+		}
+	}
+
+	return report, tx.Commit()
+}
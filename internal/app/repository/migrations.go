@@ -0,0 +1,502 @@
+package repository
+
+import (
+	"github.com/go-openapi/strfmt"
+	"time"
+	"tp-db-forum/internal/app/models"
+)
+
+// migrations bring a database created from an older init.sql up to date,
+// back to the first one, which had no schema_migrations. init.sql has
+// everything they add and records them as applied, so every migration has
+// to be safe to run on a database that already has it.
+var migrations = []struct {
+	version int
+	name    string
+	query   string
+}{
+	{
+		// The schema added before there were migrations: the outbox, the
+		// webhooks and the idempotency keys. The outbox triggers come with
+		// bulk_guard.
+		0, "baseline",
+		`CREATE TABLE IF NOT EXISTS outbox (
+			id         BIGSERIAL PRIMARY KEY,
+			topic      TEXT  NOT NULL,
+			payload    JSONB NOT NULL,
+			created    TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			dispatched BOOLEAN                  DEFAULT FALSE
+		);
+		CREATE TABLE IF NOT EXISTS webhook (
+			id      SERIAL PRIMARY KEY,
+			url     TEXT NOT NULL,
+			secret  TEXT NOT NULL,
+			topics  TEXT[]                   DEFAULT ARRAY []::TEXT[],
+			created TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+		);
+		CREATE TABLE IF NOT EXISTS webhook_delivery (
+			id           BIGSERIAL PRIMARY KEY,
+			id_outbox    BIGINT NOT NULL,
+			id_webhook   INT    NOT NULL,
+			status       TEXT                     DEFAULT 'pending',
+			attempts     INT                      DEFAULT 0,
+			next_attempt TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			last_error   TEXT,
+
+			FOREIGN KEY (id_outbox) REFERENCES "outbox" (id),
+			FOREIGN KEY (id_webhook) REFERENCES "webhook" (id) ON DELETE CASCADE
+		);
+		CREATE UNLOGGED TABLE IF NOT EXISTS idempotency_key (
+			key         TEXT PRIMARY KEY,
+			fingerprint TEXT                     NOT NULL,
+			status      INT,
+			body        BYTEA,
+			created     TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			expires     TIMESTAMP WITH TIME ZONE NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS outbox_not_dispatched ON outbox (id) WHERE NOT dispatched;
+		CREATE INDEX IF NOT EXISTS webhook_delivery_pending ON webhook_delivery (next_attempt) WHERE status = 'pending';
+		CREATE INDEX IF NOT EXISTS webhook_delivery_outbox ON webhook_delivery (id_outbox);
+		CREATE INDEX IF NOT EXISTS idempotency_key_expires ON idempotency_key (expires);
+		CREATE OR REPLACE FUNCTION writeOutbox() RETURNS TRIGGER AS
+		$write_outbox$
+		BEGIN
+			INSERT INTO outbox (topic, payload)
+			VALUES (TG_ARGV[0] || CASE TG_OP WHEN 'INSERT' THEN '.created' ELSE '.updated' END, row_to_json(NEW));
+			return NEW;
+		end
+		$write_outbox$ LANGUAGE plpgsql`,
+	},
+	{
+		1, "moderation",
+		`CREATE UNLOGGED TABLE IF NOT EXISTS thread_lock (
+			thread INT PRIMARY KEY,
+			locked TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+			FOREIGN KEY (thread) REFERENCES "thread" (id)
+		);
+		CREATE UNLOGGED TABLE IF NOT EXISTS moderated (
+			kind    TEXT   NOT NULL,
+			id      BIGINT NOT NULL,
+			title   TEXT,
+			message TEXT   NOT NULL,
+			deleted TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+			PRIMARY KEY (kind, id)
+		)`,
+	},
+	{
+		2, "users_forum_sync",
+		`CREATE OR REPLACE FUNCTION sync_user_forum() RETURNS TRIGGER AS
+		$sync_user_forum$
+		BEGIN
+			UPDATE users_forum SET fullname = NEW.fullname, about = NEW.about, email = NEW.email
+			WHERE nickname = NEW.nickname;
+			return NEW;
+		end
+		$sync_user_forum$ LANGUAGE plpgsql;
+		DROP TRIGGER IF EXISTS users_update_user_forum ON users;
+		CREATE TRIGGER users_update_user_forum
+			AFTER UPDATE OF fullname, about, email
+			ON users
+			FOR EACH ROW
+		EXECUTE PROCEDURE sync_user_forum();
+		UPDATE users_forum uf SET fullname = u.fullname, about = u.about, email = u.email
+		FROM users u
+		WHERE u.nickname = uf.nickname AND (uf.fullname, uf.about, uf.email) IS DISTINCT FROM (u.fullname, u.about, u.email)`,
+	},
+	{
+		// Votes cast before this have no time, rather than all the time of
+		// the migration.
+		3, "forum_stats",
+		`ALTER TABLE votes ADD COLUMN IF NOT EXISTS created TIMESTAMP WITH TIME ZONE;
+		ALTER TABLE votes ALTER COLUMN created SET DEFAULT NOW();
+		CREATE INDEX IF NOT EXISTS post_forum_created ON post (forum, created)`,
+	},
+	{
+		// Rows of table_count add up to the row count of a table; they are
+		// spread over shards so concurrent inserts rarely wait on each
+		// other. The counts start from the tables as they are, once.
+		4, "table_count",
+		`CREATE UNLOGGED TABLE IF NOT EXISTS table_count (
+			name  TEXT   NOT NULL,
+			shard INT    NOT NULL,
+			n     BIGINT NOT NULL,
+
+			PRIMARY KEY (name, shard)
+		);
+		CREATE OR REPLACE FUNCTION count_rows() RETURNS TRIGGER AS
+		$count_rows$
+		DECLARE
+			delta BIGINT;
+		BEGIN
+			IF TG_OP = 'INSERT' THEN
+				SELECT count(*) FROM new_rows INTO delta;
+			ELSE
+				SELECT -count(*) FROM old_rows INTO delta;
+			END IF;
+			IF delta <> 0 THEN
+				INSERT INTO table_count (name, shard, n) VALUES (TG_TABLE_NAME, pg_backend_pid() % 16, delta)
+				ON CONFLICT (name, shard) DO UPDATE SET n = table_count.n + EXCLUDED.n;
+			END IF;
+			RETURN NULL;
+		end
+		$count_rows$ LANGUAGE plpgsql;
+		DROP TRIGGER IF EXISTS users_count_insert ON users;
+		CREATE TRIGGER users_count_insert AFTER INSERT ON users
+			REFERENCING NEW TABLE AS new_rows FOR EACH STATEMENT EXECUTE PROCEDURE count_rows();
+		DROP TRIGGER IF EXISTS users_count_delete ON users;
+		CREATE TRIGGER users_count_delete AFTER DELETE ON users
+			REFERENCING OLD TABLE AS old_rows FOR EACH STATEMENT EXECUTE PROCEDURE count_rows();
+		DROP TRIGGER IF EXISTS forum_count_insert ON forum;
+		CREATE TRIGGER forum_count_insert AFTER INSERT ON forum
+			REFERENCING NEW TABLE AS new_rows FOR EACH STATEMENT EXECUTE PROCEDURE count_rows();
+		DROP TRIGGER IF EXISTS forum_count_delete ON forum;
+		CREATE TRIGGER forum_count_delete AFTER DELETE ON forum
+			REFERENCING OLD TABLE AS old_rows FOR EACH STATEMENT EXECUTE PROCEDURE count_rows();
+		DROP TRIGGER IF EXISTS thread_count_insert ON thread;
+		CREATE TRIGGER thread_count_insert AFTER INSERT ON thread
+			REFERENCING NEW TABLE AS new_rows FOR EACH STATEMENT EXECUTE PROCEDURE count_rows();
+		DROP TRIGGER IF EXISTS thread_count_delete ON thread;
+		CREATE TRIGGER thread_count_delete AFTER DELETE ON thread
+			REFERENCING OLD TABLE AS old_rows FOR EACH STATEMENT EXECUTE PROCEDURE count_rows();
+		DROP TRIGGER IF EXISTS post_count_insert ON post;
+		CREATE TRIGGER post_count_insert AFTER INSERT ON post
+			REFERENCING NEW TABLE AS new_rows FOR EACH STATEMENT EXECUTE PROCEDURE count_rows();
+		DROP TRIGGER IF EXISTS post_count_delete ON post;
+		CREATE TRIGGER post_count_delete AFTER DELETE ON post
+			REFERENCING OLD TABLE AS old_rows FOR EACH STATEMENT EXECUTE PROCEDURE count_rows();
+		INSERT INTO table_count (name, shard, n)
+		SELECT name, 0, n FROM (VALUES
+			('users', (SELECT count(*) FROM users)),
+			('forum', (SELECT count(*) FROM forum)),
+			('thread', (SELECT count(*) FROM thread)),
+			('post', (SELECT count(*) FROM post))
+		) c (name, n)
+		WHERE NOT EXISTS (SELECT 1 FROM table_count)`,
+	},
+	{
+		5, "reputation",
+		`CREATE UNLOGGED TABLE IF NOT EXISTS reputation (
+			nickname CITEXT NOT NULL,
+			forum    CITEXT NOT NULL,
+			score    BIGINT NOT NULL DEFAULT 0,
+
+			PRIMARY KEY (nickname, forum)
+		);
+		CREATE INDEX IF NOT EXISTS reputation_forum_score ON reputation (forum, score DESC);
+		CREATE INDEX IF NOT EXISTS votes_created ON votes (created);
+		CREATE OR REPLACE FUNCTION vote_reputation() RETURNS TRIGGER AS
+		$vote_reputation$
+		DECLARE
+			delta BIGINT;
+		BEGIN
+			IF TG_OP = 'INSERT' THEN
+				delta := NEW.voice;
+			ELSE
+				delta := NEW.voice - OLD.voice;
+			END IF;
+			IF delta <> 0 THEN
+				INSERT INTO reputation (nickname, forum, score)
+				SELECT author, forum, delta FROM thread WHERE id = NEW.id_thread
+				ON CONFLICT (nickname, forum) DO UPDATE SET score = reputation.score + EXCLUDED.score;
+			END IF;
+			RETURN NULL;
+		end
+		$vote_reputation$ LANGUAGE plpgsql;
+		DROP TRIGGER IF EXISTS votes_reputation ON votes;
+		CREATE TRIGGER votes_reputation
+			AFTER INSERT OR UPDATE OF voice
+			ON votes
+			FOR EACH ROW
+		EXECUTE PROCEDURE vote_reputation();
+		INSERT INTO reputation (nickname, forum, score)
+		SELECT t.author, t.forum, sum(v.voice) FROM votes v JOIN thread t ON t.id = v.id_thread
+		WHERE NOT EXISTS (SELECT 1 FROM reputation)
+		GROUP BY t.author, t.forum`,
+	},
+	{
+		6, "post_votes",
+		`CREATE UNLOGGED TABLE IF NOT EXISTS post_votes (
+			nickname CITEXT NOT NULL,
+			voice    INT    NOT NULL,
+			id_post  BIGINT NOT NULL,
+			created  TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+			FOREIGN KEY (nickname) REFERENCES "users" (nickname),
+			FOREIGN KEY (id_post) REFERENCES "post" (id),
+			UNIQUE (nickname, id_post)
+		);
+		CREATE INDEX IF NOT EXISTS post_votes_created ON post_votes (created);
+		ALTER TABLE post ADD COLUMN IF NOT EXISTS votes INT NOT NULL DEFAULT 0;
+		CREATE OR REPLACE FUNCTION post_vote() RETURNS TRIGGER AS
+		$post_vote$
+		DECLARE
+			delta BIGINT;
+		BEGIN
+			IF TG_OP = 'INSERT' THEN
+				delta := NEW.voice;
+			ELSE
+				delta := NEW.voice - OLD.voice;
+			END IF;
+			IF delta <> 0 THEN
+				WITH voted AS (
+					UPDATE post SET votes = votes + delta WHERE id = NEW.id_post RETURNING author, forum
+				)
+				INSERT INTO reputation (nickname, forum, score)
+				SELECT author, forum, delta FROM voted
+				ON CONFLICT (nickname, forum) DO UPDATE SET score = reputation.score + EXCLUDED.score;
+			END IF;
+			RETURN NULL;
+		end
+		$post_vote$ LANGUAGE plpgsql;
+		DROP TRIGGER IF EXISTS post_votes_count ON post_votes;
+		CREATE TRIGGER post_votes_count
+			AFTER INSERT OR UPDATE OF voice
+			ON post_votes
+			FOR EACH ROW
+		EXECUTE PROCEDURE post_vote();
+		DROP TRIGGER IF EXISTS post_votes_outbox ON post_votes;
+		CREATE TRIGGER post_votes_outbox
+			AFTER INSERT OR UPDATE OF voice
+			ON post_votes
+			FOR EACH ROW
+		EXECUTE PROCEDURE writeOutbox('post_vote')`,
+	},
+	{
+		// webhook_delivery references the other two, so it goes last.
+		7, "logged_outbox",
+		`ALTER TABLE outbox SET LOGGED;
+		ALTER TABLE webhook SET LOGGED;
+		ALTER TABLE webhook_delivery SET LOGGED`,
+	},
+	{
+		8, "thread_forum_created_id",
+		`CREATE INDEX IF NOT EXISTS thr_forum_created_id ON thread (forum, created, id)`,
+	},
+	{
+		// A bulk load sets forum.bulk for its transaction and does the work
+		// of these triggers itself, once for all of its rows.
+		9, "bulk_guard",
+		`DROP TRIGGER IF EXISTS addThreadInForum ON thread;
+		CREATE TRIGGER addThreadInForum BEFORE INSERT ON thread
+			FOR EACH ROW WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+		EXECUTE PROCEDURE updateCountOfThreads();
+		DROP TRIGGER IF EXISTS add_voice ON votes;
+		CREATE TRIGGER add_voice BEFORE INSERT ON votes
+			FOR EACH ROW WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+		EXECUTE PROCEDURE insertVotes();
+		DROP TRIGGER IF EXISTS votes_reputation ON votes;
+		CREATE TRIGGER votes_reputation AFTER INSERT OR UPDATE OF voice ON votes
+			FOR EACH ROW WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+		EXECUTE PROCEDURE vote_reputation();
+		DROP TRIGGER IF EXISTS update_path_trigger ON post;
+		CREATE TRIGGER update_path_trigger BEFORE INSERT ON post
+			FOR EACH ROW WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+		EXECUTE PROCEDURE updatePath();
+		DROP TRIGGER IF EXISTS thread_insert_user_forum ON thread;
+		CREATE TRIGGER thread_insert_user_forum AFTER INSERT ON thread
+			FOR EACH ROW WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+		EXECUTE PROCEDURE update_user_forum();
+		DROP TRIGGER IF EXISTS post_insert_user_forum ON post;
+		CREATE TRIGGER post_insert_user_forum AFTER INSERT ON post
+			FOR EACH ROW WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+		EXECUTE PROCEDURE update_user_forum();
+		DROP TRIGGER IF EXISTS users_count_insert ON users;
+		CREATE TRIGGER users_count_insert AFTER INSERT ON users
+			REFERENCING NEW TABLE AS new_rows FOR EACH STATEMENT WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+		EXECUTE PROCEDURE count_rows();
+		DROP TRIGGER IF EXISTS forum_count_insert ON forum;
+		CREATE TRIGGER forum_count_insert AFTER INSERT ON forum
+			REFERENCING NEW TABLE AS new_rows FOR EACH STATEMENT WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+		EXECUTE PROCEDURE count_rows();
+		DROP TRIGGER IF EXISTS thread_count_insert ON thread;
+		CREATE TRIGGER thread_count_insert AFTER INSERT ON thread
+			REFERENCING NEW TABLE AS new_rows FOR EACH STATEMENT WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+		EXECUTE PROCEDURE count_rows();
+		DROP TRIGGER IF EXISTS post_count_insert ON post;
+		CREATE TRIGGER post_count_insert AFTER INSERT ON post
+			REFERENCING NEW TABLE AS new_rows FOR EACH STATEMENT WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+		EXECUTE PROCEDURE count_rows();
+		DROP TRIGGER IF EXISTS users_outbox ON users;
+		CREATE TRIGGER users_outbox AFTER INSERT OR UPDATE OF fullname, about, email ON users
+			FOR EACH ROW WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+		EXECUTE PROCEDURE writeOutbox('user');
+		DROP TRIGGER IF EXISTS forum_outbox ON forum;
+		CREATE TRIGGER forum_outbox AFTER INSERT ON forum
+			FOR EACH ROW WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+		EXECUTE PROCEDURE writeOutbox('forum');
+		DROP TRIGGER IF EXISTS thread_outbox ON thread;
+		CREATE TRIGGER thread_outbox AFTER INSERT OR UPDATE OF title, message ON thread
+			FOR EACH ROW WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+		EXECUTE PROCEDURE writeOutbox('thread');
+		DROP TRIGGER IF EXISTS post_outbox ON post;
+		CREATE TRIGGER post_outbox AFTER INSERT OR UPDATE OF message ON post
+			FOR EACH ROW WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+		EXECUTE PROCEDURE writeOutbox('post');
+		DROP TRIGGER IF EXISTS votes_outbox ON votes;
+		CREATE TRIGGER votes_outbox AFTER INSERT OR UPDATE OF voice ON votes
+			FOR EACH ROW WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+		EXECUTE PROCEDURE writeOutbox('vote')`,
+	},
+	{
+		// Bulk loads take post votes too.
+		10, "bulk_guard_post_votes",
+		`DROP TRIGGER IF EXISTS post_votes_count ON post_votes;
+		CREATE TRIGGER post_votes_count AFTER INSERT OR UPDATE OF voice ON post_votes
+			FOR EACH ROW WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+		EXECUTE PROCEDURE post_vote();
+		DROP TRIGGER IF EXISTS post_votes_outbox ON post_votes;
+		CREATE TRIGGER post_votes_outbox AFTER INSERT OR UPDATE OF voice ON post_votes
+			FOR EACH ROW WHEN (current_setting('forum.bulk', true) IS DISTINCT FROM 'on')
+		EXECUTE PROCEDURE writeOutbox('post_vote')`,
+	},
+	{
+		// Events nobody subscribes to aren't written; FanOutOutbox matches
+		// topics the same way.
+		11, "subscribed_outbox",
+		`CREATE OR REPLACE FUNCTION writeOutbox() RETURNS TRIGGER AS
+		$write_outbox$
+		DECLARE
+			event TEXT := TG_ARGV[0] || CASE TG_OP WHEN 'INSERT' THEN '.created' ELSE '.updated' END;
+		BEGIN
+			IF EXISTS (SELECT 1 FROM webhook w WHERE cardinality(w.topics) = 0 OR event = ANY(w.topics) OR TG_ARGV[0] = ANY(w.topics)) THEN
+				INSERT INTO outbox (topic, payload) VALUES (event, row_to_json(NEW));
+			END IF;
+			return NEW;
+		end
+		$write_outbox$ LANGUAGE plpgsql`,
+	},
+	{
+		// Posts to a locked thread fail in the insert itself; InsertPosts
+		// turns the error into models.ErrThreadLocked.
+		12, "post_thread_lock",
+		`CREATE OR REPLACE FUNCTION updatePath() RETURNS TRIGGER AS
+		$update_path$
+		DECLARE
+			parentPath         BIGINT[];
+			first_parent_thread INT;
+		BEGIN
+			IF EXISTS (SELECT 1 FROM thread_lock WHERE thread = NEW.thread) THEN
+				RAISE EXCEPTION 'thread is locked' USING ERRCODE = '00423';
+			end if;
+
+			IF (NEW.parent IS NULL) THEN
+				NEW.path := array_append(new.path, new.id);
+			ELSE
+				SELECT path FROM post WHERE id = new.parent INTO parentPath;
+				SELECT thread FROM post WHERE id = parentPath[1] INTO first_parent_thread;
+				IF NOT FOUND OR first_parent_thread != NEW.thread THEN
+					RAISE EXCEPTION 'parent is from different thread' USING ERRCODE = '00409';
+				end if;
+
+				NEW.path := NEW.path || parentPath || new.id;
+			end if;
+			UPDATE forum SET Posts=Posts + 1 WHERE forum.slug = new.forum;
+			RETURN new;
+		end
+		$update_path$ LANGUAGE plpgsql`,
+	},
+}
+
+// migrationLock keeps two instances starting at once from migrating twice.
+const migrationLock = 7342001
+
+const createMigrations = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INT PRIMARY KEY,
+	name    TEXT NOT NULL,
+	applied TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+)`
+
+// Migrate applies the migrations the database doesn't have yet, in one
+// transaction, and returns them.
+func (p *postgresAppRepository) Migrate() ([]models.Migration, error) {
+	applied := []models.Migration{}
+
+	tx, err := p.Conn.Begin()
+	if err != nil {
+		return applied, err
+	}
+
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, migrationLock); err != nil {
+		return applied, err
+	}
+
+	if _, err := tx.Exec(createMigrations); err != nil {
+		return applied, err
+	}
+
+	var version int
+	// A database without any, like one from the first init.sql, needs the
+	// baseline too.
+	if err := tx.QueryRow(`SELECT COALESCE(max(version), -1) FROM schema_migrations`).Scan(&version); err != nil {
+		return applied, err
+	}
+
+	now := strfmt.DateTime(time.Now().UTC()).String()
+	for _, migration := range migrations {
+		if migration.version <= version {
+			continue
+		}
+
+		if _, err := tx.Exec(migration.query); err != nil {
+			return []models.Migration{}, err
+		}
+
+		_, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, migration.version, migration.name)
+		if err != nil {
+			return []models.Migration{}, err
+		}
+
+		applied = append(applied, models.Migration{Version: migration.version, Name: migration.name, Applied: now})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return []models.Migration{}, err
+	}
+
+	return applied, nil
+}
+
+// SelectMigrations lists every migration, with when it was applied for the
+// ones that were.
+func (p *postgresAppRepository) SelectMigrations() ([]models.Migration, error) {
+	result := make([]models.Migration, 0, len(migrations))
+	for _, migration := range migrations {
+		result = append(result, models.Migration{Version: migration.version, Name: migration.name})
+	}
+
+	var found bool
+	if err := p.Conn.QueryRow(`SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&found); err != nil || !found {
+		return result, err
+	}
+
+	rows, err := p.Conn.Query(`SELECT version, applied FROM schema_migrations`)
+	if err != nil {
+		return result, err
+	}
+
+	defer rows.Close()
+
+	applied := map[int]string{}
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return result, err
+		}
+
+		applied[version] = strfmt.DateTime(at.UTC()).String()
+	}
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+
+	for i := range result {
+		result[i].Applied = applied[result[i].Version]
+	}
+
+	return result, nil
+}
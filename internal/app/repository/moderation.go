@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/pgtype"
+	"strings"
+	"tp-db-forum/internal/app/models"
+)
+
+// deletedContent replaces the title and message of deleted threads and
+// posts; the originals wait in moderated for a restore.
+const deletedContent = "[deleted]"
+
+func exists(tx *pgx.Tx, table string, id int) error {
+	var found bool
+	err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM `+table+` WHERE id=$1)`, id).Scan(&found)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+func (p *postgresAppRepository) LockThread(id int, locked bool) error {
+	tx, err := p.Conn.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := exists(tx, "thread", id); err != nil {
+		return err
+	}
+
+	if locked {
+		_, err = tx.Exec(`INSERT INTO thread_lock (thread) VALUES ($1) ON CONFLICT DO NOTHING`, id)
+	} else {
+		_, err = tx.Exec(`DELETE FROM thread_lock WHERE thread=$1`, id)
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ModerateThread deletes or restores a thread. Both are no-ops when the
+// thread already is in that state.
+func (p *postgresAppRepository) ModerateThread(id int, deleted bool) error {
+	tx, err := p.Conn.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := exists(tx, "thread", id); err != nil {
+		return err
+	}
+
+	if deleted {
+		var tag pgx.CommandTag
+		tag, err = tx.Exec(
+			`INSERT INTO moderated (kind, id, title, message)
+			SELECT $1, id, title, message FROM thread WHERE id=$2
+			ON CONFLICT DO NOTHING`,
+			models.ModerationThread,
+			id,
+		)
+		if err == nil && tag.RowsAffected() != 0 {
+			_, err = tx.Exec(`UPDATE thread SET title=$1, message=$1 WHERE id=$2`, deletedContent, id)
+		}
+	} else {
+		_, err = tx.Exec(
+			`WITH original AS (DELETE FROM moderated WHERE kind=$1 AND id=$2 RETURNING title, message)
+			UPDATE thread SET title=original.title, message=original.message FROM original WHERE thread.id=$2`,
+			models.ModerationThread,
+			id,
+		)
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ModeratePost deletes or restores a post, like ModerateThread.
+func (p *postgresAppRepository) ModeratePost(id int, deleted bool) error {
+	tx, err := p.Conn.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := exists(tx, "post", id); err != nil {
+		return err
+	}
+
+	if deleted {
+		var tag pgx.CommandTag
+		tag, err = tx.Exec(
+			`INSERT INTO moderated (kind, id, message)
+			SELECT $1, id, message FROM post WHERE id=$2
+			ON CONFLICT DO NOTHING`,
+			models.ModerationPost,
+			id,
+		)
+		if err == nil && tag.RowsAffected() != 0 {
+			_, err = tx.Exec(`UPDATE post SET message=$1 WHERE id=$2`, deletedContent, id)
+		}
+	} else {
+		_, err = tx.Exec(
+			`WITH original AS (DELETE FROM moderated WHERE kind=$1 AND id=$2 RETURNING message)
+			UPDATE post SET message=original.message FROM original WHERE post.id=$2`,
+			models.ModerationPost,
+			id,
+		)
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (p *postgresAppRepository) SelectModeration(kind string, id int) (models.Moderation, error) {
+	var moderation models.Moderation
+	err := p.Conn.QueryRow(
+		`SELECT $1 = 'thread' AND EXISTS (SELECT 1 FROM thread_lock WHERE thread=$2),
+			EXISTS (SELECT 1 FROM moderated WHERE kind=$1 AND id=$2)`,
+		kind,
+		id,
+	).Scan(&moderation.Locked, &moderation.Deleted)
+
+	return moderation, err
+}
+
+// RenameUser moves a user to a new nickname along with everything that
+// refers to it. The foreign keys don't cascade, so the user is copied
+// under the new nickname first and the old row goes last. A nickname that
+// differs only in case is the same key to the CITEXT columns, so it is
+// updated in place instead.
+func (p *postgresAppRepository) RenameUser(nickname, newNickname string) (models.User, error) {
+	tx, err := p.Conn.Begin()
+	if err != nil {
+		return models.User{}, err
+	}
+
+	defer tx.Rollback()
+
+	var user models.User
+	var about, email pgtype.Text
+	err = tx.QueryRow(
+		`SELECT nickname, fullname, about, email FROM users WHERE nickname=$1 FOR UPDATE`,
+		nickname,
+	).Scan(&user.Nickname, &user.FullName, &about, &email)
+	if err != nil {
+		return models.User{}, err
+	}
+	user.About, user.Email = about.String, email.String
+
+	if strings.EqualFold(user.Nickname, newNickname) {
+		for _, query := range []string{
+			`UPDATE users SET nickname=$2 WHERE nickname=$1`,
+			`UPDATE forum SET "user"=$2 WHERE "user"=$1`,
+			`UPDATE thread SET author=$2 WHERE author=$1`,
+			`UPDATE post SET author=$2 WHERE author=$1`,
+			`UPDATE votes SET nickname=$2 WHERE nickname=$1`,
+			`UPDATE post_votes SET nickname=$2 WHERE nickname=$1`,
+			`UPDATE users_forum SET nickname=$2 WHERE nickname=$1`,
+			`UPDATE reputation SET nickname=$2 WHERE nickname=$1`,
+		} {
+			if _, err := tx.Exec(query, user.Nickname, newNickname); err != nil {
+				return models.User{}, err
+			}
+		}
+
+		user.Nickname = newNickname
+
+		return user, tx.Commit()
+	}
+
+	// The email is unique too, so it moves over once the old row is gone.
+	_, err = tx.Exec(
+		`INSERT INTO users (nickname, fullname, about) VALUES ($1, $2, $3)`,
+		newNickname,
+		user.FullName,
+		about,
+	)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	for _, query := range []string{
+		`UPDATE forum SET "user"=$2 WHERE "user"=$1`,
+		`UPDATE thread SET author=$2 WHERE author=$1`,
+		`UPDATE post SET author=$2 WHERE author=$1`,
+		`UPDATE votes SET nickname=$2 WHERE nickname=$1`,
+		`UPDATE post_votes SET nickname=$2 WHERE nickname=$1`,
+		`UPDATE users_forum SET nickname=$2 WHERE nickname=$1`,
+		`UPDATE reputation SET nickname=$2 WHERE nickname=$1`,
+		`DELETE FROM users WHERE nickname=$1`,
+	} {
+		if _, err := tx.Exec(query, user.Nickname, newNickname); err != nil {
+			return models.User{}, err
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET email=$1 WHERE nickname=$2`, email, newNickname); err != nil {
+		return models.User{}, err
+	}
+
+	user.Nickname = newNickname
+
+	return user, tx.Commit()
+}
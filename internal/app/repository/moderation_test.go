@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"tp-db-forum/internal/app/models"
+)
+
+func TestRenameUserCase(t *testing.T) {
+	p, done := testRepository(t)
+	defer done()
+
+	nickname := fmt.Sprintf("case%d", time.Now().UnixNano())
+	if err := p.InsertUser(models.User{Nickname: nickname, FullName: "Case", Email: nickname + "@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	forum, err := p.InsertForum(models.Forum{Slug: nickname, Title: "case", User: nickname})
+	if err != nil {
+		t.Fatal(err)
+	}
+	thread, err := p.InsertThread(models.Thread{Slug: nickname, Author: nickname, Forum: forum.Slug, Message: "m", Title: "t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	renamed := strings.ToUpper(nickname[:1]) + nickname[1:]
+	user, err := p.RenameUser(nickname, renamed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Nickname != renamed || user.Email != nickname+"@example.com" {
+		t.Errorf("renamed user is %+v", user)
+	}
+
+	thread, err = p.SelectThreadById(thread.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thread.Author != renamed {
+		t.Errorf("thread author is %s, want %s", thread.Author, renamed)
+	}
+}
+
+func TestInsertPostsLocked(t *testing.T) {
+	p, done := testRepository(t)
+	defer done()
+
+	nickname := fmt.Sprintf("lock%d", time.Now().UnixNano())
+	if err := p.InsertUser(models.User{Nickname: nickname, FullName: "Lock", Email: nickname + "@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	forum, err := p.InsertForum(models.Forum{Slug: nickname, Title: "lock", User: nickname})
+	if err != nil {
+		t.Fatal(err)
+	}
+	thread, err := p.InsertThread(models.Thread{Slug: nickname, Author: nickname, Forum: forum.Slug, Message: "m", Title: "t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.LockThread(thread.Id, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.InsertPosts([]models.Post{{Author: nickname, Message: "a"}}, thread.Id, forum.Slug); err != models.ErrThreadLocked {
+		t.Errorf("posting to a locked thread got %v", err)
+	}
+
+	if err := p.LockThread(thread.Id, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.InsertPosts([]models.Post{{Author: nickname, Message: "a"}}, thread.Id, forum.Slug); err != nil {
+		t.Errorf("posting to an unlocked thread got %v", err)
+	}
+}
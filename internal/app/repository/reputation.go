@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"time"
+	"tp-db-forum/internal/app/models"
+)
+
+func (p *postgresAppRepository) SelectReputation(nickname string) (int64, error) {
+	var reputation int64
+	err := p.Conn.QueryRow(
+		`SELECT COALESCE(sum(score), 0)::bigint FROM reputation WHERE nickname=$1`,
+		nickname,
+	).Scan(&reputation)
+
+	return reputation, err
+}
+
+// SelectLeaderboard reads the all time leaderboards from reputation, which
+// the votes triggers keep, and counts the windowed ones from votes and
+// post_votes.
+func (p *postgresAppRepository) SelectLeaderboard(options models.LeaderboardOptions) (models.Leaderboard, error) {
+	leaderboard := models.Leaderboard{Window: options.Window, Entries: []models.LeaderboardEntry{}}
+
+	if options.Forum != "" {
+		err := p.Conn.QueryRow(`SELECT slug::text FROM forum WHERE slug=$1`, options.Forum).Scan(&leaderboard.Forum)
+		if err != nil {
+			return leaderboard, err
+		}
+	}
+
+	var query string
+	var args []interface{}
+	since, windowed := options.Since(time.Now())
+	switch {
+	case !windowed && leaderboard.Forum == "":
+		query = `SELECT nickname::text, sum(score)::bigint FROM reputation
+		GROUP BY nickname ORDER BY 2 DESC, 1 LIMIT $1`
+		args = []interface{}{options.Limit}
+	case !windowed:
+		query = `SELECT nickname::text, score FROM reputation WHERE forum=$1
+		ORDER BY score DESC, nickname LIMIT $2`
+		args = []interface{}{leaderboard.Forum, options.Limit}
+	case leaderboard.Forum == "":
+		query = `SELECT author::text, sum(voice)::bigint FROM (
+			SELECT t.author, v.voice FROM votes v JOIN thread t ON t.id = v.id_thread
+			WHERE v.created >= $1
+			UNION ALL
+			SELECT p.author, v.voice FROM post_votes v JOIN post p ON p.id = v.id_post
+			WHERE v.created >= $1
+		) v GROUP BY author ORDER BY 2 DESC, 1 LIMIT $2`
+		args = []interface{}{since.UTC(), options.Limit}
+	default:
+		query = `SELECT author::text, sum(voice)::bigint FROM (
+			SELECT t.author, v.voice FROM votes v JOIN thread t ON t.id = v.id_thread
+			WHERE v.created >= $1 AND t.forum = $2
+			UNION ALL
+			SELECT p.author, v.voice FROM post_votes v JOIN post p ON p.id = v.id_post
+			WHERE v.created >= $1 AND p.forum = $2
+		) v GROUP BY author ORDER BY 2 DESC, 1 LIMIT $3`
+		args = []interface{}{since.UTC(), leaderboard.Forum, options.Limit}
+	}
+
+	rows, err := p.Conn.Query(query, args...)
+	if err != nil {
+		return leaderboard, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.LeaderboardEntry
+		if err := rows.Scan(&entry.Nickname, &entry.Reputation); err != nil {
+			return leaderboard, err
+		}
+
+		leaderboard.Entries = append(leaderboard.Entries, entry)
+	}
+
+	return leaderboard, rows.Err()
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"github.com/go-openapi/strfmt"
+	"github.com/jackc/pgx"
+	"time"
+	"tp-db-forum/internal/app/models"
+)
+
+// The queries of SelectForumStats take the forum as $1 and the window as
+// $2 and $3, then the bucket or the limit of the top list as $4.
+const (
+	selectActivity = `WITH buckets AS (
+		SELECT generate_series($2::timestamptz AT TIME ZONE 'UTC', ($3::timestamptz AT TIME ZONE 'UTC') - interval '1 microsecond', ('1 ' || $4)::interval) AS start
+	), posts AS (
+		SELECT date_trunc($4, created AT TIME ZONE 'UTC') AS start, author FROM post
+		WHERE forum = $1 AND created >= $2 AND created < $3
+	), threads AS (
+		SELECT date_trunc($4, created AT TIME ZONE 'UTC') AS start, author FROM thread
+		WHERE forum = $1 AND created >= $2 AND created < $3
+	), votes AS (
+		SELECT date_trunc($4, v.created AT TIME ZONE 'UTC') AS start, v.nickname AS author FROM votes v
+		JOIN thread t ON t.id = v.id_thread
+		WHERE t.forum = $1 AND v.created >= $2 AND v.created < $3
+	), users AS (
+		SELECT start, count(DISTINCT author) AS n FROM (
+			SELECT start, author FROM posts
+			UNION ALL SELECT start, author FROM threads
+			UNION ALL SELECT start, author FROM votes
+		) a GROUP BY start
+	)
+	SELECT b.start, COALESCE(p.n, 0), COALESCE(t.n, 0), COALESCE(u.n, 0), COALESCE(v.n, 0)
+	FROM buckets b
+	LEFT JOIN (SELECT start, count(*) AS n FROM posts GROUP BY start) p ON p.start = b.start
+	LEFT JOIN (SELECT start, count(*) AS n FROM threads GROUP BY start) t ON t.start = b.start
+	LEFT JOIN users u ON u.start = b.start
+	LEFT JOIN (SELECT start, count(*) AS n FROM votes GROUP BY start) v ON v.start = b.start
+	ORDER BY b.start`
+
+	selectTopPosters = `SELECT author::text, count(*) FROM post
+	WHERE forum = $1 AND created >= $2 AND created < $3
+	GROUP BY author ORDER BY count(*) DESC, author LIMIT $4`
+
+	selectBusiestThreads = `SELECT t.id, COALESCE(t.slug, '')::text, t.title, c.n FROM (
+		SELECT thread, count(*) AS n FROM post
+		WHERE forum = $1 AND created >= $2 AND created < $3
+		GROUP BY thread ORDER BY count(*) DESC, thread LIMIT $4
+	) c JOIN thread t ON t.id = c.thread
+	ORDER BY c.n DESC, t.id`
+
+	selectDepths = `SELECT array_length(path, 1) - 1, count(*) FROM post
+	WHERE forum = $1 AND created >= $2 AND created < $3
+	GROUP BY 1 ORDER BY 1`
+)
+
+// SelectForumStats reads the activity of the forum with slug from one
+// snapshot. The options have to be normalized.
+func (p *postgresAppRepository) SelectForumStats(slug string, options models.StatsOptions) (models.ForumStats, error) {
+	stats := models.ForumStats{
+		Bucket:         options.Bucket,
+		Since:          strfmt.DateTime(options.Since.UTC()).String(),
+		Until:          strfmt.DateTime(options.Until.UTC()).String(),
+		Activity:       []models.ActivityBucket{},
+		TopPosters:     []models.TopPoster{},
+		BusiestThreads: []models.BusyThread{},
+		Depths:         []models.DepthCount{},
+		Generated:      strfmt.DateTime(time.Now().UTC()).String(),
+	}
+
+	tx, err := p.Conn.BeginEx(context.Background(), &pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return stats, err
+	}
+
+	defer tx.Rollback()
+
+	if err := tx.QueryRow(`SELECT slug::text FROM forum WHERE slug=$1`, slug).Scan(&stats.Forum); err != nil {
+		return stats, err
+	}
+
+	window := []interface{}{stats.Forum, options.Since.UTC(), options.Until.UTC()}
+
+	err = queryRows(tx, selectActivity, append(window, options.Bucket), func(rows *pgx.Rows) error {
+		var bucket models.ActivityBucket
+		var start time.Time
+		if err := rows.Scan(&start, &bucket.Posts, &bucket.Threads, &bucket.Users, &bucket.Votes); err != nil {
+			return err
+		}
+
+		bucket.Start = strfmt.DateTime(start.UTC()).String()
+		stats.Activity = append(stats.Activity, bucket)
+
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	err = queryRows(tx, selectTopPosters, append(window, options.Limit), func(rows *pgx.Rows) error {
+		var poster models.TopPoster
+		if err := rows.Scan(&poster.Nickname, &poster.Posts); err != nil {
+			return err
+		}
+
+		stats.TopPosters = append(stats.TopPosters, poster)
+
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	err = queryRows(tx, selectBusiestThreads, append(window, options.Limit), func(rows *pgx.Rows) error {
+		var thread models.BusyThread
+		if err := rows.Scan(&thread.Id, &thread.Slug, &thread.Title, &thread.Posts); err != nil {
+			return err
+		}
+
+		if models.IsUUID(thread.Slug) {
+			thread.Slug = ""
+		}
+		stats.BusiestThreads = append(stats.BusiestThreads, thread)
+
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	err = queryRows(tx, selectDepths, window, func(rows *pgx.Rows) error {
+		var depth models.DepthCount
+		if err := rows.Scan(&depth.Depth, &depth.Posts); err != nil {
+			return err
+		}
+
+		stats.Depths = append(stats.Depths, depth)
+
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	return stats, tx.Commit()
+}
+
+func queryRows(tx *pgx.Tx, query string, args []interface{}, scan func(rows *pgx.Rows) error) error {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
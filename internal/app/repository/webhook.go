@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"github.com/go-openapi/strfmt"
+	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/pgtype"
+	"time"
+	"tp-db-forum/internal/app/models"
+)
+
+func (p *postgresAppRepository) InsertWebhook(webhook models.Webhook) (models.Webhook, error) {
+	if webhook.Topics == nil {
+		webhook.Topics = []string{}
+	}
+
+	var topics pgtype.TextArray
+	err := topics.Set(webhook.Topics)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+
+	var created time.Time
+	err = p.Conn.QueryRow(
+		`INSERT INTO webhook(url, secret, topics) VALUES ($1, $2, $3) RETURNING id, created`,
+		webhook.Url,
+		webhook.Secret,
+		&topics,
+	).Scan(&webhook.Id, &created)
+
+	webhook.Created = strfmt.DateTime(created.UTC()).String()
+
+	return webhook, err
+}
+
+func (p *postgresAppRepository) SelectWebhooks() ([]models.Webhook, error) {
+	rows, err := p.Conn.Query(`SELECT id, url, topics, created FROM webhook ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	webhooks := make([]models.Webhook, 0)
+	for rows.Next() {
+		var webhook models.Webhook
+		var created time.Time
+
+		err = rows.Scan(&webhook.Id, &webhook.Url, &webhook.Topics, &created)
+		if err != nil {
+			return nil, err
+		}
+
+		webhook.Created = strfmt.DateTime(created.UTC()).String()
+
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+func (p *postgresAppRepository) DeleteWebhook(id int) error {
+	tag, err := p.Conn.Exec(`DELETE FROM webhook WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+func (p *postgresAppRepository) FanOutOutbox(limit int) (int, error) {
+	var events int
+	err := p.Conn.QueryRow(
+		`WITH events AS (
+			UPDATE outbox SET dispatched = TRUE
+			WHERE id IN (SELECT id FROM outbox WHERE NOT dispatched ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED)
+			RETURNING id, topic
+		), deliveries AS (
+			INSERT INTO webhook_delivery(id_outbox, id_webhook)
+			SELECT e.id, w.id FROM events e JOIN webhook w
+			ON cardinality(w.topics) = 0 OR e.topic = ANY(w.topics) OR split_part(e.topic, '.', 1) = ANY(w.topics)
+			RETURNING 1
+		)
+		SELECT COUNT(*) FROM events`,
+		limit,
+	).Scan(&events)
+
+	return events, err
+}
+
+func scanDeliveries(rows *pgx.Rows) ([]models.WebhookDelivery, error) {
+	defer rows.Close()
+
+	deliveries := make([]models.WebhookDelivery, 0)
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		var payload string
+		var lastError *string
+		var nextAttempt, created time.Time
+
+		err := rows.Scan(
+			&delivery.Id,
+			&delivery.Webhook,
+			&delivery.Status,
+			&delivery.Attempts,
+			&nextAttempt,
+			&lastError,
+			&delivery.Url,
+			&delivery.Secret,
+			&delivery.Event.Id,
+			&delivery.Event.Topic,
+			&payload,
+			&created,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		delivery.NextAttempt = strfmt.DateTime(nextAttempt.UTC()).String()
+		if lastError != nil {
+			delivery.LastError = *lastError
+		}
+		delivery.Event.Data = []byte(payload)
+		delivery.Event.Created = strfmt.DateTime(created.UTC()).String()
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (p *postgresAppRepository) ClaimDueDeliveries(limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	rows, err := p.Conn.Query(
+		`WITH claimed AS (
+			UPDATE webhook_delivery SET next_attempt = NOW() + $2 * INTERVAL '1 millisecond'
+			WHERE id IN (
+				SELECT id FROM webhook_delivery WHERE status = 'pending' AND next_attempt <= NOW()
+				ORDER BY next_attempt LIMIT $1 FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *
+		)
+		SELECT c.id, c.id_webhook, c.status, c.attempts, c.next_attempt, c.last_error,
+			w.url, w.secret, o.id, o.topic, o.payload::text, o.created
+		FROM claimed c
+		JOIN webhook w ON w.id = c.id_webhook
+		JOIN outbox o ON o.id = c.id_outbox
+		ORDER BY o.id`,
+		limit,
+		lease.Milliseconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanDeliveries(rows)
+}
+
+func (p *postgresAppRepository) UpdateDelivery(delivery models.WebhookDelivery, retryIn time.Duration) error {
+	_, err := p.Conn.Exec(
+		`UPDATE webhook_delivery SET status=$1, attempts=$2, last_error=NULLIF($3, ''),
+			next_attempt = NOW() + $4 * INTERVAL '1 millisecond'
+		WHERE id=$5`,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.LastError,
+		retryIn.Milliseconds(),
+		delivery.Id,
+	)
+
+	return err
+}
+
+func (p *postgresAppRepository) SelectDeadDeliveries(limit int) ([]models.WebhookDelivery, error) {
+	rows, err := p.Conn.Query(
+		`SELECT d.id, d.id_webhook, d.status, d.attempts, d.next_attempt, d.last_error,
+			w.url, w.secret, o.id, o.topic, o.payload::text, o.created
+		FROM webhook_delivery d
+		JOIN webhook w ON w.id = d.id_webhook
+		JOIN outbox o ON o.id = d.id_outbox
+		WHERE d.status = 'dead'
+		ORDER BY d.id DESC LIMIT NULLIF($1, 0)`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanDeliveries(rows)
+}
+
+func (p *postgresAppRepository) ReplayDelivery(id int64) error {
+	tag, err := p.Conn.Exec(
+		`UPDATE webhook_delivery SET status='pending', attempts=0, last_error=NULL, next_attempt=NOW() WHERE id=$1`,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+func (p *postgresAppRepository) ReplayOutboxEvent(id int64) (int, error) {
+	tx, err := p.Conn.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	var topic string
+	err = tx.QueryRow(`SELECT topic FROM outbox WHERE id=$1`, id).Scan(&topic)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(
+		`UPDATE webhook_delivery SET status='pending', attempts=0, last_error=NULL, next_attempt=NOW()
+		WHERE id_outbox=$1`,
+		id,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO webhook_delivery(id_outbox, id_webhook)
+		SELECT $1, w.id FROM webhook w
+		WHERE (cardinality(w.topics) = 0 OR $2 = ANY(w.topics) OR split_part($2, '.', 1) = ANY(w.topics))
+		AND NOT EXISTS (SELECT 1 FROM webhook_delivery d WHERE d.id_outbox = $1 AND d.id_webhook = w.id)`,
+		id,
+		topic,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM webhook_delivery WHERE id_outbox=$1`, id).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, tx.Commit()
+}
+
+func (p *postgresAppRepository) PruneOutbox(olderThan time.Duration) error {
+	_, err := p.Conn.Exec(
+		`DELETE FROM webhook_delivery
+		WHERE status = 'delivered' AND next_attempt < NOW() - $1 * INTERVAL '1 millisecond'`,
+		olderThan.Milliseconds(),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.Conn.Exec(
+		`DELETE FROM outbox o
+		WHERE dispatched AND created < NOW() - $1 * INTERVAL '1 millisecond'
+		AND NOT EXISTS (SELECT 1 FROM webhook_delivery d WHERE d.id_outbox = o.id)`,
+		olderThan.Milliseconds(),
+	)
+
+	return err
+}
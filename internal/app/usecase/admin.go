@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"fmt"
+	"tp-db-forum/internal/app/models"
+)
+
+func (a appUseCase) LockThread(id int, locked bool) error {
+	return a.appRepository.LockThread(id, locked)
+}
+
+func (a appUseCase) DeleteThread(id int) error {
+	return a.appRepository.ModerateThread(id, true)
+}
+
+func (a appUseCase) RestoreThread(id int) error {
+	return a.appRepository.ModerateThread(id, false)
+}
+
+func (a appUseCase) DeletePost(id int) error {
+	return a.appRepository.ModeratePost(id, true)
+}
+
+func (a appUseCase) RestorePost(id int) error {
+	return a.appRepository.ModeratePost(id, false)
+}
+
+func (a appUseCase) CheckModeration(kind string, id int) (models.Moderation, error) {
+	return a.appRepository.SelectModeration(kind, id)
+}
+
+// RenameUser gives a user a new nickname, which may also change only the
+// case of the old one.
+func (a appUseCase) RenameUser(nickname, newNickname string) (models.User, error) {
+	if newNickname == "" || nickname == newNickname {
+		return models.User{}, fmt.Errorf("can't rename %s to %q", nickname, newNickname)
+	}
+
+	return a.appRepository.RenameUser(nickname, newNickname)
+}
+
+func (a appUseCase) ReconcileCounters(fix bool) (models.CounterReport, error) {
+	return a.appRepository.ReconcileCounters(fix)
+}
+
+func (a appUseCase) ReconcileForumUsers(slug string, fix bool) (models.MembershipReport, error) {
+	return a.appRepository.ReconcileForumUsers(slug, fix)
+}
+
+func (a appUseCase) Migrate() ([]models.Migration, error) {
+	return a.appRepository.Migrate()
+}
+
+func (a appUseCase) CheckMigrations() ([]models.Migration, error) {
+	return a.appRepository.SelectMigrations()
+}
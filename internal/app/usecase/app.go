@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"github.com/google/uuid"
+	"github.com/jackc/pgx"
 	"tp-db-forum/internal/app"
 	"tp-db-forum/internal/app/models"
 )
@@ -45,6 +46,11 @@ func (a appUseCase) HasUser(user models.User) ([]models.User, error) {
 
 func (a appUseCase) EditUser(newUser models.User) (models.User, error) {
 	u, err := a.appRepository.UpdateUser(newUser)
+	if err == pgx.ErrNoRows && newUser.Version != 0 {
+		if _, err := a.appRepository.SelectUserByNickname(newUser.Nickname); err == nil {
+			return models.User{}, models.ErrVersionMismatch
+		}
+	}
 
 	return u, err
 }
@@ -91,13 +97,32 @@ func (a appUseCase) CheckThreadById(id int) (models.Thread, error) {
 }
 
 func (a appUseCase) CreatePosts(posts []models.Post, id int) ([]models.Post, error) {
-	result, err := a.appRepository.InsertPosts(posts, id)
+	if len(posts) == 0 {
+		return []models.Post{}, nil
+	}
+
+	forum, err := a.appRepository.SelectForumSlugByThreadId(id)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := a.appRepository.InsertPosts(posts, id, forum)
 
 	return result, err
 }
 
 func (a appUseCase) EditThread(thread models.Thread) (models.Thread, error) {
 	newThread, err := a.appRepository.UpdateThread(thread)
+	if err == pgx.ErrNoRows && thread.Version != 0 {
+		if thread.Slug == "" {
+			_, err = a.appRepository.SelectThreadById(thread.Id)
+		} else {
+			_, err = a.appRepository.SelectThreadBySlug(thread.Slug)
+		}
+		if err == nil {
+			return models.Thread{}, models.ErrVersionMismatch
+		}
+	}
 
 	return newThread, err
 }
@@ -114,8 +139,16 @@ func (a appUseCase) UpdateVote(vote models.Vote) (models.Vote, error) {
 	return newVote, err
 }
 
-func (a appUseCase) GetServiceStatus() (map[string]int, error) {
-	return a.appRepository.GetServiceStatus()
+func (a appUseCase) AddPostVote(vote models.Vote) (models.Vote, error) {
+	return a.appRepository.InsertPostVote(vote)
+}
+
+func (a appUseCase) UpdatePostVote(vote models.Vote) (models.Vote, error) {
+	return a.appRepository.UpdatePostVote(vote)
+}
+
+func (a appUseCase) GetServiceStatus(estimate bool) (map[string]int, error) {
+	return a.appRepository.GetServiceStatus(estimate)
 }
 
 func (a appUseCase) ClearDatabase() error {
@@ -134,6 +167,14 @@ func (a appUseCase) CheckThreadsByForum(slugForum string, parameters models.Quer
 	return threads, err
 }
 
+func (a appUseCase) StreamUsersByForum(slugForum string, parameters models.QueryParameters, each func(models.User) error) error {
+	return a.appRepository.StreamUsersByForum(slugForum, parameters, each)
+}
+
+func (a appUseCase) StreamThreadsByForum(slugForum string, parameters models.QueryParameters, each func(models.Thread) error) error {
+	return a.appRepository.StreamThreadsByForum(slugForum, parameters, each)
+}
+
 func (a appUseCase) CheckPostById(id int, related []string) (map[string]interface{}, error) {
 	post, err := a.appRepository.SelectPostById(id)
 	if err != nil {
@@ -183,8 +224,13 @@ func (a appUseCase) CheckPostById(id int, related []string) (map[string]interfac
 	return data, nil
 }
 
-func (a appUseCase) EditPost(id int, message string) (models.Post, error) {
-	post, err := a.appRepository.UpdatePost(id, message)
+func (a appUseCase) EditPost(id int, message string, version int64) (models.Post, error) {
+	post, err := a.appRepository.UpdatePost(id, message, version)
+	if err == pgx.ErrNoRows && version != 0 {
+		if _, err := a.appRepository.SelectPostById(id); err == nil {
+			return models.Post{}, models.ErrVersionMismatch
+		}
+	}
 
 	return post, err
 }
@@ -195,6 +241,10 @@ func (a appUseCase) CheckPostsByThread(thread models.Thread, limit, since int, s
 	return posts, err
 }
 
+func (a appUseCase) StreamPostsByThread(thread models.Thread, limit, since int, sort string, desc bool, each func(models.Post) error) error {
+	return a.appRepository.StreamPostsByThread(thread, limit, since, sort, desc, each)
+}
+
 func (a appUseCase) CheckThreadByForum(forum string) (models.Thread, error) {
 	thread, err := a.appRepository.SelectThreadByForum(forum)
 
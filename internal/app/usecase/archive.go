@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"fmt"
+	"io"
+	"tp-db-forum/internal/app/archive"
+	"tp-db-forum/internal/app/models"
+)
+
+// ExportForum writes the archive of a forum to w. It returns pgx.ErrNoRows,
+// having written nothing, when there is no such forum. On other errors the
+// archive is left without its trailer, so it can't be imported.
+func (a appUseCase) ExportForum(slug string, w io.Writer) error {
+	writer := archive.NewWriter(w, slug)
+	if err := a.appRepository.ExportForum(slug, writer.Write); err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+func (a appUseCase) ImportForum(r io.Reader, options models.ImportOptions) (models.ImportReport, error) {
+	if options.Users == "" {
+		options.Users = models.ConflictSkip
+	}
+	if options.Slugs == "" {
+		options.Slugs = models.ConflictFail
+	}
+
+	switch options.Users {
+	case models.ConflictSkip, models.ConflictOverwrite, models.ConflictFail:
+	default:
+		return models.ImportReport{}, fmt.Errorf("%w: users policy must be skip, overwrite or fail", models.ErrInvalidImportOptions)
+	}
+
+	switch options.Slugs {
+	case models.ConflictRename, models.ConflictFail:
+	default:
+		return models.ImportReport{}, fmt.Errorf("%w: slugs policy must be rename or fail", models.ErrInvalidImportOptions)
+	}
+
+	reader, err := archive.NewReader(r)
+	if err != nil {
+		return models.ImportReport{}, err
+	}
+
+	return a.appRepository.ImportForum(reader.Next, options)
+}
@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"strconv"
+	"tp-db-forum/internal/app/models"
+)
+
+func (a appUseCase) CheckUsersByNicknames(nicknames []string) ([]models.User, error) {
+	if len(nicknames) == 0 {
+		return nil, nil
+	}
+
+	return a.appRepository.SelectUsersByNicknames(nicknames)
+}
+
+func (a appUseCase) CheckForumsBySlugs(slugs []string) ([]models.Forum, error) {
+	if len(slugs) == 0 {
+		return nil, nil
+	}
+
+	return a.appRepository.SelectForumsBySlugs(slugs)
+}
+
+func (a appUseCase) CheckThreadsByIds(ids []int) ([]models.Thread, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	return a.appRepository.SelectThreadsByIds(ids)
+}
+
+// CheckThreadsByKeys looks threads up by keys that are either numeric ids or
+// slugs, the same way the /api/thread/{slug_or_id} routes do.
+func (a appUseCase) CheckThreadsByKeys(keys []string) ([]models.Thread, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	var ids []int
+	var slugs []string
+	for _, key := range keys {
+		if id, err := strconv.Atoi(key); err == nil {
+			ids = append(ids, id)
+		} else {
+			slugs = append(slugs, key)
+		}
+	}
+
+	return a.appRepository.SelectThreadsByIdsOrSlugs(ids, slugs)
+}
+
+func (a appUseCase) CheckPostsByIds(ids []int) ([]models.Post, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	return a.appRepository.SelectPostsByIds(ids)
+}
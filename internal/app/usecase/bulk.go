@@ -0,0 +1,7 @@
+package usecase
+
+import "tp-db-forum/internal/app/models"
+
+func (a appUseCase) BulkLoad(sources models.BulkSources) (models.BulkReport, error) {
+	return a.appRepository.BulkLoad(sources)
+}
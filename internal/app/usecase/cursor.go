@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"tp-db-forum/internal/app/models"
+)
+
+func threadsPage(threads []models.Thread, desc, fromCursor, prev, hasMore bool) models.Page {
+	return models.NewPage(len(threads), fromCursor, prev, hasMore,
+		func(prev bool) models.Cursor { return models.ThreadCursor(threads[0], desc, prev) },
+		func(prev bool) models.Cursor { return models.ThreadCursor(threads[len(threads)-1], desc, prev) },
+	)
+}
+
+func usersPage(users []models.User, desc, fromCursor, prev, hasMore bool) models.Page {
+	return models.NewPage(len(users), fromCursor, prev, hasMore,
+		func(prev bool) models.Cursor { return models.UserCursor(users[0], desc, prev) },
+		func(prev bool) models.Cursor { return models.UserCursor(users[len(users)-1], desc, prev) },
+	)
+}
+
+func postsPage(posts []models.Post, sort string, desc, fromCursor, prev, hasMore bool) models.Page {
+	return models.NewPage(len(posts), fromCursor, prev, hasMore,
+		func(prev bool) models.Cursor { return models.PostCursor(posts[0], sort, desc, prev) },
+		func(prev bool) models.Cursor { return models.PostCursor(posts[len(posts)-1], sort, desc, prev) },
+	)
+}
+
+func (a appUseCase) CheckThreadsPageByForum(slugForum string, parameters models.QueryParameters) ([]models.Thread, models.Page, error) {
+	if parameters.Cursor == nil {
+		threads, err := a.appRepository.SelectThreadsByForum(slugForum, parameters)
+		if err != nil {
+			return nil, models.Page{}, err
+		}
+
+		hasMore := parameters.Limit > 0 && len(threads) == parameters.Limit
+
+		return threads, threadsPage(threads, parameters.Desc, parameters.Since != "", false, hasMore), nil
+	}
+
+	cursor := *parameters.Cursor
+	if cursor.Sort != "created" {
+		return nil, models.Page{}, models.ErrInvalidCursor
+	}
+
+	threads, hasMore, err := a.appRepository.SelectThreadsByForumCursor(slugForum, parameters.Limit, cursor)
+	if err != nil {
+		return nil, models.Page{}, err
+	}
+
+	return threads, threadsPage(threads, cursor.Desc, true, cursor.Prev, hasMore), nil
+}
+
+func (a appUseCase) CheckUsersPageByForum(slugForum string, parameters models.QueryParameters) ([]models.User, models.Page, error) {
+	if parameters.Cursor == nil {
+		users, err := a.appRepository.SelectUsersByForum(slugForum, parameters)
+		if err != nil {
+			return nil, models.Page{}, err
+		}
+
+		hasMore := parameters.Limit > 0 && len(users) == parameters.Limit
+
+		return users, usersPage(users, parameters.Desc, parameters.Since != "", false, hasMore), nil
+	}
+
+	cursor := *parameters.Cursor
+	if cursor.Sort != "nickname" {
+		return nil, models.Page{}, models.ErrInvalidCursor
+	}
+
+	users, hasMore, err := a.appRepository.SelectUsersByForumCursor(slugForum, parameters.Limit, cursor)
+	if err != nil {
+		return nil, models.Page{}, err
+	}
+
+	return users, usersPage(users, cursor.Desc, true, cursor.Prev, hasMore), nil
+}
+
+func (a appUseCase) CheckPostsPageByThread(thread models.Thread, limit, since int, sort string, desc bool, cursor *models.Cursor) ([]models.Post, models.Page, error) {
+	if cursor == nil {
+		posts, err := a.appRepository.SelectPostsByThread(thread, limit, since, sort, desc)
+		if err != nil {
+			return nil, models.Page{}, err
+		}
+
+		var hasMore bool
+		if sort == "parent_tree" {
+			roots := 0
+			for _, post := range posts {
+				if !post.Parent.Valid || post.Parent.Int64 == 0 {
+					roots++
+				}
+			}
+			hasMore = limit > 0 && roots == limit
+		} else {
+			hasMore = limit > 0 && len(posts) == limit
+		}
+
+		return posts, postsPage(posts, sort, desc, since != 0, false, hasMore), nil
+	}
+
+	if cursor.Sort != "flat" && cursor.Sort != "tree" && cursor.Sort != "parent_tree" && cursor.Sort != "score_tree" {
+		return nil, models.Page{}, models.ErrInvalidCursor
+	}
+
+	posts, hasMore, err := a.appRepository.SelectPostsByThreadCursor(thread, limit, *cursor)
+	if err != nil {
+		return nil, models.Page{}, err
+	}
+
+	return posts, postsPage(posts, cursor.Sort, cursor.Desc, true, cursor.Prev, hasMore), nil
+}
+
+func (a appUseCase) CountThreadsByForum(slugForum string, estimate bool) (int, error) {
+	return a.appRepository.CountThreadsByForum(slugForum, estimate)
+}
+
+func (a appUseCase) CountUsersByForum(slugForum string, estimate bool) (int, error) {
+	return a.appRepository.CountUsersByForum(slugForum, estimate)
+}
+
+func (a appUseCase) CountPostsByThread(thread models.Thread, estimate bool) (int, error) {
+	return a.appRepository.CountPostsByThread(thread, estimate)
+}
@@ -0,0 +1,26 @@
+package usecase
+
+import "tp-db-forum/internal/app/models"
+
+func (a appUseCase) CheckUserProfile(nickname string) (models.UserProfile, error) {
+	user, err := a.appRepository.SelectUserByNickname(nickname)
+	if err != nil {
+		return models.UserProfile{}, err
+	}
+
+	reputation, err := a.appRepository.SelectReputation(user.Nickname)
+	if err != nil {
+		return models.UserProfile{}, err
+	}
+
+	return models.UserProfile{User: user, Reputation: reputation}, nil
+}
+
+func (a appUseCase) CheckLeaderboard(options models.LeaderboardOptions) (models.Leaderboard, error) {
+	options, err := options.Normalize()
+	if err != nil {
+		return models.Leaderboard{}, err
+	}
+
+	return a.appRepository.SelectLeaderboard(options)
+}
@@ -0,0 +1,15 @@
+package usecase
+
+import (
+	"time"
+	"tp-db-forum/internal/app/models"
+)
+
+func (a appUseCase) CheckForumStats(slug string, options models.StatsOptions) (models.ForumStats, error) {
+	options, err := options.Normalize(time.Now())
+	if err != nil {
+		return models.ForumStats{}, err
+	}
+
+	return a.appRepository.SelectForumStats(slug, options)
+}
@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"github.com/go-openapi/strfmt"
+	"time"
+	"tp-db-forum/configs"
+	"tp-db-forum/internal/app/models"
+)
+
+var started = time.Now()
+
+func (a appUseCase) CheckServiceInfo(estimate bool) (models.ServiceInfo, error) {
+	info := models.ServiceInfo{
+		Estimated: estimate,
+		Version:   configs.Version,
+		Started:   strfmt.DateTime(started.UTC()).String(),
+		Uptime:    int64(time.Since(started) / time.Second),
+		Pool:      a.appRepository.SelectPoolStats(),
+	}
+
+	status, err := a.appRepository.GetServiceStatus(estimate)
+	if err != nil {
+		return info, err
+	}
+	info.Status = status
+
+	migrations, err := a.appRepository.SelectMigrations()
+	if err != nil {
+		return info, err
+	}
+	for _, migration := range migrations {
+		if migration.Applied != "" && migration.Version > info.Migration {
+			info.Migration = migration.Version
+		}
+	}
+
+	return info, nil
+}
@@ -0,0 +1,41 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"tp-db-forum/internal/app/models"
+)
+
+func (a appUseCase) CreateWebhook(webhook models.Webhook) (models.Webhook, error) {
+	if webhook.Secret == "" {
+		secret := make([]byte, 32)
+		_, err := rand.Read(secret)
+		if err != nil {
+			return models.Webhook{}, err
+		}
+
+		webhook.Secret = hex.EncodeToString(secret)
+	}
+
+	return a.appRepository.InsertWebhook(webhook)
+}
+
+func (a appUseCase) CheckWebhooks() ([]models.Webhook, error) {
+	return a.appRepository.SelectWebhooks()
+}
+
+func (a appUseCase) RemoveWebhook(id int) error {
+	return a.appRepository.DeleteWebhook(id)
+}
+
+func (a appUseCase) CheckDeadDeliveries(limit int) ([]models.WebhookDelivery, error) {
+	return a.appRepository.SelectDeadDeliveries(limit)
+}
+
+func (a appUseCase) ReplayDelivery(id int64) error {
+	return a.appRepository.ReplayDelivery(id)
+}
+
+func (a appUseCase) ReplayEvent(id int64) (int, error) {
+	return a.appRepository.ReplayOutboxEvent(id)
+}
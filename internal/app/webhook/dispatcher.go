@@ -0,0 +1,205 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"tp-db-forum/internal/app"
+	"tp-db-forum/internal/app/models"
+)
+
+const (
+	SignatureHeader = "X-Forum-Signature"
+	TimestampHeader = "X-Forum-Timestamp"
+	EventHeader     = "X-Forum-Event"
+	DeliveryHeader  = "X-Forum-Delivery"
+)
+
+type Config struct {
+	Interval    time.Duration
+	BatchSize   int
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	Timeout     time.Duration
+	Retention   time.Duration
+}
+
+var DefaultConfig = Config{
+	Interval:    time.Second,
+	BatchSize:   100,
+	MaxAttempts: 8,
+	Backoff:     5 * time.Second,
+	MaxBackoff:  time.Hour,
+	Timeout:     10 * time.Second,
+	Retention:   7 * 24 * time.Hour,
+}
+
+// Dispatcher turns outbox rows into deliveries for the registered webhooks
+// and posts them, retrying failed ones with exponential backoff until they
+// are delivered or moved to the dead-letter list.
+type Dispatcher struct {
+	repository app.Repository
+	client     *http.Client
+	config     Config
+}
+
+func NewDispatcher(repository app.Repository, config Config) *Dispatcher {
+	return &Dispatcher{
+		repository: repository,
+		client:     &http.Client{Timeout: config.Timeout},
+		config:     config,
+	}
+}
+
+// Sign returns the signature sent in SignatureHeader: a hex HMAC-SHA256 of
+// the timestamp and the body joined by a dot.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (d *Dispatcher) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+
+	lastPrune := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		for {
+			events, err := d.repository.FanOutOutbox(d.config.BatchSize)
+			if err != nil {
+				log.Printf("webhook: fan out: %v", err)
+				break
+			}
+
+			if events < d.config.BatchSize {
+				break
+			}
+		}
+
+		for {
+			deliveries, err := d.repository.ClaimDueDeliveries(d.config.BatchSize, 2*d.config.Timeout)
+			if err != nil {
+				log.Printf("webhook: claim deliveries: %v", err)
+				break
+			}
+
+			// The batch is sent at once, so every delivery is done within
+			// one client timeout, well inside its lease.
+			var wg sync.WaitGroup
+			for _, delivery := range deliveries {
+				wg.Add(1)
+				go func(delivery models.WebhookDelivery) {
+					defer wg.Done()
+					d.deliver(delivery)
+				}(delivery)
+			}
+			wg.Wait()
+
+			if len(deliveries) < d.config.BatchSize {
+				break
+			}
+		}
+
+		if time.Since(lastPrune) > time.Hour {
+			lastPrune = time.Now()
+			if err := d.repository.PruneOutbox(d.config.Retention); err != nil {
+				log.Printf("webhook: prune outbox: %v", err)
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	backoff := d.config.Backoff
+	for i := 1; i < attempts && backoff < d.config.MaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > d.config.MaxBackoff {
+		backoff = d.config.MaxBackoff
+	}
+
+	return backoff
+}
+
+func (d *Dispatcher) deliver(delivery models.WebhookDelivery) {
+	delivery.Attempts++
+
+	err := d.send(delivery)
+	if err == nil {
+		delivery.Status = models.DeliveryDelivered
+		delivery.LastError = ""
+
+		if err := d.repository.UpdateDelivery(delivery, 0); err != nil {
+			log.Printf("webhook: update delivery %d: %v", delivery.Id, err)
+		}
+
+		return
+	}
+
+	delivery.LastError = err.Error()
+
+	retryIn := d.backoff(delivery.Attempts)
+	if delivery.Attempts >= d.config.MaxAttempts {
+		delivery.Status = models.DeliveryDead
+		retryIn = 0
+	}
+
+	if err := d.repository.UpdateDelivery(delivery, retryIn); err != nil {
+		log.Printf("webhook: update delivery %d: %v", delivery.Id, err)
+	}
+}
+
+func (d *Dispatcher) send(delivery models.WebhookDelivery) error {
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, delivery.Url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(EventHeader, delivery.Event.Topic)
+	request.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.Id, 10))
+	request.Header.Set(TimestampHeader, timestamp)
+	request.Header.Set(SignatureHeader, Sign(delivery.Secret, timestamp, body))
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(response.Body, 1<<16))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+
+	return nil
+}